@@ -0,0 +1,263 @@
+//
+// Binary.go
+//
+// This file contains functionality for jobs over binary keys and values (e.g. protobuf
+// blobs), which may not be valid UTF-8 and so cannot be stored in KeyValues as they are.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/base64"
+)
+
+//
+// BinaryKeyValue
+//
+// A key/value pair of arbitrary bytes, as emitted by a binary Map function (see MapBinary)
+// and passed to a binary Reduce function (see ReduceBinary). It is stored in files as a
+// KeyValue whose key and value are base64-encoded (see encodeBinaryKeyValue), so every byte
+// survives the round trip, where JSON would replace invalid UTF-8.
+//
+type BinaryKeyValue struct {
+	Key   []byte
+	Value []byte
+}
+
+//
+// doMapBinary
+//
+// Does the job of a binary map worker with the default configuration (see
+// Worker.MapBinary).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined, binary Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapBinary(
+	jobName       string,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents []byte) []BinaryKeyValue,
+) (MapStats, error) {
+	return (&Worker{JobName: jobName}).MapBinary(mapTaskNumber, inFile, nReduce, mapFunc)
+}
+
+//
+// MapBinary
+//
+// This function does the job of a map worker like Map, but passes the input file's contents
+// to the Map function as bytes, and takes its output as BinaryKeyValues, which are written
+// base64-encoded. Keys are partitioned (and sorted, see Worker.SortPartitions) by their
+// encoded form, which groups equal keys as their bytes do, but does not order them as their
+// bytes do. The intermediate files must be reduced with ReduceBinary.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined, binary Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapBinary(
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents []byte) []BinaryKeyValue,
+) (MapStats, error) {
+	records := func(yield func([]byte, error) bool) {
+		contentBytes, err := readStorageFile(w.storage(), inFile)
+
+		if err == nil && len(contentBytes) == 0 {
+			// Empty file: no records (see fileContents)
+			return
+		}
+
+		yield(contentBytes, err)
+	}
+
+	return mapRecords(w, mapTaskNumber, inFile, 0, nReduce, records, func(file string, contents []byte) ([]KeyValue, error) {
+		binaryKeyValues := mapFunc(file, contents)
+		keyValues       := make([]KeyValue, len(binaryKeyValues))
+
+		for i, bkv := range binaryKeyValues {
+			keyValues[i] = encodeBinaryKeyValue(bkv)
+		}
+
+		return keyValues, nil
+	})
+}
+
+//
+// doReduceBinary
+//
+// Does the job of a binary reduce worker with the default configuration (see
+// Worker.ReduceBinary).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined, binary Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func doReduceBinary(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key []byte, values [][]byte) []byte,
+) (string, error) {
+	return (&Worker{JobName: jobName}).ReduceBinary(reduceTaskNumber, nMap, reduceFunc)
+}
+
+//
+// ReduceBinary
+//
+// This function does the job of a reduce worker like Reduce, over the intermediate files of
+// binary Map tasks (see MapBinary): it decodes each key and value to its bytes before
+// calling the Reduce function, and encodes its result. The merge file holds base64-encoded
+// KeyValues, whose bytes are recovered with readBinaryKeyValues. A key or value that is not
+// valid base64 fails the task with ErrReduceFunc.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined, binary Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func (w *Worker) ReduceBinary(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key []byte, values [][]byte) []byte,
+) (string, error) {
+	return w.Reduce(reduceTaskNumber, nMap, binaryReduceFunc(reduceFunc))
+}
+
+//
+// binaryReduceFunc
+//
+// Adapts a binary Reduce function to base64-encoded string keys and values.
+// *NOTE* A key or value that cannot be decoded panics with a valueCodecError, which
+//        callReduce converts back into the task's error (as in typedReduceFunc)
+//
+//      reduceFunc - the user-defined, binary Reduce function
+//
+// Returns the adapted Reduce function.
+//
+func binaryReduceFunc(
+	reduceFunc func(key []byte, values [][]byte) []byte,
+) func(key string, values []string) string {
+	return func(key string, values []string) string {
+		keyBytes, err := base64.StdEncoding.DecodeString(key)
+
+		if err != nil {
+			panic(valueCodecError{err})
+		}
+
+		valueBytes := make([][]byte, len(values))
+
+		for i, value := range values {
+			valueBytes[i], err = base64.StdEncoding.DecodeString(value)
+
+			if err != nil {
+				panic(valueCodecError{err})
+			}
+		}
+
+		return base64.StdEncoding.EncodeToString(reduceFunc(keyBytes, valueBytes))
+	}
+}
+
+//
+// encodeBinaryKeyValue
+//
+// Encodes a BinaryKeyValue as a KeyValue, with its key and value in standard base64.
+// *NOTE* Encoded values are never "error" (whose length is not a multiple of 4), so a
+//        binary result cannot be mistaken for a failed Reduce function
+//
+//      bkv - the BinaryKeyValue to encode
+//
+// Returns the encoded KeyValue.
+//
+func encodeBinaryKeyValue(bkv BinaryKeyValue) KeyValue {
+	return KeyValue{
+		Key:   base64.StdEncoding.EncodeToString(bkv.Key),
+		Value: base64.StdEncoding.EncodeToString(bkv.Value),
+	}
+}
+
+//
+// decodeBinaryKeyValue
+//
+// Decodes a KeyValue encoded by encodeBinaryKeyValue back into its bytes.
+//
+//      kv - the KeyValue to decode
+//
+// Returns the decoded BinaryKeyValue, and nil on success. Otherwise, the error decoding it.
+//
+func decodeBinaryKeyValue(kv KeyValue) (BinaryKeyValue, error) {
+	var bkv BinaryKeyValue = BinaryKeyValue{}
+	var err error          = nil
+
+	bkv.Key, err = base64.StdEncoding.DecodeString(kv.Key)
+
+	if err == nil {
+		bkv.Value, err = base64.StdEncoding.DecodeString(kv.Value)
+	}
+
+	return bkv, err
+}
+
+//
+// readBinaryKeyValues
+//
+// Reads the BinaryKeyValues of a file written by a binary job, e.g. the merge file of
+// ReduceBinary.
+//
+//      storage  - the storage backend holding the file
+//      fileName - the name of the file
+//
+// Returns the decoded BinaryKeyValues, and nil on success. Otherwise, the error reading or
+// decoding the file.
+//
+func readBinaryKeyValues(storage Storage, fileName string) ([]BinaryKeyValue, error) {
+	var binaryKeyValues []BinaryKeyValue = nil
+
+	err := forEachKeyValue(storage, fileName, defaultReadBufferSize, func(kv KeyValue) error {
+		bkv, tempErr := decodeBinaryKeyValue(kv)
+
+		if tempErr == nil {
+			binaryKeyValues = append(binaryKeyValues, bkv)
+		}
+
+		return tempErr
+	})
+
+	return binaryKeyValues, err
+}
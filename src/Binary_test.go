@@ -0,0 +1,124 @@
+//
+// Binary_test.go
+//
+// This file contains tests for jobs over binary keys and values.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"testing"
+	"unicode/utf8"
+)
+
+//
+// TestBinaryRoundTrip
+//
+// Runs a binary job whose input, keys and values hold invalid UTF-8 (and NUL) bytes, and
+// checks that the Map function sees the input, and the Reduce function its values, byte for
+// byte, and that the merge file decodes to the exact bytes the Reduce function returned.
+//
+func TestBinaryRoundTrip(t *testing.T) {
+	w := newTestWorker(t, "binary")
+
+	input := "\xff\xfe\x00blob\n\xc3\x28\n\xff\xfe\x00blob"
+
+	if utf8.ValidString(input) {
+		t.Fatal("input is valid UTF-8")
+	}
+
+	inFile := writeInput(t, w, "input", input)
+
+	_, err := w.MapBinary(0, inFile, 2, func(file string, contents []byte) []BinaryKeyValue {
+		if string(contents) != input {
+			t.Errorf("Map function saw %q, expected %q", contents, input)
+		}
+
+		var binaryKeyValues []BinaryKeyValue = nil
+
+		for i, line := range bytes.Split(contents, []byte("\n")) {
+			binaryKeyValues = append(binaryKeyValues, BinaryKeyValue{line, []byte{0x80, byte(i), 0xff}})
+		}
+
+		return binaryKeyValues
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for r := 0; r < 2; r++ {
+		_, err = w.ReduceBinary(r, 1, func(key []byte, values [][]byte) []byte {
+			return append(slices.Clone(key), bytes.Join(values, []byte{0x00})...)
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		"\xff\xfe\x00blob": "\xff\xfe\x00blob\x80\x00\xff\x00\x80\x02\xff",
+		"\xc3\x28":         "\xc3\x28\x80\x01\xff",
+	}
+
+	output := make(map[string]string)
+
+	for r := 0; r < 2; r++ {
+		binaryKeyValues, err := readBinaryKeyValues(w.storage(), w.mergeName(r))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, bkv := range binaryKeyValues {
+			output[string(bkv.Key)] = string(bkv.Value)
+		}
+	}
+
+	checkOutput(t, output, expected)
+}
+
+//
+// TestReduceBinaryInvalidBase64
+//
+// Checks that ReduceBinary fails the task on intermediate files not written by MapBinary,
+// whose keys are not base64.
+//
+func TestReduceBinaryInvalidBase64(t *testing.T) {
+	w := newTestWorker(t, "notbinary")
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.ReduceBinary(0, 1, func(key []byte, values [][]byte) []byte {
+		return key
+	})
+
+	if !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrReduceFunc)
+	}
+}
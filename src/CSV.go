@@ -0,0 +1,165 @@
+//
+// CSV.go
+//
+// This file contains functionality for 'map' workers whose input is CSV, calling the Map
+// function once per row (see DoMap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/csv"
+	"io"
+	"iter"
+)
+
+//
+// CSVOptions
+//
+// Describes how a CSV input is parsed. The zero value parses standard, comma-separated CSV
+// without a header.
+//
+//      Comma   - the field delimiter (zero means ',')
+//      Comment - the character that starts a comment line (zero means none)
+//      Header  - whether the first row is a header, which is skipped rather than mapped
+//
+type CSVOptions struct {
+	Comma   rune
+	Comment rune
+	Header  bool
+}
+
+//
+// doMapCSV
+//
+// Does the job of a map worker over a CSV input file with the default configuration (see
+// Worker.MapCSV).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      options       - how the input is parsed
+//      mapFunc		  - the user-defined Map function, called once per row
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapCSV(
+	jobName       string,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	options       CSVOptions,
+	mapFunc       func(file string, record []string) []KeyValue,
+) (MapStats, error) {
+	w := &Worker{JobName: jobName}
+
+	file, err := w.storage().Open(inFile)
+
+	if err != nil {
+		// Error opening file
+		w.logf("Function error [CSV.doMapCSV]: %s\n", err.Error())
+		return MapStats{}, err
+	}
+
+	defer file.Close()
+
+	return w.MapCSV(mapTaskNumber, inFile, file, nReduce, options, mapFunc)
+}
+
+//
+// MapCSV
+//
+// This function does the job of a map worker like MapReader, but parses its input as CSV and
+// calls the Map function once per row, with the row's fields. Rows are parsed as they are
+// mapped, so the input is streamed rather than read whole; the reader can decompress it (e.g.
+// gzip.NewReader) or come from the network. A malformed row fails the task.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      name          - the name of the input, passed to mapFunc as its file
+//      input         - the reader of the CSV input
+//      nReduce       - the number of Reduce tasks that will be run
+//      options       - how the input is parsed
+//      mapFunc		  - the user-defined Map function, called once per row
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task. MapStats.InputRecords counts rows, not including the header.
+//
+func (w *Worker) MapCSV(
+	mapTaskNumber int,
+	name          string,
+	input         io.Reader,
+	nReduce       int,
+	options       CSVOptions,
+	mapFunc       func(file string, record []string) []KeyValue,
+) (MapStats, error) {
+	return mapRecords(w, mapTaskNumber, name, 0, nReduce, csvRecords(input, options), func(file string, record []string) ([]KeyValue, error) {
+		return mapFunc(file, record), nil
+	})
+}
+
+//
+// csvRecords
+//
+// Parses a CSV input into a sequence of rows (see mapRecords).
+//
+//      input   - the reader of the CSV input
+//      options - how the input is parsed
+//
+// Returns the sequence of the input's rows, ending with the error that ended parsing, if
+// any.
+//
+func csvRecords(input io.Reader, options CSVOptions) iter.Seq2[[]string, error] {
+	return func(yield func([]string, error) bool) {
+		reader := csv.NewReader(input)
+
+		if options.Comma != 0 {
+			reader.Comma = options.Comma
+		}
+
+		reader.Comment = options.Comment
+
+		skip := options.Header
+
+		for {
+			record, err := reader.Read()
+
+			if err == io.EOF {
+				// End of input
+				return
+			} else if err != nil {
+				// Error parsing row
+				yield(nil, err)
+				return
+			}
+
+			if skip {
+				// Header row
+				skip = false
+				continue
+			}
+
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,104 @@
+//
+// CSV_test.go
+//
+// This file contains tests for the CSV input adapter.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// testCSV
+//
+// A CSV input with a header, and fields that are quoted because they hold commas, quotes
+// and newlines.
+//
+const testCSV = "name,city\n" +
+	"\"Smith, John\",\"New York\"\n" +
+	"\"O\"\"Brien\",Boston\n" +
+	"Jane,\"Multi\nline\"\n"
+
+//
+// TestMapCSV
+//
+// Checks that MapCSV unquotes quoted fields, and skips the header only when asked to.
+//
+func TestMapCSV(t *testing.T) {
+	rows := [][]string{
+		{"name", "city"},
+		{"Smith, John", "New York"},
+		{"O\"Brien", "Boston"},
+		{"Jane", "Multi\nline"},
+	}
+
+	for _, header := range []bool{false, true} {
+		var mapped [][]string = nil
+
+		w := newTestWorker(t, "csv")
+
+		stats, err := w.MapCSV(0, "input.csv", strings.NewReader(testCSV), 1, CSVOptions{Header: header}, func(file string, record []string) []KeyValue {
+			mapped = append(mapped, record)
+			return []KeyValue{{record[0], record[1]}}
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := rows
+
+		if header {
+			expected = rows[1:]
+		}
+
+		if !slices.EqualFunc(mapped, expected, slices.Equal) || stats.InputRecords != len(expected) {
+			t.Fatalf("header %v: mapped %q (%d records), expected %q", header, mapped, stats.InputRecords, expected)
+		}
+	}
+}
+
+//
+// TestMapCSVDelimiter
+//
+// Checks that MapCSV splits fields on a custom delimiter, and leaves commas in them.
+//
+func TestMapCSVDelimiter(t *testing.T) {
+	var mapped [][]string = nil
+
+	w := newTestWorker(t, "csv")
+
+	_, err := w.MapCSV(0, "input.csv", strings.NewReader("a;\"b;c\";d,e\n"), 1, CSVOptions{Comma: ';'}, func(file string, record []string) []KeyValue {
+		mapped = append(mapped, record)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := [][]string{{"a", "b;c", "d,e"}}; !slices.EqualFunc(mapped, expected, slices.Equal) {
+		t.Fatalf("mapped %q, expected %q", mapped, expected)
+	}
+}
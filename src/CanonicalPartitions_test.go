@@ -0,0 +1,96 @@
+//
+// CanonicalPartitions_test.go
+//
+// This file contains tests for writing reproducible intermediate files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// shuffledMap
+//
+// Builds a Map function that emits each word of its input with the word's position as its
+// value, in an order shuffled by a seed, as a Map function with nondeterministic output
+// order (e.g. one ranging over a Go map) would.
+//
+//      seed - the seed of the shuffle
+//
+// Returns the Map function.
+//
+func shuffledMap(seed uint64) func(file string, contents string) []KeyValue {
+	return func(file string, contents string) []KeyValue {
+		var keyValues []KeyValue = nil
+
+		for i, word := range strings.Fields(contents) {
+			keyValues = append(keyValues, KeyValue{word, strconv.Itoa(i)})
+		}
+
+		random := rand.New(rand.NewPCG(seed, seed))
+
+		random.Shuffle(len(keyValues), func(i int, j int) {
+			keyValues[i], keyValues[j] = keyValues[j], keyValues[i]
+		})
+
+		return keyValues
+	}
+}
+
+//
+// TestCanonicalPartitions
+//
+// Runs the same Map task twice, with its KeyValues emitted in different orders, and checks
+// that with CanonicalPartitions the two runs write byte-identical intermediate files, and
+// that without it they do not.
+//
+func TestCanonicalPartitions(t *testing.T) {
+	input := strings.Repeat("the quick brown fox jumps over the lazy dog ", 10)
+
+	for _, canonical := range []bool{true, false} {
+		var runs [2][]string
+
+		for run := range runs {
+			w := newTestWorker(t, "canonical")
+			w.CanonicalPartitions = canonical
+
+			inFile := writeInput(t, w, "input", input)
+
+			if _, err := w.Map(0, inFile, 3, shuffledMap(uint64(run+1))); err != nil {
+				t.Fatal(err)
+			}
+
+			for r := 0; r < 3; r++ {
+				runs[run] = append(runs[run], readFile(t, w, w.reduceName(0, r)))
+			}
+		}
+
+		for r := 0; r < 3; r++ {
+			if identical := runs[0][r] == runs[1][r]; identical != canonical {
+				t.Fatalf("canonical %v: partition %d identical %v:\n%s\n%s", canonical, r, identical, runs[0][r], runs[1][r])
+			}
+		}
+	}
+}
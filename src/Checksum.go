@@ -0,0 +1,97 @@
+//
+// Checksum.go
+//
+// This file contains functionality for checksumming the output of a job, so that two runs
+// can be checked for identical results (e.g. in the CI of a data pipeline).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+)
+
+//
+// JobChecksum
+//
+// Computes the checksum of a job's output with the default configuration (see
+// Worker.JobChecksum).
+//
+// 		jobName - the name of the MapReduce job
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns the hex-encoded checksum, and nil on success. Otherwise, the error reading a merge
+// file.
+//
+func JobChecksum(jobName string, nReduce int) (string, error) {
+	return (&Worker{JobName: jobName}).JobChecksum(nReduce)
+}
+
+//
+// JobChecksum
+//
+// Computes a SHA-256 checksum over the KeyValue pairs of a job's merge files, which is equal
+// for two runs exactly when they produced the same KeyValue pairs. The pairs of every merge
+// file are decoded and sorted by key, then value, before they are hashed, so the checksum
+// does not depend on the order of keys within a merge file, on which partition holds a key,
+// or on how the files are compressed, named or timestamped. The merge files must be
+// JSON-encoded (see OutputFormat), and are held in memory while they are sorted.
+//
+// Each key and value is hashed with its length, so that e.g. {"ab", "c"} and {"a", "bc"} do
+// not collide.
+//
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns the hex-encoded checksum, and nil on success. Otherwise, the error reading a merge
+// file; a missing merge file is an error, as the job did not complete.
+//
+func (w *Worker) JobChecksum(nReduce int) (string, error) {
+	var keyValues []KeyValue = nil
+	var err       error      = nil
+
+	for i := 0; i < nReduce && err == nil; i++ {
+		_, err = w.storage().Stat(w.mergeName(i))
+
+		if err == nil {
+			keyValues, err = readKeyValues(w.storage(), w.mergeName(i), keyValues, w.ReadBufferSize)
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [Checksum.JobChecksum]: %s\n", err.Error())
+		return "", err
+	}
+
+	sortKeyValuesCanonical(keyValues)
+
+	hash   := sha256.New()
+	length := make([]byte, binary.MaxVarintLen64)
+
+	for _, kv := range keyValues {
+		for _, s := range []string{kv.Key, kv.Value} {
+			hash.Write(length[:binary.PutUvarint(length, uint64(len(s)))])
+			hash.Write([]byte(s))
+		}
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
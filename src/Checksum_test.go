@@ -0,0 +1,86 @@
+//
+// Checksum_test.go
+//
+// This file contains tests for job output checksums.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+//
+// TestJobChecksum
+//
+// Runs the same job twice, and checks that the checksums are equal, also when the second
+// run has other partitions and compressed merge files, and differ from a run over other
+// input.
+//
+func TestJobChecksum(t *testing.T) {
+	inputs := []string{testInput, "a fox and a dog"}
+
+	checksum := func(w *Worker, inputs []string, nReduce int) string {
+		runWordCount(t, w, inputs, nReduce)
+
+		sum, err := w.JobChecksum(nReduce)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return sum
+	}
+
+	first  := checksum(newTestWorker(t, "first"), inputs, 2)
+	second := checksum(newTestWorker(t, "second"), inputs, 2)
+
+	if first != second {
+		t.Fatalf("checksums %s and %s of the same job differ", first, second)
+	}
+
+	w := newTestWorker(t, "third")
+	w.OutputCompression = CompressionGzip
+
+	if third := checksum(w, inputs, 5); third != first {
+		t.Fatalf("checksum %s with 5 gzipped partitions, expected %s", third, first)
+	}
+
+	if other := checksum(newTestWorker(t, "other"), []string{testInput, "a fox and a cat"}, 2); other == first {
+		t.Fatalf("checksum %s of another job's output, expected it to differ", other)
+	}
+}
+
+//
+// TestJobChecksumMissing
+//
+// Checks that the checksum of a job missing a merge file is an error.
+//
+func TestJobChecksumMissing(t *testing.T) {
+	w := newTestWorker(t, "missing")
+
+	runWordCount(t, w, []string{testInput}, 2)
+
+	if _, err := w.JobChecksum(3); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("error %v, expected %v", err, fs.ErrNotExist)
+	}
+}
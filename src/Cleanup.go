@@ -0,0 +1,202 @@
+//
+// Cleanup.go
+//
+// This file contains functionality for removing the intermediate files of a job once its
+// 'reduce' workers no longer need them (see DoMap.go and DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+)
+
+//
+// CleanupJob
+//
+// Removes the intermediate files of a job with the default configuration (see
+// Worker.CleanupJob).
+//
+//      jobName - the name of the MapReduce job
+//      nMap    - the number of Map tasks that were run
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files.
+//
+func CleanupJob(jobName string, nMap int, nReduce int) error {
+	return (&Worker{JobName: jobName}).CleanupJob(nMap, nReduce)
+}
+
+//
+// CleanJob
+//
+// Removes every file of a job with the default configuration, in a storage backend (see
+// Worker.CleanJob).
+//
+//      storage - the storage backend holding the files (nil means the local filesystem)
+//      jobName - the name of the MapReduce job
+//      nMap    - the number of Map tasks that were run
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files.
+//
+func CleanJob(storage Storage, jobName string, nMap int, nReduce int) error {
+	return (&Worker{JobName: jobName, Storage: storage}).CleanJob(nMap, nReduce)
+}
+
+//
+// CleanupJob
+//
+// Removes every intermediate file of a job, once all of its Reduce tasks have completed.
+// Files that are already gone are skipped, so it is safe to call more than once, or after
+// Reduce tasks that removed their own files (see Worker.CleanupIntermediate). Merge files
+// are kept.
+//
+//      nMap    - the number of Map tasks that were run
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files (every file is attempted).
+//
+func (w *Worker) CleanupJob(nMap int, nReduce int) error {
+	var errs []error = nil
+
+	for i := 0; i < nReduce; i++ {
+		tempErr := w.removeIntermediate(i, nMap)
+
+		if tempErr != nil {
+			errs = append(errs, tempErr)
+		}
+	}
+
+	if w.CombinedLayout {
+		tempErr := w.removeCombined(nMap)
+
+		if tempErr != nil {
+			errs = append(errs, tempErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// removeIntermediate
+//
+// Removes the intermediate files read by a Reduce task (and then its partition directory, if
+// using them and it is empty). Files that are already gone are skipped.
+//
+//      reduceTaskNumber - the number of the Reduce task that read the files
+//      nMap             - the number of Map tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files (every file is attempted).
+//
+func (w *Worker) removeIntermediate(reduceTaskNumber int, nMap int) error {
+	var errs []error = nil
+
+	if w.CombinedLayout {
+		// Combined files hold every partition, so are only removed with the job (see CleanupJob)
+		return nil
+	}
+
+	if w.PreShuffled {
+		// Pre-shuffled files are written outside the job, so are left for it to remove
+		return nil
+	}
+
+	for _, i := range w.mapTasks(nMap) {
+		tempErr := w.removeIntermediateFile(w.reduceName(i, reduceTaskNumber))
+
+		if tempErr != nil {
+			errs = append(errs, tempErr)
+		}
+	}
+
+	//
+	// Remove the partition directory, if using them:
+	// *NOTE* Not treating an error as one, as the directory may still hold other files (e.g.
+	//        of Map tasks that were not read, or of another run)
+	//
+	if w.PartitionDirs && errs == nil {
+		w.storage().Remove(w.partitionDir(reduceTaskNumber))
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// CleanJob
+//
+// Removes every file of a job, in the Worker's storage backend (see Worker.Storage): its
+// intermediate files (see CleanupJob), its merge and side files (with any completion
+// markers), and its manifest. This is the teardown after a job has been consumed. Files
+// that are already gone are skipped, so it is safe to call more than once, or on a job that
+// only partly ran.
+//
+//      nMap    - the number of Map tasks that were run
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files (every file is attempted).
+//
+func (w *Worker) CleanJob(nMap int, nReduce int) error {
+	errs := []error{w.CleanupJob(nMap, nReduce)}
+
+	for i := 0; i < nReduce; i++ {
+		errs = append(errs, w.removeReduceOutput(i))
+	}
+
+	tempErr := w.storage().Remove(w.manifestName())
+
+	if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+		errs = append(errs, tempErr)
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// removeReduceOutput
+//
+// Removes the output of a Reduce task: its merge file (with any completion marker) and its
+// side files. Files that are already gone are skipped.
+//
+//      reduceTaskNumber - the number of the Reduce task
+//
+// Returns nil on success. Otherwise, the errors removing files (every file is attempted).
+//
+func (w *Worker) removeReduceOutput(reduceTaskNumber int) error {
+	var errs []error = nil
+
+	fileNames := []string{markerName(w.mergeName(reduceTaskNumber)), w.mergeName(reduceTaskNumber)}
+
+	for _, output := range w.SideOutputs {
+		fileNames = append(fileNames, w.sideName(reduceTaskNumber, output))
+	}
+
+	for _, fileName := range fileNames {
+		tempErr := w.storage().Remove(fileName)
+
+		if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+			errs = append(errs, tempErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
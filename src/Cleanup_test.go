@@ -0,0 +1,179 @@
+//
+// Cleanup_test.go
+//
+// This file contains tests for the removal of the files of failed tasks and finished jobs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// errTestWrite
+//
+// The write error injected by the cleanup tests.
+//
+var errTestWrite = errors.New("injected write error")
+
+//
+// listDir
+//
+// Lists the names of the entries of a directory, failing the test on error.
+//
+//      t   - the test
+//      dir - the directory
+//
+// Returns the sorted names of the entries.
+//
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string = nil
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	return names
+}
+
+//
+// TestFailedTaskCleanupOutDir
+//
+// Checks that a failed Map or Reduce task writing to an output directory other than the
+// working directory removes the files it created there, by their full paths, and leaves
+// files of the same base name in the working directory alone.
+//
+func TestFailedTaskCleanupOutDir(t *testing.T) {
+	chdirTemp(t)
+
+	w := newTestWorker(t, "cleanup")
+	inFile := writeInput(t, w, "input", testInput)
+
+	// Files in the working directory named like the task's outputs
+	decoys := []string{filepath.Base(w.reduceName(0, 0)), filepath.Base(w.reduceName(0, 1)), filepath.Base(w.mergeName(0))}
+
+	for _, decoy := range decoys {
+		if err := os.WriteFile(decoy, []byte("decoy"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	faulty := *w
+	faulty.Storage = &faultyStorage{Storage: OSStorage{}, writeErr: errTestWrite}
+
+	if _, err := faulty.Map(0, inFile, 2, WordCountMap); !errors.Is(err, errTestWrite) {
+		t.Fatalf("map error %v, expected %v", err, errTestWrite)
+	}
+
+	if names := listDir(t, w.OutDir); !slices.Equal(names, []string{"input"}) {
+		t.Fatalf("failed map task left %v", names)
+	}
+
+	if _, err := w.Map(0, inFile, 2, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	intermediate := listDir(t, w.OutDir)
+
+	if _, err := faulty.Reduce(0, 1, WordCountReduce); !errors.Is(err, errTestWrite) {
+		t.Fatalf("reduce error %v, expected %v", err, errTestWrite)
+	}
+
+	if names := listDir(t, w.OutDir); !slices.Equal(names, intermediate) {
+		t.Fatalf("failed reduce task left %v, expected %v", names, intermediate)
+	}
+
+	for _, decoy := range decoys {
+		if _, err := os.Stat(decoy); err != nil {
+			t.Fatalf("file %s in the working directory removed: %v", decoy, err)
+		}
+	}
+}
+
+//
+// TestCleanJob
+//
+// Runs a job to completion, with its manifest, in a MemoryStorage and on disk, then checks
+// that CleanJob removes every file of the job but its input files, and that calling it
+// again, once the files are gone, succeeds.
+//
+func TestCleanJob(t *testing.T) {
+	inputs := []string{"a b c", "b c d", "c d e"}
+
+	memory := NewMemoryStorage()
+
+	tests := []struct {
+		name    string
+		storage Storage
+		list    func() []string
+	}{
+		{"memory", memory, func() []string {
+			return slices.Sorted(maps.Keys(memory.files))
+		}},
+		{"disk", nil, func() []string {
+			return listDir(t, ".")
+		}},
+	}
+
+	chdirTemp(t)
+
+	for _, test := range tests {
+		w := &Worker{JobName: "cleanjob", Storage: test.storage, EmitManifest: true}
+
+		var inFiles []string = nil
+
+		for m, input := range inputs {
+			inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(m), input))
+		}
+
+		if err := w.RunJob(inFiles, 2, WordCountMap, WordCountReduce); err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if names := test.list(); len(names) <= len(inFiles) {
+			t.Fatalf("%s: job left only %v", test.name, names)
+		}
+
+		for i := 0; i < 2; i++ {
+			if err := CleanJob(test.storage, "cleanjob", len(inputs), 2); err != nil {
+				t.Fatalf("%s: CleanJob call %d: %v", test.name, i+1, err)
+			}
+		}
+
+		if names := test.list(); !slices.Equal(names, inFiles) {
+			t.Fatalf("%s: CleanJob left %v, expected %v", test.name, names, inFiles)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+//
+// Codec.go
+//
+// This file contains functionality for reducing typed values (e.g. numbers) rather than
+// strings, while keeping the string KeyValue format of intermediate and merge files (see
+// DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+)
+
+//
+// ValueCodec
+//
+// Converts values between their string form, as stored in KeyValues, and a typed form, as
+// passed to a typed Reduce function (see ReduceTyped).
+//
+//      Decode - parses the string form of a value
+//      Encode - formats a typed value as a string
+//
+type ValueCodec[T any] struct {
+	Decode func(value string) (T, error)
+	Encode func(value T) string
+}
+
+//
+// IntCodec
+//
+// Converts values to and from base-10 integers.
+//
+var IntCodec = ValueCodec[int]{
+	Decode: strconv.Atoi,
+	Encode: strconv.Itoa,
+}
+
+//
+// FloatCodec
+//
+// Converts values to and from 64-bit floating-point numbers, formatted with the fewest digits
+// that parse back to the same number.
+//
+var FloatCodec = ValueCodec[float64]{
+	Decode: func(value string) (float64, error) {
+		return strconv.ParseFloat(value, 64)
+	},
+	Encode: func(value float64) string {
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	},
+}
+
+//
+// valueCodecError
+//
+// Carries an error decoding a value out of a typed Reduce function adapter, which can only
+// return a string, as a panic that callReduce recognizes (see typedReduceFunc).
+//
+type valueCodecError struct {
+	err error
+}
+
+//
+// doReduceTyped
+//
+// Does the job of a reduce worker over typed values with the default configuration (see
+// ReduceTyped).
+//
+//      jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap             - the number of Map tasks that were run
+//      codec            - converts values to and from their typed form
+//      reduceFunc       - the user-defined, typed Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func doReduceTyped[T any](
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	codec            ValueCodec[T],
+	reduceFunc       func(key string, values []T) T,
+) (string, error) {
+	return ReduceTyped(&Worker{JobName: jobName}, reduceTaskNumber, nMap, codec, reduceFunc)
+}
+
+//
+// ReduceTyped
+//
+// This function does the job of a reduce worker like Worker.Reduce, but decodes each value
+// with a codec before calling the Reduce function, and encodes its result, so that e.g. a
+// sum can be reduced as integers (see IntCodec) without converting in every reducer. Files
+// keep their string KeyValue format. A value the codec cannot decode fails the task with
+// ErrReduceFunc. (A function, rather than a Worker method, as methods cannot be generic.)
+//
+//      w                - the Worker whose configuration is used
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap             - the number of Map tasks that were run
+//      codec            - converts values to and from their typed form
+//      reduceFunc       - the user-defined, typed Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func ReduceTyped[T any](
+	w                *Worker,
+	reduceTaskNumber int,
+	nMap             int,
+	codec            ValueCodec[T],
+	reduceFunc       func(key string, values []T) T,
+) (string, error) {
+	return w.Reduce(reduceTaskNumber, nMap, typedReduceFunc(codec, reduceFunc))
+}
+
+//
+// typedReduceFunc
+//
+// Adapts a typed Reduce function to string values, through a codec.
+// *NOTE* A value that cannot be decoded panics with a valueCodecError, which callReduce
+//        converts back into the task's error
+//
+//      codec      - converts values to and from their typed form
+//      reduceFunc - the user-defined, typed Reduce function
+//
+// Returns the adapted Reduce function.
+//
+func typedReduceFunc[T any](
+	codec      ValueCodec[T],
+	reduceFunc func(key string, values []T) T,
+) func(key string, values []string) string {
+	return func(key string, values []string) string {
+		typedValues := make([]T, len(values))
+
+		for i, value := range values {
+			typedValue, err := codec.Decode(value)
+
+			if err != nil {
+				panic(valueCodecError{err})
+			}
+
+			typedValues[i] = typedValue
+		}
+
+		return codec.Encode(reduceFunc(key, typedValues))
+	}
+}
@@ -0,0 +1,90 @@
+//
+// Codec_test.go
+//
+// This file contains tests for Reduce functions over typed values.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+//
+// sumInts
+//
+// A typed Reduce function that sums integer values.
+//
+//      key    - the key
+//      values - the integer values of the key
+//
+// Returns the sum.
+//
+func sumInts(key string, values []int) int {
+	var total int = 0
+
+	for _, value := range values {
+		total += value
+	}
+
+	return total
+}
+
+//
+// TestReduceTypedSum
+//
+// Sums integer values through IntCodec, and checks the sums written, as strings.
+//
+func TestReduceTypedSum(t *testing.T) {
+	w := newTestWorker(t, "typed")
+
+	inputs := []string{testInput, "the fox"}
+
+	for m, input := range inputs {
+		if _, err := w.Map(m, writeInput(t, w, "input-"+strconv.Itoa(m), input), 2, wordOnesMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for r := 0; r < 2; r++ {
+		if _, err := ReduceTyped(w, r, len(inputs), IntCodec, sumInts); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkOutput(t, readOutput(t, w, 2), countWords(inputs...))
+}
+
+//
+// TestReduceTypedDecodeError
+//
+// Checks that a value the codec cannot decode fails the task with ErrReduceFunc.
+//
+func TestReduceTypedDecodeError(t *testing.T) {
+	w := newTestWorker(t, "typed")
+
+	writeIntermediate(t, w, 0, 0, `{"Key":"a","Value":"1"}`+"\n"+`{"Key":"a","Value":"one"}`)
+
+	if _, err := ReduceTyped(w, 0, 1, IntCodec, sumInts); !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrReduceFunc)
+	}
+}
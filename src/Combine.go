@@ -0,0 +1,111 @@
+//
+// Combine.go
+//
+// This file contains functionality for combining the KeyValue pairs of a 'map' worker before
+// they are written, to shrink its intermediate files (see DoMap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+)
+
+//
+// combineKeyValues
+//
+// Combines KeyValue pairs into one KeyValue per key, by calling the Worker's Combiner with
+// each key's values. Keys keep the order in which they were first seen.
+//
+//      keyValues - the KeyValue pairs to be combined
+//
+// Returns the combined KeyValue pairs, and any error from the Combiner.
+//
+func (w *Worker) combineKeyValues(keyValues []KeyValue) ([]KeyValue, error) {
+	var err error = nil
+
+	//
+	// Group the values of each key:
+	//
+	keys         := make([]string, 0)
+	keyValuesMap := make(map[string][]string)
+
+	for _, kv := range keyValues {
+		values, exists := keyValuesMap[kv.Key]
+
+		if !exists {
+			keys = append(keys, kv.Key)
+		}
+
+		keyValuesMap[kv.Key] = append(values, kv.Value)
+	}
+
+	//
+	// Combine the values of each key:
+	//
+	combinedKeyValues := make([]KeyValue, 0, len(keys))
+
+	for _, key := range keys {
+		values := keyValuesMap[key]
+
+		if len(values) == 1 {
+			combinedKeyValues = append(combinedKeyValues, KeyValue{key, values[0]})
+			continue
+		}
+
+		newValue, tempErr := callReduceFunc(w.Combiner, key, values, w.Timeout)
+
+		if tempErr == nil && newValue == "error" {
+			tempErr = errors.New("Combiner Function Error")
+		}
+
+		if tempErr != nil {
+			err = stageError(ErrReduceFunc, tempErr)
+			break
+		}
+
+		combinedKeyValues = append(combinedKeyValues, KeyValue{key, newValue})
+	}
+
+	if err != nil {
+		return keyValues, err
+	}
+
+	return combinedKeyValues, nil
+}
+
+//
+// keyValuesBytes
+//
+// Approximates the memory held by KeyValue pairs by the lengths of their keys and values.
+//
+//      keyValues - the KeyValue pairs to be measured
+//
+// Returns the approximate number of bytes.
+//
+func keyValuesBytes(keyValues []KeyValue) int {
+	var bytes int = 0
+
+	for _, kv := range keyValues {
+		bytes += len(kv.Key) + len(kv.Value)
+	}
+
+	return bytes
+}
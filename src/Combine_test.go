@@ -0,0 +1,120 @@
+//
+// Combine_test.go
+//
+// This file contains tests for combining Map output.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+//
+// TestCombineThreshold
+//
+// Checks that Map only calls its Combiner once a partition's buffered output grows past
+// CombineThreshold, which then shrinks the intermediate file without changing the output.
+//
+func TestCombineThreshold(t *testing.T) {
+	// 300 KeyValues of 2 bytes each
+	input := strings.Repeat("a b c ", 100)
+
+	for _, test := range []struct {
+		threshold int
+		combined  bool
+	}{
+		{0, false},
+		{1 << 20, false},
+		{64, true},
+	} {
+		var calls atomic.Int64
+
+		w := newTestWorker(t, "combine")
+		w.CombineThreshold = test.threshold
+		w.Combiner         = func(key string, values []string) string {
+			calls.Add(1)
+			return WordCountReduce(key, values)
+		}
+
+		inFile := writeInput(t, w, "input", input)
+		stats, err := w.Map(0, inFile, 1, wordOnesMap)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.combined != (calls.Load() > 0) {
+			t.Fatalf("threshold %d: %d Combiner calls", test.threshold, calls.Load())
+		}
+
+		written := len(readKeyValueFile(t, w, w.reduceName(0, 0)))
+
+		if test.combined != (written < stats.OutputRecords) {
+			t.Fatalf("threshold %d: wrote %d of %d KeyValues", test.threshold, written, stats.OutputRecords)
+		}
+
+		if _, err = w.Reduce(0, 1, WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+
+		checkOutput(t, readOutput(t, w, 1), countWords(input))
+	}
+}
+
+//
+// TestCombineBudget
+//
+// Checks that a small CombineBudget makes Map combine and flush its buffered output several
+// times, writing one partially combined KeyValue per key and flush, which Reduce then adds
+// up to the same output. The budget is checked between input records, so the input is
+// mapped a line at a time.
+//
+func TestCombineBudget(t *testing.T) {
+	input := strings.Repeat("a b c\n", 100)
+
+	w := newTestWorker(t, "budget")
+	w.Combiner      = WordCountReduce
+	w.CombineBudget = 64
+
+	if _, err := w.MapRecordReader(0, "input", NewLineReader(strings.NewReader(input)), 1, wordOnesMap); err != nil {
+		t.Fatal(err)
+	}
+
+	var flushes int = 0
+
+	for _, kv := range readKeyValueFile(t, w, w.reduceName(0, 0)) {
+		if kv.Key == "a" {
+			flushes++
+		}
+	}
+
+	if flushes < 2 || flushes >= 100 {
+		t.Fatalf("%d flushes, expected several partially combined ones", flushes)
+	}
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), countWords(input))
+}
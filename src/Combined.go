@@ -0,0 +1,416 @@
+//
+// Combined.go
+//
+// This file contains functionality for the combined intermediate layout, where each 'map'
+// worker writes one file holding every partition, plus an index of the partitions' byte
+// offsets, rather than one file per partition (see Worker.CombinedLayout).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+//
+// combinedWriter
+//
+// Writes the partitions of a Map task, in partition order, as consecutive segments of one
+// combined file. Each segment is its own (optionally compressed) stream of JSON-encoded
+// KeyValues, so a Reduce task can decode its segment alone. The offset at which each segment
+// starts, followed by the end of the last, is written to the index file on close.
+//
+type combinedWriter struct {
+	storage     Storage
+	file        io.WriteCloser
+	fileName    string
+	indexName   string
+	writer      *bufio.Writer
+	counter     *countingWriter
+	compression Compression
+	compressor  io.WriteCloser
+	encoder     *json.Encoder
+	offsets     []int64
+	nReduce     int
+	durable     bool
+	escapeHTML  bool
+	waitCreate  func()
+}
+
+//
+// countingWriter
+//
+// Counts the bytes written through it.
+//
+type countingWriter struct {
+	writer io.Writer
+	count  int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.writer.Write(p)
+	c.count += int64(n)
+	return n, err
+}
+
+//
+// createCombinedFile
+//
+// Creates the combined file of a Map task, replacing it and its index if they already exist.
+//
+//      mapTaskNumber - the unique number assigned to this Map task
+//      nReduce       - the number of Reduce tasks that will be run
+//
+// Returns the writer of the file, and nil on success. Otherwise, the error that failed; the
+// writer is still returned, so that the caller can remove anything created.
+//
+func (w *Worker) createCombinedFile(mapTaskNumber int, nReduce int) (*combinedWriter, error) {
+	var err error = nil
+
+	out := &combinedWriter{
+		storage:     w.storage(),
+		fileName:    w.combinedName(mapTaskNumber),
+		indexName:   w.combinedName(mapTaskNumber) + ".index",
+		compression: w.Compression,
+		nReduce:     nReduce,
+		durable:     w.Durable,
+		escapeHTML:  !w.DisableHTMLEscape,
+		waitCreate:  w.waitCreate,
+	}
+
+	bufferSize := w.WriteBufferSize
+
+	if bufferSize <= 0 {
+		bufferSize = defaultWriteBufferSize
+	}
+
+	//
+	// Remove the index first, so a combined file is never left with a stale index:
+	//
+	for _, fileName := range []string{out.indexName, out.fileName} {
+		tempErr := out.storage.Remove(fileName)
+
+		if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+			// Error removing file
+			err = stageError(ErrWriteIntermediate, tempErr)
+			break
+		}
+	}
+
+	if err == nil {
+		w.waitCreate()
+
+		file, tempErr := out.storage.Create(out.fileName)
+
+		if tempErr != nil {
+			// Error creating file
+			err = stageError(ErrWriteIntermediate, tempErr)
+		} else {
+			out.file    = file
+			out.writer  = bufio.NewWriterSize(file, bufferSize)
+			out.counter = &countingWriter{writer: out.writer}
+		}
+	}
+
+	return out, err
+}
+
+//
+// write
+//
+// Encodes KeyValue pairs to the segment of a partition. Partitions must be written in order;
+// any skipped partition is given an empty segment.
+//
+//      partition - the number of the partition
+//      keyValues - the KeyValue pairs to be encoded
+//
+// Returns nil on success. Otherwise, the error encoding a KeyValue.
+//
+func (out *combinedWriter) write(partition int, keyValues []KeyValue) error {
+	var err error = nil
+
+	if partition < len(out.offsets)-1 {
+		return fmt.Errorf("partition %d written after partition %d in %s", partition, len(out.offsets)-1, out.fileName)
+	}
+
+	for err == nil && len(out.offsets) <= partition {
+		err = out.nextSegment()
+	}
+
+	for i := 0; i < len(keyValues) && err == nil; i++ {
+		err = out.encoder.Encode(&keyValues[i])
+
+		if err != nil {
+			// Error encoding KeyValue
+			err = encodeError(err)
+		}
+	}
+
+	return err
+}
+
+//
+// nextSegment
+//
+// Ends the current segment, if any, and starts the next one at the current offset, with its
+// version header (see writeFormatHeader).
+//
+// Returns any error ending the current segment, or starting the next.
+//
+func (out *combinedWriter) nextSegment() error {
+	if out.compressor != nil {
+		tempErr := out.compressor.Close()
+
+		if tempErr != nil {
+			// Error writing segment
+			return stageError(ErrWriteIntermediate, tempErr)
+		}
+	}
+
+	out.offsets    = append(out.offsets, out.counter.count)
+	out.compressor = compressWriter(out.counter, out.compression)
+	out.encoder    = newJSONEncoder(out.compressor, out.escapeHTML)
+
+	tempErr := writeFormatHeader(out.compressor)
+
+	if tempErr != nil {
+		// Error writing segment
+		return stageError(ErrWriteIntermediate, tempErr)
+	}
+
+	return nil
+}
+
+//
+// close
+//
+// Ends the last segment (giving any partitions not written an empty segment), flushes and
+// closes the combined file, and writes its index.
+//
+// Returns nil on success. Otherwise, the error writing or closing the files.
+//
+func (out *combinedWriter) close() error {
+	var err error = nil
+
+	for err == nil && len(out.offsets) < out.nReduce {
+		err = out.nextSegment()
+	}
+
+	if err == nil && out.compressor != nil {
+		err = out.compressor.Close()
+	}
+
+	out.offsets = append(out.offsets, out.counter.count)
+
+	if err == nil {
+		err = out.writer.Flush()
+	}
+
+	if err == nil && out.durable {
+		err = syncFile(out.file)
+	}
+
+	if err == nil {
+		err = out.file.Close()
+	}
+
+	//
+	// Write the index last, so that an index only exists for a complete file:
+	//
+	if err == nil {
+		index, tempErr := json.Marshal(out.offsets)
+
+		if tempErr == nil {
+			out.waitCreate()
+
+			tempErr = writeFileDurable(out.storage, out.indexName, index, out.durable)
+		}
+
+		err = tempErr
+	}
+
+	return stageError(ErrWriteIntermediate, err)
+}
+
+//
+// remove
+//
+// Closes and removes the combined file and its index, after a failure.
+//
+// Returns nil on success. Otherwise, the errors removing the files, joined.
+//
+func (out *combinedWriter) remove() error {
+	var errs []error = nil
+
+	if out.file != nil {
+		out.file.Close()
+	}
+
+	for _, fileName := range []string{out.indexName, out.fileName} {
+		tempErr := out.storage.Remove(fileName)
+
+		if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+			// Error removing file
+			errs = append(errs, tempErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// openCombinedPartition
+//
+// Opens the segment of a Map task's combined file that holds a Reduce task's partition, by
+// looking up its byte offsets in the index (see combinedWriter).
+// *NOTE* A combined file without an index (e.g. its Map task has not completed) is treated
+//        as not existing, and returns a nil file
+//
+//      mapTaskNumber    - the number of the Map task that wrote the combined file
+//      reduceTaskNumber - the number of the Reduce task whose partition is opened
+//
+// Returns the opened file (which the caller must close), the reader to decode the partition
+// from, the name of the partition (for errors), and any error opening it.
+//
+func (w *Worker) openCombinedPartition(
+	mapTaskNumber    int,
+	reduceTaskNumber int,
+) (io.ReadCloser, io.Reader, string, error) {
+	var file   io.ReadCloser = nil
+	var reader io.Reader     = nil
+	var err    error         = nil
+
+	fileName := w.combinedName(mapTaskNumber)
+	name     := fmt.Sprintf("%s (partition %d)", fileName, reduceTaskNumber)
+
+	//
+	// Read the partition's offsets from the index:
+	//
+	var offsets []int64 = nil
+
+	index, tempErr := readStorageFile(w.storage(), fileName + ".index")
+
+	if tempErr != nil {
+		if !errors.Is(tempErr, fs.ErrNotExist) {
+			// Error reading index
+			err = tempErr
+		}
+	} else {
+		tempErr = json.Unmarshal(index, &offsets)
+
+		if tempErr == nil && reduceTaskNumber+1 >= len(offsets) {
+			tempErr = fmt.Errorf("%s.index has no partition %d", fileName, reduceTaskNumber)
+		}
+
+		if tempErr != nil {
+			// Error decoding index
+			offsets = nil
+			err     = tempErr
+		}
+	}
+
+	//
+	// Open the combined file, limited to the partition's segment:
+	//
+	if offsets != nil {
+		file, tempErr = w.storage().Open(fileName)
+
+		if tempErr == nil {
+			tempErr = skipTo(file, offsets[reduceTaskNumber])
+		}
+
+		if tempErr == nil {
+			bufferSize := w.ReadBufferSize
+
+			if bufferSize <= 0 {
+				bufferSize = defaultReadBufferSize
+			}
+
+			segment := io.LimitReader(file, offsets[reduceTaskNumber+1]-offsets[reduceTaskNumber])
+
+			reader, tempErr = decompressReader(bufio.NewReaderSize(segment, bufferSize))
+		}
+
+		if tempErr != nil {
+			// Error opening file, or reading compression header
+			if file != nil {
+				file.Close()
+			}
+
+			file = nil
+			err  = tempErr
+		}
+	}
+
+	return file, reader, name, err
+}
+
+//
+// skipTo
+//
+// Moves a file opened for reading to an offset: by seeking, if its storage backend can, or
+// otherwise by reading up to it.
+//
+//      file   - the file, positioned at its start
+//      offset - the offset to move to
+//
+// Returns nil on success. Otherwise, the error seeking or reading.
+//
+func skipTo(file io.Reader, offset int64) error {
+	if seeker, ok := file.(io.Seeker); ok {
+		_, err := seeker.Seek(offset, io.SeekStart)
+		return err
+	}
+
+	_, err := io.CopyN(io.Discard, file, offset)
+
+	return err
+}
+
+//
+// removeCombined
+//
+// Removes the combined files and indexes of a job's Map tasks. Files that are already gone
+// are skipped.
+//
+//      nMap - the number of Map tasks that were run
+//
+// Returns nil on success. Otherwise, the errors removing files.
+//
+func (w *Worker) removeCombined(nMap int) error {
+	var errs []error = nil
+
+	for _, i := range w.mapTasks(nMap) {
+		for _, fileName := range []string{w.combinedName(i) + ".index", w.combinedName(i)} {
+			tempErr := w.storage().Remove(fileName)
+
+			if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+				errs = append(errs, tempErr)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+//
+// Combined_test.go
+//
+// This file contains tests for the combined intermediate layout.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// TestCombinedLayout
+//
+// Runs the same job in the per-file and combined layouts, and checks that their merge files
+// are identical, while the combined layout writes one file (and index) per Map task.
+//
+func TestCombinedLayout(t *testing.T) {
+	const nReduce = 4
+
+	perFile := newTestWorker(t, "layout")
+	perFile.SortOutput = true
+
+	combined := newTestWorker(t, "layout")
+	combined.SortOutput     = true
+	combined.CombinedLayout = true
+
+	checkOutput(t, runWordCount(t, perFile, testInputs, nReduce), countWords(testInputs...))
+	checkOutput(t, runWordCount(t, combined, testInputs, nReduce), countWords(testInputs...))
+
+	for r := 0; r < nReduce; r++ {
+		if readFile(t, perFile, perFile.mergeName(r)) != readFile(t, combined, combined.mergeName(r)) {
+			t.Fatalf("merge files %d differ between the layouts", r)
+		}
+	}
+
+	// Inputs, then per Map task a combined file and its index, then merge files
+	var expected []string = nil
+
+	for m := range testInputs {
+		expected = append(expected, "input-"+strconv.Itoa(m))
+	}
+
+	for m := range testInputs {
+		expected = append(expected, filepath.Base(combined.combinedName(m)), filepath.Base(combined.combinedName(m))+".index")
+	}
+
+	for r := 0; r < nReduce; r++ {
+		expected = append(expected, filepath.Base(combined.mergeName(r)))
+	}
+
+	slices.Sort(expected)
+
+	if names := listDir(t, combined.OutDir); !slices.Equal(names, expected) {
+		t.Fatalf("combined layout wrote %v, expected %v", names, expected)
+	}
+}
@@ -0,0 +1,203 @@
+//
+// Completion.go
+//
+// This file contains functionality for marking merge files complete, so that a retried
+// 'reduce' worker can skip a task that already completed (see Worker.SkipCompleted).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+)
+
+//
+// completionMarker
+//
+// The contents of a merge file's completion marker: the size and checksum of the merge file
+// it was written for, so that a marker left beside a different (e.g. partially rewritten)
+// merge file is not mistaken for completion.
+//
+//      Bytes  - the size of the merge file
+//      SHA256 - the hex-encoded SHA-256 checksum of the merge file
+//
+type completionMarker struct {
+	Bytes  int64
+	SHA256 string
+}
+
+//
+// markerName
+//
+// Builds the path of a merge file's completion marker.
+//
+//      mergeFileName - the path of the merge file
+//
+// Returns the completion marker path.
+//
+func markerName(mergeFileName string) string {
+	return mergeFileName + ".done"
+}
+
+//
+// completedMerge
+//
+// Checks whether a Reduce task's merge file is complete: its completion marker exists, and
+// matches the merge file's size and checksum.
+//
+//      reduceTaskNumber - the number of the Reduce task
+//
+// Returns true if the merge file is complete. Otherwise (including on any error reading the
+// files), false.
+//
+func (w *Worker) completedMerge(reduceTaskNumber int) bool {
+	fileName := w.mergeName(reduceTaskNumber)
+
+	encoding, tempErr := readStorageFile(w.storage(), markerName(fileName))
+
+	if tempErr != nil {
+		// No marker (or unreadable)
+		return false
+	}
+
+	var marker completionMarker
+
+	if json.Unmarshal(encoding, &marker) != nil {
+		// Malformed marker
+		return false
+	}
+
+	current, tempErr := checksumFile(w.storage(), fileName)
+
+	return tempErr == nil && current == marker
+}
+
+//
+// writeCompletionMarker
+//
+// Writes the completion marker of a merge file, once the merge file is complete. The marker
+// is written through a temporary file and renamed, so that it either exists complete or not
+// at all.
+//
+//      fileName - the path of the merge file
+//
+// Returns nil on success. Otherwise, the error checksumming the merge file or writing the
+// marker.
+//
+func (w *Worker) writeCompletionMarker(fileName string) error {
+	staged, err := w.stageCompletionMarker(fileName, fileName)
+
+	if err == nil {
+		_, err = w.publishFiles([]stagedFile{staged})
+	}
+
+	if err != nil {
+		w.storage().Remove(staged.tempName)
+	}
+
+	return err
+}
+
+//
+// stageCompletionMarker
+//
+// Writes the completion marker of a merge file to its temporary file, to be renamed into
+// place after the merge file (see publishFiles).
+//
+//      fileName    - the path of the merge file
+//      contentName - the path holding the merge file's contents, e.g. its staged temporary
+//                    file
+//
+// Returns the staged marker, and nil on success. Otherwise, the error checksumming the
+// contents or writing the marker; the temporary file is removed.
+//
+func (w *Worker) stageCompletionMarker(fileName string, contentName string) (stagedFile, error) {
+	staged := stagedFile{tempName: markerName(fileName) + ".tmp", fileName: markerName(fileName)}
+
+	marker, err := checksumFile(w.storage(), contentName)
+
+	var encoding []byte = nil
+
+	if err == nil {
+		encoding, err = json.Marshal(&marker)
+	}
+
+	if err == nil {
+		err = writeFileDurable(w.storage(), staged.tempName, encoding, w.Durable)
+	}
+
+	if err != nil {
+		w.storage().Remove(staged.tempName)
+	}
+
+	return staged, err
+}
+
+//
+// removeCompletionMarker
+//
+// Removes the completion marker of a merge file, before the merge file is rewritten.
+//
+//      storage  - the storage backend holding the merge file
+//      fileName - the path of the merge file
+//
+// Returns nil on success, or if there is no marker. Otherwise, the error removing it.
+//
+func removeCompletionMarker(storage Storage, fileName string) error {
+	err := storage.Remove(markerName(fileName))
+
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+
+	return err
+}
+
+//
+// checksumFile
+//
+// Computes the size and SHA-256 checksum of a file.
+//
+//      storage  - the storage backend holding the file
+//      fileName - the name of the file
+//
+// Returns the size and checksum, and any error reading the file.
+//
+func checksumFile(storage Storage, fileName string) (completionMarker, error) {
+	var marker completionMarker
+
+	file, err := storage.Open(fileName)
+
+	if err == nil {
+		hash := sha256.New()
+
+		marker.Bytes, err = io.Copy(hash, file)
+		marker.SHA256     = hex.EncodeToString(hash.Sum(nil))
+
+		file.Close()
+	}
+
+	return marker, err
+}
@@ -0,0 +1,105 @@
+//
+// Completion_test.go
+//
+// This file contains tests for skipping completed Reduce tasks.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"path/filepath"
+	"testing"
+)
+
+//
+// TestSkipCompleted
+//
+// Runs a Reduce task twice with SkipCompleted, and checks that the second run is a no-op
+// that returns the same merge file, and that a merge file changed since it was marked
+// complete is reduced again.
+//
+func TestSkipCompleted(t *testing.T) {
+	var calls int = 0
+
+	countingReduce := func(key string, values []string) string {
+		calls++
+		return WordCountReduce(key, values)
+	}
+
+	w := newTestWorker(t, "skip")
+	w.SkipCompleted = true
+	w.SortOutput    = true
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := w.Reduce(0, 1, countingReduce)
+
+	if err != nil || calls == 0 {
+		t.Fatalf("first run: %d reduceFunc calls, error %v", calls, err)
+	}
+
+	output := readFile(t, w, first)
+	calls   = 0
+
+	second, err := w.Reduce(0, 1, countingReduce)
+
+	if err != nil || second != first || calls != 0 || readFile(t, w, second) != output {
+		t.Fatalf("second run: merge file %s (expected %s), %d reduceFunc calls, error %v", second, first, calls, err)
+	}
+
+	// A merge file that no longer matches its marker is not complete
+	writeInput(t, w, filepath.Base(first), "{}")
+
+	if _, err = w.Reduce(0, 1, countingReduce); err != nil || calls == 0 || readFile(t, w, first) != output {
+		t.Fatalf("run after changing the merge file: %d reduceFunc calls, error %v", calls, err)
+	}
+}
+
+//
+// TestSkipCompletedAppend
+//
+// Checks that SkipCompleted is rejected with AppendOutput, which would otherwise skip every
+// later run and never fold in its new KeyValues, and that the merge file is left unchanged.
+//
+func TestSkipCompletedAppend(t *testing.T) {
+	w := newTestWorker(t, "skipappend")
+	runWordCount(t, w, []string{testInput}, 1)
+
+	output := readFile(t, w, w.mergeName(0))
+
+	w.AppendOutput  = true
+	w.SkipCompleted = true
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); err == nil {
+		t.Fatal("SkipCompleted with AppendOutput accepted")
+	}
+
+	if readFile(t, w, w.mergeName(0)) != output {
+		t.Fatalf("merge file changed, expected %q", output)
+	}
+
+	if _, err := w.storage().Stat(markerName(w.mergeName(0))); err == nil {
+		t.Fatal("completion marker written")
+	}
+}
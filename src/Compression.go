@@ -0,0 +1,221 @@
+//
+// Compression.go
+//
+// This file contains functionality for compressing the intermediate files written by 'map'
+// workers, and detecting compression when 'reduce' workers read them (see DoMap.go and
+// DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/klauspost/compress/zstd"
+)
+
+//
+// Compression
+//
+// Identifies how the intermediate files of a Map task, or the merge file of a Reduce task,
+// are compressed. Readers detect the compression of each file they read, so files written
+// with different compressions can be mixed in one job (e.g. while migrating a job to
+// compression).
+//
+// Snappy is fast with a lower ratio, so it suits intermediate files, which are written and
+// read once, where speed matters most. Zstd has a high ratio, so it suits merge files that
+// are archived. Gzip is in between, and needs no third-party codec to read.
+//
+type Compression int
+
+const (
+	CompressionNone   Compression = iota // Plain JSON (the default)
+	CompressionGzip                      // gzip-compressed JSON
+	CompressionSnappy                    // Snappy-compressed JSON, in the framing format
+	CompressionZstd                      // Zstandard-compressed JSON
+)
+
+//
+// String
+//
+// Returns the name of the compression.
+//
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "none"
+	case CompressionGzip:
+		return "gzip"
+	case CompressionSnappy:
+		return "snappy"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "unknown"
+	}
+}
+
+//
+// extension
+//
+// Returns the file name extension of the compression (e.g. ".gz"), or "" if none.
+//
+func (c Compression) extension() string {
+	switch c {
+	case CompressionGzip:
+		return ".gz"
+	case CompressionSnappy:
+		return ".sz"
+	case CompressionZstd:
+		return ".zst"
+	default:
+		return ""
+	}
+}
+
+//
+// Compression magic numbers
+//
+// The first bytes of every stream of each compression, by which readers detect it. None of
+// them can start a JSON value, or a format header (see Decode.go).
+//
+//      gzipMagic   - the gzip header's ID bytes
+//      snappyMagic - the stream identifier chunk of the Snappy framing format
+//      zstdMagic   - the magic number of a Zstandard frame (little-endian 0xFD2FB528)
+//
+var (
+	gzipMagic   = []byte{0x1f, 0x8b}
+	snappyMagic = []byte("\xff\x06\x00\x00sNaPpY")
+	zstdMagic   = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+//
+// detectCompression
+//
+// Detects the compression of a stream from its first bytes.
+//
+//      header - the first bytes of the stream (as many as are available, up to
+//               len(snappyMagic))
+//
+// Returns the compression (CompressionNone if the stream is not compressed, or too short
+// to tell).
+//
+func detectCompression(header []byte) Compression {
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		return CompressionGzip
+	case bytes.HasPrefix(header, snappyMagic):
+		return CompressionSnappy
+	case bytes.HasPrefix(header, zstdMagic):
+		return CompressionZstd
+	default:
+		return CompressionNone
+	}
+}
+
+//
+// compressWriter
+//
+// Wraps a writer so that everything written through it is compressed.
+//
+//      writer      - the writer to receive the compressed bytes
+//      compression - the compression to be applied
+//
+// Returns the compressing writer. Closing it flushes the compression layer, but does not
+// close the underlying writer.
+//
+func compressWriter(writer io.Writer, compression Compression) io.WriteCloser {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewWriter(writer)
+	case CompressionSnappy:
+		return s2.NewWriter(writer, s2.WriterSnappyCompat(), s2.WriterConcurrency(1))
+	case CompressionZstd:
+		//
+		// One encoder goroutine, as Map holds an encoder per partition:
+		// *NOTE* NewWriter only fails on invalid options
+		//
+		encoder, _ := zstd.NewWriter(writer, zstd.WithEncoderConcurrency(1))
+		return encoder
+	default:
+		return nopWriteCloser{writer}
+	}
+}
+
+//
+// decompressReader
+//
+// Detects whether a stream is compressed by peeking at its first bytes, and wraps it so that
+// everything read through it is decompressed. Peeking does not consume any bytes.
+//
+//      reader - the stream to be read
+//
+// Returns the decompressing reader (or the stream itself, if it is not compressed), and any
+// error reading the compression header.
+//
+func decompressReader(reader *bufio.Reader) (io.Reader, error) {
+	// *NOTE* Peek returns the bytes available, even if fewer than requested
+	header, _ := reader.Peek(len(snappyMagic))
+
+	return decompress(reader, detectCompression(header))
+}
+
+//
+// decompress
+//
+// Wraps a stream so that everything read through it is decompressed.
+//
+//      reader      - the stream to be read
+//      compression - the compression of the stream (see detectCompression)
+//
+// Returns the decompressing reader (or the stream itself, if it is not compressed), and any
+// error reading the compression header.
+//
+func decompress(reader io.Reader, compression Compression) (io.Reader, error) {
+	switch compression {
+	case CompressionGzip:
+		return gzip.NewReader(reader)
+	case CompressionSnappy:
+		return s2.NewReader(reader), nil
+	case CompressionZstd:
+		// *NOTE* With a concurrency of one, a stream is decoded synchronously, on the
+		//        caller's goroutine, so the decoder holds no goroutines needing Close
+		return zstd.NewReader(reader, zstd.WithDecoderConcurrency(1))
+	default:
+		return reader, nil
+	}
+}
+
+//
+// nopWriteCloser
+//
+// Adds a Close method that does nothing to a writer.
+//
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
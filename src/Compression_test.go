@@ -0,0 +1,162 @@
+//
+// Compression_test.go
+//
+// This file contains tests for the compression of intermediate and merge files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestReduceMixedCompression
+//
+// Checks that one Reduce task reads intermediate files written with each compression, and
+// without, detecting each file's compression from its magic bytes.
+//
+func TestReduceMixedCompression(t *testing.T) {
+	w := newTestWorker(t, "mixed")
+
+	inputs       := []string{"a b a", "b c c", "c a", "a d", "d b"}
+	compressions := []Compression{CompressionGzip, CompressionNone, CompressionSnappy, CompressionZstd, CompressionGzip}
+
+	for m, input := range inputs {
+		worker := *w
+		worker.Compression = compressions[m]
+
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := worker.Map(m, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for m := range inputs {
+		if detected := detectCompression([]byte(readFile(t, w, w.reduceName(m, 0)))); detected != compressions[m] {
+			t.Fatalf("intermediate file of map task %d detected as %s, expected %s", m, detected, compressions[m])
+		}
+	}
+
+	if _, err := w.Reduce(0, len(inputs), WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), countWords(inputs...))
+}
+
+//
+// TestCompressedMergeRoundTrip
+//
+// Runs a word count job with gzip merge files, and checks that each merge file is named with
+// ".gz" and starts with the gzip magic bytes, that no temporary file is left behind, and that
+// the output reads back, through Reduce's decoder and through FinalMerge.
+//
+func TestCompressedMergeRoundTrip(t *testing.T) {
+	w := newTestWorker(t, "gzmerge")
+	w.OutputCompression = CompressionGzip
+	w.SortOutput        = true
+
+	inputs   := []string{testInput, "a fox and a dog"}
+	expected := countWords(inputs...)
+
+	checkOutput(t, runWordCount(t, w, inputs, 2), expected)
+
+	for r := 0; r < 2; r++ {
+		fileName := w.mergeName(r)
+
+		if !strings.HasSuffix(fileName, ".gz") || !strings.HasPrefix(readFile(t, w, fileName), string(gzipMagic)) {
+			t.Errorf("merge file %s is not gzipped", fileName)
+		}
+	}
+
+	for _, name := range listDir(t, w.OutDir) {
+		if filepath.Ext(name) == ".tmp" {
+			t.Errorf("temporary file %s left behind", name)
+		}
+	}
+
+	//
+	// Read the merged output back:
+	//
+	var merged bytes.Buffer
+
+	if err := w.FinalMerge(2, &merged); err != nil {
+		t.Fatal(err)
+	}
+
+	output  := make(map[string]string)
+	decoder := json.NewDecoder(&merged)
+
+	for {
+		var kv KeyValue
+
+		if err := decoder.Decode(&kv); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+
+		output[kv.Key] = kv.Value
+	}
+
+	checkOutput(t, output, expected)
+}
+
+//
+// TestCompressionRoundTrip
+//
+// Runs a word count job with each compression for both its intermediate and merge files,
+// and checks that every file is detected as written with it, that each merge file is named
+// with its extension, and that the output reads back.
+//
+func TestCompressionRoundTrip(t *testing.T) {
+	inputs   := []string{testInput, "a fox and a dog"}
+	expected := countWords(inputs...)
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip, CompressionSnappy, CompressionZstd} {
+		w := newTestWorker(t, "roundtrip")
+		w.Compression       = compression
+		w.OutputCompression = compression
+
+		checkOutput(t, runWordCount(t, w, inputs, 2), expected)
+
+		for r := 0; r < 2; r++ {
+			fileName := w.mergeName(r)
+
+			if !strings.HasSuffix(fileName, compression.extension()) {
+				t.Errorf("%s: merge file %s not named with %q", compression, fileName, compression.extension())
+			}
+
+			for _, name := range []string{fileName, w.reduceName(0, r), w.reduceName(1, r)} {
+				if detected := detectCompression([]byte(readFile(t, w, name))); detected != compression {
+					t.Errorf("%s: file %s detected as %s", compression, name, detected)
+				}
+			}
+		}
+	}
+}
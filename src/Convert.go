@@ -0,0 +1,105 @@
+//
+// Convert.go
+//
+// This file contains functionality for converting merge files to other output formats (see
+// Format.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"io"
+)
+
+//
+// ConvertMerge
+//
+// Converts a merge file to another output format with the default configuration (see
+// Worker.ConvertMerge).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the number of the Reduce task that wrote the merge file
+//      format           - the output format to convert to
+//      out              - the writer to write the converted KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error that failed the conversion.
+//
+func ConvertMerge(jobName string, reduceTaskNumber int, format OutputFormat, out io.Writer) error {
+	return (&Worker{JobName: jobName}).ConvertMerge(reduceTaskNumber, format, out)
+}
+
+//
+// ConvertMerge
+//
+// Converts the KeyValue pairs of a merge file to another output format, e.g. to load a
+// job's output into a spreadsheet without rerunning the job. The merge file must be
+// JSON-encoded (see OutputFormat), and may be compressed (see Worker.OutputCompression); the
+// pairs are decoded and written one at a time, in the order of the merge file, so it is
+// never held in memory as a whole. The converted pairs are escaped or quoted as the target
+// format requires (see keyValueEncoder), separated by the Worker's record separator (see
+// Worker.RecordSeparator), and written uncompressed.
+//
+//      reduceTaskNumber - the number of the Reduce task that wrote the merge file
+//      format           - the output format to convert to
+//      out              - the writer to write the converted KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error reading, decoding or writing.
+//
+func (w *Worker) ConvertMerge(reduceTaskNumber int, format OutputFormat, out io.Writer) error {
+	file, err := w.storage().Open(w.mergeName(reduceTaskNumber))
+
+	if err != nil {
+		// Error opening file
+		w.logf("Function error [Convert.ConvertMerge]: %s\n", err.Error())
+		return err
+	}
+
+	defer file.Close()
+
+	bufferSize := w.ReadBufferSize
+
+	if bufferSize <= 0 {
+		bufferSize = defaultReadBufferSize
+	}
+
+	reader, err := decompressReader(bufio.NewReaderSize(file, bufferSize))
+
+	writer         := bufio.NewWriter(out)
+	encode, finish := keyValueEncoder(writer, format, !w.DisableHTMLEscape, w.recordSeparator())
+
+	if err == nil {
+		err = decodeKeyValues(reader, encode)
+	}
+
+	if err == nil {
+		err = finish()
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err != nil {
+		w.logf("Function error [Convert.ConvertMerge]: %s\n", err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,101 @@
+//
+// Convert_test.go
+//
+// This file contains tests for converting merge files to other output formats.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"testing"
+)
+
+//
+// convertKeyValues
+//
+// The KeyValues of the conversion tests' merge file, with the characters each text format
+// must escape or quote.
+//
+var convertKeyValues = []KeyValue{
+	{"a\tb", "line1\nline2"},
+	{"comma,key", `say "hi"`},
+	{`back\slash`, "<html>&"},
+	{"plain", "1"},
+}
+
+//
+// TestConvertMerge
+//
+// Converts a fixture JSON merge file, plain and gzipped, to each output format, and checks
+// that the conversion is exactly the merge file Reduce writes in that format, and that the
+// TSV and CSV conversions escape and quote as expected.
+//
+func TestConvertMerge(t *testing.T) {
+	explicit := map[OutputFormat]string{
+		OutputFormatTSV: "a\\tb\tline1\\nline2\n" + "back\\\\slash\t<html>&\n" + "comma,key\tsay \"hi\"\n" + "plain\t1\n",
+		OutputFormatCSV: "a\tb,\"line1\nline2\"\n" + "back\\slash,<html>&\n" + "\"comma,key\",\"say \"\"hi\"\"\"\n" + "plain,1\n",
+	}
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		fixture := newTestWorker(t, "convert")
+		fixture.OutputCompression = compression
+		fixture.SortOutput        = true
+
+		runJob(t, fixture, 1, emitMap(convertKeyValues...), firstValue)
+
+		for _, format := range []OutputFormat{OutputFormatJSON, OutputFormatTSV, OutputFormatCSV, OutputFormatJSONArray} {
+			var converted bytes.Buffer
+
+			if err := fixture.ConvertMerge(0, format, &converted); err != nil {
+				t.Fatalf("%s, %s: %v", compression, format, err)
+			}
+
+			w := newTestWorker(t, "convert")
+			w.OutputFormat = format
+			w.SortOutput   = true
+
+			runJob(t, w, 1, emitMap(convertKeyValues...), firstValue)
+
+			if expected := readFile(t, w, w.mergeName(0)); converted.String() != expected {
+				t.Fatalf("%s, %s: converted to %q, expected %q", compression, format, converted.String(), expected)
+			}
+
+			if expected, exists := explicit[format]; exists && converted.String() != expected {
+				t.Fatalf("%s, %s: converted to %q, expected %q", compression, format, converted.String(), expected)
+			}
+		}
+	}
+}
+
+//
+// TestConvertMergeMissing
+//
+// Checks that converting a merge file that does not exist fails.
+//
+func TestConvertMergeMissing(t *testing.T) {
+	w := newTestWorker(t, "convert")
+
+	var converted bytes.Buffer
+
+	if err := w.ConvertMerge(0, OutputFormatCSV, &converted); err == nil {
+		t.Fatal("missing merge file converted")
+	}
+}
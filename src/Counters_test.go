@@ -0,0 +1,161 @@
+//
+// Counters_test.go
+//
+// This file contains tests for the counters of map functions.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"maps"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// countingMap
+//
+// A context-aware word count Map function that counts its calls, words, and words of three
+// letters or fewer, in its counters.
+//
+//      ctx      - the context of the Map task
+//      file     - the name of the input file
+//      contents - the contents of the input file
+//
+// Returns a KeyValue per word, as wordOnesMap.
+//
+func countingMap(ctx MapContext, file string, contents string) []KeyValue {
+	ctx.Counters.Add("calls", 1)
+
+	for _, word := range strings.Fields(contents) {
+		ctx.Counters.Add("words", 1)
+
+		if len(word) <= 3 {
+			ctx.Counters.Add("short", 1)
+		}
+	}
+
+	return wordOnesMap(file, contents)
+}
+
+//
+// TestCountersMerge
+//
+// Checks that merging counters adds them by name, in any order, leaving the merged counters
+// unchanged.
+//
+func TestCountersMerge(t *testing.T) {
+	a := Counters{"x": 1, "y": -2}
+	b := Counters{"y": 5, "z": 3}
+
+	ab := Counters{}
+	ab.Merge(a)
+	ab.Merge(b)
+
+	ba := Counters{}
+	ba.Merge(b)
+	ba.Merge(a)
+
+	expected := Counters{"x": 1, "y": 3, "z": 3}
+
+	if !maps.Equal(ab, expected) || !maps.Equal(ba, expected) {
+		t.Fatalf("merged %v and %v, expected %v", ab, ba, expected)
+	}
+
+	if !maps.Equal(a, Counters{"x": 1, "y": -2}) || !maps.Equal(b, Counters{"y": 5, "z": 3}) {
+		t.Fatalf("merged counters changed to %v and %v", a, b)
+	}
+}
+
+//
+// TestRunJobCounters
+//
+// Runs a job whose Map tasks count their words, at several parallelisms, and checks that
+// the job's statistics hold the totals of every task.
+//
+func TestRunJobCounters(t *testing.T) {
+	// The empty input has no records, so its Map function is not called
+	expected := Counters{"calls": 3, "words": 0, "short": 0}
+
+	for _, input := range testInputs {
+		for _, word := range strings.Fields(input) {
+			expected["words"]++
+
+			if len(word) <= 3 {
+				expected["short"]++
+			}
+		}
+	}
+
+	for _, parallelism := range []int{1, 4} {
+		w := newTestWorker(t, "counters")
+		w.Parallelism = parallelism
+
+		stats, err := w.RunJobWithContext(writeInputs(t, w, testInputs), 2, countingMap, WordCountReduce)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !maps.Equal(stats.Counters, expected) {
+			t.Fatalf("parallelism %d: counters %v, expected %v", parallelism, stats.Counters, expected)
+		}
+	}
+}
+
+//
+// TestCountersTimeout
+//
+// Checks that a map call abandoned by its timeout can go on incrementing its counters
+// after the task has returned, without racing with the task's counters (run with -race),
+// which do not include them.
+//
+func TestCountersTimeout(t *testing.T) {
+	w := newTestWorker(t, "timeout")
+	w.Timeout = 10 * time.Millisecond
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	release := make(chan struct{})
+	done    := make(chan struct{})
+
+	stats, err := w.MapWithContext(0, inFile, 1, func(ctx MapContext, file string, contents string) []KeyValue {
+		defer close(done)
+
+		<-release
+
+		ctx.Counters.Add("late", 1)
+
+		return nil
+	})
+
+	close(release)
+	<-done
+
+	if !errors.Is(err, ErrMapFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrMapFunc)
+	}
+
+	if stats.Counters["late"] != 0 {
+		t.Fatalf("counters %v include the abandoned call's", stats.Counters)
+	}
+}
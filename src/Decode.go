@@ -0,0 +1,426 @@
+//
+// Decode.go
+//
+// This file contains functionality for decoding the KeyValue pairs of intermediate and merge
+// files (see DoReduce.go and Merge.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//
+// Intermediate format versions
+//
+// Map writes a version header at the start of every intermediate file (and of every
+// partition's segment of a combined file, inside any compression): formatMagic followed by
+// the version number and a newline, e.g. "#mapreduce v1\n". Reduce decodes a stream by the
+// version in its header, so as the format evolves, files written by an older Map can still
+// be read during a rolling upgrade, without re-running every Map task. A stream without a
+// header is a legacy file, written before headers were added, and is decoded in the
+// headerless format, which version 1 matches.
+//
+//      formatLegacy  - the headerless format of legacy files
+//      formatV1      - JSON-encoded KeyValues one after another
+//      formatCurrent - the version written by Map
+//
+const (
+	formatLegacy  = 0
+	formatV1      = 1
+	formatCurrent = formatV1
+)
+
+//
+// formatMagic
+//
+// The start of a version header. It cannot start a JSON value, so a header is never mistaken
+// for a KeyValue.
+//
+const formatMagic = "#mapreduce v"
+
+//
+// writeFormatHeader
+//
+// Writes the header of the current format version to the start of an intermediate stream.
+//
+//      writer - the writer of the stream
+//
+// Returns any error writing the header.
+//
+func writeFormatHeader(writer io.Writer) error {
+	_, err := io.WriteString(writer, formatMagic+strconv.Itoa(formatCurrent)+"\n")
+	return err
+}
+
+//
+// readFormatHeader
+//
+// Consumes the version header at the start of a stream, if it has one.
+//
+//      reader - the stream, positioned at its first non-whitespace byte
+//
+// Returns the format version (formatLegacy if there is no header), the number of bytes of
+// the header consumed, and any error reading or parsing the header.
+//
+func readFormatHeader(reader *bufio.Reader) (int, int, error) {
+	peeked, _ := reader.Peek(len(formatMagic))
+
+	if string(peeked) != formatMagic {
+		// No header: legacy file
+		return formatLegacy, 0, nil
+	}
+
+	line, err := reader.ReadString('\n')
+
+	if err != nil && !errors.Is(err, io.EOF) {
+		// Error reading header
+		return formatLegacy, len(line), err
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, formatMagic)))
+
+	if err != nil || version <= formatLegacy {
+		return formatLegacy, len(line), fmt.Errorf("invalid format header %q", strings.TrimSpace(line))
+	}
+
+	return version, len(line), nil
+}
+
+//
+// keyValueDecoder
+//
+// Decodes KeyValue pairs from a stream in either of two formats, detected from the stream's
+// first non-whitespace byte (after its version header, if any):
+//
+//      object stream - JSON-encoded KeyValues one after another, as written by Map and Reduce
+//                      (the native format)
+//      array         - a single JSON array of KeyValues, as written by some external
+//                      producers (first byte '[')
+//
+// Both are decoded one KeyValue at a time, so neither is held in memory as a whole.
+//
+// When tolerating truncation (see Worker.TolerateTruncation), a stream that ends part way
+// through a KeyValue (or, for an array, before its closing bracket) ends cleanly after the
+// last complete KeyValue, and is marked truncated. A stream cut short by a crashed writer
+// ends this way, whereas corruption within the stream is a syntax error, which still fails.
+//
+// When decoding strictly (see Worker.StrictDecoding), a KeyValue object with any field other
+// than Key and Value is an error, rather than having the field ignored, and every decoding
+// error names the stream, the record (numbered from 1) and the byte offset at which decoding
+// of the record began: its start, or the whitespace or comma before it, counted in the
+// uncompressed stream.
+//
+type keyValueDecoder struct {
+	decoder   *json.Decoder
+	array     bool
+	done      bool
+	options   decodeOptions
+	name      string
+	records   int
+	base      int64
+	truncated bool
+}
+
+//
+// decodeOptions
+//
+// How a keyValueDecoder treats malformed streams (see keyValueDecoder). The zero value
+// decodes leniently, and fails on a stream cut short.
+//
+//      tolerateTruncation - whether a stream cut short ends after its last complete KeyValue
+//      strict             - whether unexpected fields are errors, and errors are located
+//
+type decodeOptions struct {
+	tolerateTruncation bool
+	strict             bool
+}
+
+//
+// decodeOptions
+//
+// Returns the decoding options of the Worker's Reduce tasks (see Worker.TolerateTruncation
+// and Worker.StrictDecoding).
+//
+func (w *Worker) decodeOptions() decodeOptions {
+	return decodeOptions{tolerateTruncation: w.TolerateTruncation, strict: w.StrictDecoding}
+}
+
+//
+// newKeyValueDecoder
+//
+// Creates a decoder over a stream, detecting its format version (see readFormatHeader) and
+// its format (see keyValueDecoder). A version newer than formatCurrent, written by a newer
+// Map, is an error.
+//
+//      reader  - the stream to be decoded
+//      name    - the name of the stream, used in strict errors
+//      options - how malformed streams are treated
+//
+// Returns the decoder, and any error reading the start of the stream.
+//
+func newKeyValueDecoder(reader io.Reader, name string, options decodeOptions) (*keyValueDecoder, error) {
+	var version  int   = formatLegacy
+	var consumed int   = 0
+	var err      error = nil
+
+	buffered, ok := reader.(*bufio.Reader)
+
+	if !ok {
+		buffered = bufio.NewReader(reader)
+	}
+
+	//
+	// Read the version header, if any, and dispatch on it:
+	//
+	consumed, err = skipWhitespace(buffered)
+
+	if err == nil {
+		var size int
+
+		version, size, err = readFormatHeader(buffered)
+		consumed          += size
+	}
+
+	if err == nil {
+		switch version {
+		case formatLegacy, formatV1:
+			// JSON-encoded KeyValues, decoded below
+		default:
+			err = fmt.Errorf("unsupported format version %d (newer than %d)", version, formatCurrent)
+		}
+	}
+
+	//
+	// Skip leading whitespace, and peek at the first byte:
+	//
+	var first byte = 0
+
+	if err == nil {
+		var size int
+
+		size, err = skipWhitespace(buffered)
+		consumed += size
+	}
+
+	if err == nil {
+		peeked, tempErr := buffered.Peek(1)
+
+		if tempErr == nil {
+			first = peeked[0]
+		}
+	}
+
+	d := &keyValueDecoder{
+		decoder: json.NewDecoder(buffered),
+		array:   first == '[',
+		options: options,
+		name:    name,
+		base:    int64(consumed),
+	}
+
+	if options.strict {
+		d.decoder.DisallowUnknownFields()
+	}
+
+	if err == nil && d.array {
+		// Consume the array's opening bracket
+		_, err = d.decoder.Token()
+	}
+
+	return d, err
+}
+
+//
+// skipWhitespace
+//
+// Consumes the whitespace at the start of a stream.
+//
+//      reader - the stream
+//
+// Returns the number of bytes consumed, and any error reading the stream, other than
+// reaching its end.
+//
+func skipWhitespace(reader *bufio.Reader) (int, error) {
+	var consumed int = 0
+
+	for {
+		peeked, err := reader.Peek(1)
+
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				// Empty stream
+				return consumed, nil
+			}
+
+			return consumed, err
+		}
+
+		if peeked[0] != ' ' && peeked[0] != '\t' && peeked[0] != '\n' && peeked[0] != '\r' {
+			return consumed, nil
+		}
+
+		reader.ReadByte()
+
+		consumed++
+	}
+}
+
+//
+// next
+//
+// Decodes the stream's next KeyValue.
+//
+//      kv - the KeyValue to decode into
+//
+// Returns true if a KeyValue was decoded, false at the end of the stream, and any error
+// decoding.
+//
+func (d *keyValueDecoder) next(kv *KeyValue) (bool, error) {
+	if d.done {
+		return false, nil
+	}
+
+	if !d.decoder.More() {
+		d.done = true
+
+		if d.array {
+			// Consume the array's closing bracket
+			_, err := d.decoder.Token()
+
+			if err != nil && d.options.tolerateTruncation && isTruncation(err) {
+				// Array cut short before its closing bracket
+				d.truncated = true
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return false, nil
+	}
+
+	offset := d.base + d.decoder.InputOffset()
+
+	err := d.decoder.Decode(kv)
+
+	d.records++
+
+	if err != nil && d.options.tolerateTruncation && isTruncation(err) {
+		// Stream cut short part way through a KeyValue
+		d.done      = true
+		d.truncated = true
+		return false, nil
+	}
+
+	if err != nil && d.options.strict {
+		// Locate the malformed record
+		err = fmt.Errorf("%s: record %d near byte offset %d: %w", d.name, d.records, offset, err)
+	}
+
+	return err == nil, err
+}
+
+//
+// isTruncation
+//
+// Reports whether a decoding error means the stream ended part way through a value, rather
+// than held invalid JSON.
+// *NOTE* encoding/json reports the end of a stream in the middle of an array as a syntax
+//        error, which can only be told apart from others by its message
+//
+//      err - the decoding error
+//
+// Returns true if the stream was cut short.
+//
+func isTruncation(err error) bool {
+	var syntaxErr *json.SyntaxError
+
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Error() == "unexpected end of JSON input"
+	}
+
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+//
+// decodeKeyValues
+//
+// Decodes the KeyValue pairs of a stream one at a time (see keyValueDecoder).
+//
+//      reader - the stream to be decoded
+//      visit  - called with each decoded KeyValue; an error stops the decoding
+//
+// Returns nil on success. Otherwise, the error reading the stream or returned by visit.
+//
+func decodeKeyValues(reader io.Reader, visit func(kv KeyValue) error) error {
+	_, err := decodeKeyValuesWith(reader, "", decodeOptions{}, visit)
+	return err
+}
+
+//
+// decodeKeyValuesWith
+//
+// Decodes the KeyValue pairs of a stream one at a time, treating a malformed stream as
+// requested (see keyValueDecoder).
+//
+//      reader  - the stream to be decoded
+//      name    - the name of the stream, used in strict errors
+//      options - how malformed streams are treated
+//      visit   - called with each decoded KeyValue; an error stops the decoding
+//
+// Returns whether the stream was cut short (when tolerating truncation), and nil on success.
+// Otherwise, the error reading the stream or returned by visit.
+//
+func decodeKeyValuesWith(
+	reader  io.Reader,
+	name    string,
+	options decodeOptions,
+	visit   func(kv KeyValue) error,
+) (bool, error) {
+	decoder, err := newKeyValueDecoder(reader, name, options)
+
+	for err == nil {
+		var tempKV KeyValue
+
+		more, tempErr := decoder.next(&tempKV)
+
+		if tempErr != nil {
+			// Error decoding
+			err = tempErr
+			break
+		}
+
+		if !more {
+			break
+		}
+
+		err = visit(tempKV)
+	}
+
+	return decoder.truncated, err
+}
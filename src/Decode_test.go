@@ -0,0 +1,203 @@
+//
+// Decode_test.go
+//
+// This file contains tests for decoding intermediate files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// writeIntermediate
+//
+// Writes the intermediate file of a Map task for a Reduce task directly, as an external
+// producer would.
+//
+//      t                - the test
+//      w                - the Worker
+//      mapTaskNumber    - the number of the Map task the file stands for
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//      contents         - the contents of the file
+//
+func writeIntermediate(t testing.TB, w *Worker, mapTaskNumber int, reduceTaskNumber int, contents string) {
+	t.Helper()
+
+	fileName := w.reduceName(mapTaskNumber, reduceTaskNumber)
+
+	writeInput(t, &Worker{Storage: w.Storage, OutDir: filepath.Dir(fileName)}, filepath.Base(fileName), contents)
+}
+
+//
+// TestDecodeArrayAndStream
+//
+// Checks that one Reduce task decodes intermediate files holding a stream of JSON objects,
+// a JSON array of them, and an empty array, whatever whitespace surrounds them.
+//
+func TestDecodeArrayAndStream(t *testing.T) {
+	w := newTestWorker(t, "decode")
+
+	writeIntermediate(t, w, 0, 0, `{"Key":"a","Value":"1"}`+"\n"+`{"Key":"b","Value":"2"}`+"\n")
+	writeIntermediate(t, w, 1, 0, ` [ {"Key":"a","Value":"3"}, {"Key":"c","Value":"4"} ]`+"\n")
+	writeIntermediate(t, w, 2, 0, "\n\t[]")
+	writeIntermediate(t, w, 3, 0, `{"Key":"c","Value":"5"}{"Key":"b","Value":"6"}`)
+
+	if _, err := w.Reduce(0, 4, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "4", "b": "8", "c": "9"})
+}
+
+//
+// TestDecodeMalformedArray
+//
+// Checks that a JSON array that is not closed fails the Reduce task.
+//
+func TestDecodeMalformedArray(t *testing.T) {
+	w := newTestWorker(t, "decode")
+
+	writeIntermediate(t, w, 0, 0, `[{"Key":"a","Value":"1"}, {"Key":"b"`)
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); err == nil {
+		t.Fatal("unclosed array decoded")
+	}
+}
+
+//
+// TestDecodeVersions
+//
+// Checks that Map writes the current format version header, and that one Reduce task, over
+// sorted and unsorted partitions, decodes a file written by Map with one written in the
+// legacy headerless format and one with an explicit version 1 header.
+//
+func TestDecodeVersions(t *testing.T) {
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "decode")
+		w.SortPartitions   = sorted
+		w.PartitionsSorted = sorted
+
+		inFile := writeInput(t, w, "input", "a b a")
+
+		if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		header := formatMagic + "1\n"
+
+		if contents := readFile(t, w, w.reduceName(0, 0)); !strings.HasPrefix(contents, header) {
+			t.Fatalf("Map wrote %q, expected header %q", contents, header)
+		}
+
+		writeIntermediate(t, w, 1, 0, `{"Key":"a","Value":"1"}`+"\n"+`{"Key":"c","Value":"1"}`+"\n")
+		writeIntermediate(t, w, 2, 0, "\n"+header+`{"Key":"b","Value":"1"}`+"\n"+`{"Key":"c","Value":"1"}`+"\n")
+
+		if _, err := w.Reduce(0, 3, WordCountReduce); err != nil {
+			t.Fatalf("sorted %v: %v", sorted, err)
+		}
+
+		checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "3", "b": "2", "c": "2"})
+	}
+}
+
+//
+// TestDecodeBadVersion
+//
+// Checks that a version header that is malformed, or names a version newer than the
+// current one, fails the Reduce task.
+//
+func TestDecodeBadVersion(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"newer",     formatMagic + strconv.Itoa(formatCurrent+1) + "\n"},
+		{"malformed", formatMagic + "x\n"},
+		{"zero",      formatMagic + "0\n"},
+	}
+
+	for _, test := range tests {
+		w := newTestWorker(t, "decode")
+
+		writeIntermediate(t, w, 0, 0, test.header+`{"Key":"a","Value":"1"}`+"\n")
+
+		if _, err := w.Reduce(0, 1, WordCountReduce); err == nil {
+			t.Fatalf("%s: header %q decoded", test.name, test.header)
+		}
+	}
+}
+
+//
+// TestStrictDecoding
+//
+// Feeds Reduce, over sorted and unsorted partitions, an intermediate file whose third record
+// has an unexpected field, in the stream and array formats. Checks that with StrictDecoding
+// the task fails, naming the file, the record and the byte offset decoding it began at (its
+// start, or the comma before it), and that without it the field is ignored.
+//
+func TestStrictDecoding(t *testing.T) {
+	header := formatMagic + "1\n"
+	good   := `{"Key":"a","Value":"1"}` + "\n" + `{"Key":"b","Value":"1"}`
+	array  := `[{"Key":"a","Value":"1"}, {"Key":"b","Value":"1"}`
+
+	tests := []struct {
+		name     string
+		contents string
+		offset   int
+	}{
+		{"stream", header + good + "\n" + `{"Key":"c","Value":"1","Extra":"x"}` + "\n", len(header + good + "\n")},
+		{"array",  array + `, {"Key":"c","Count":1,"Value":"1"}]`, len(array)},
+	}
+
+	for _, test := range tests {
+		for _, sorted := range []bool{false, true} {
+			for _, strict := range []bool{false, true} {
+				w := newTestWorker(t, "strict")
+				w.PartitionsSorted = sorted
+				w.StrictDecoding   = strict
+
+				writeIntermediate(t, w, 0, 0, test.contents)
+
+				_, err := w.Reduce(0, 1, WordCountReduce)
+
+				if !strict {
+					if err != nil {
+						t.Fatalf("%s (sorted %v): %v", test.name, sorted, err)
+					}
+
+					checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "1", "b": "1", "c": "1"})
+					continue
+				}
+
+				location := fmt.Sprintf("%s: record 3 near byte offset %d", w.reduceName(0, 0), test.offset)
+
+				if err == nil || !strings.Contains(err.Error(), location) || !strings.Contains(err.Error(), "unknown field") {
+					t.Fatalf("%s (sorted %v): Reduce returned %v, expected an unknown field at %q", test.name, sorted, err, location)
+				}
+			}
+		}
+	}
+}
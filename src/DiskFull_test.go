@@ -0,0 +1,92 @@
+//go:build !plan9
+
+//
+// DiskFull_test.go
+//
+// This file contains tests for the reporting of full-disk errors (see DiskFull.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"slices"
+	"syscall"
+	"testing"
+)
+
+//
+// TestDiskFull
+//
+// Fails the writes of a Map and a Reduce task with ENOSPC, and checks that both report
+// ErrDiskFull, wrapping the original error, and remove the files they created.
+//
+func TestDiskFull(t *testing.T) {
+	w := newTestWorker(t, "full")
+	inFile := writeInput(t, w, "input", testInput)
+
+	full := *w
+	full.Storage = &faultyStorage{Storage: OSStorage{}, writeErr: syscall.ENOSPC}
+
+	_, err := full.Map(0, inFile, 2, WordCountMap)
+
+	if !errors.Is(err, ErrDiskFull) || !errors.Is(err, syscall.ENOSPC) {
+		t.Fatalf("map error %v, expected %v wrapping %v", err, ErrDiskFull, syscall.ENOSPC)
+	}
+
+	if names := listDir(t, w.OutDir); !slices.Equal(names, []string{"input"}) {
+		t.Fatalf("failed map task left %v", names)
+	}
+
+	if _, err = w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	intermediate := listDir(t, w.OutDir)
+
+	if _, err = full.Reduce(0, 1, WordCountReduce); !errors.Is(err, ErrDiskFull) {
+		t.Fatalf("reduce error %v, expected %v", err, ErrDiskFull)
+	}
+
+	if names := listDir(t, w.OutDir); !slices.Equal(names, intermediate) {
+		t.Fatalf("failed reduce task left %v, expected %v", names, intermediate)
+	}
+}
+
+//
+// TestDiskFullCleanupError
+//
+// Checks that when removing the files of a task that failed on a full disk fails too, the
+// removal error is reported alongside ErrDiskFull, rather than in place of it.
+//
+func TestDiskFullCleanupError(t *testing.T) {
+	removeErr := errors.New("injected remove error")
+
+	w := newTestWorker(t, "full")
+	w.Storage = &faultyStorage{Storage: OSStorage{}, writeErr: syscall.ENOSPC, removeErr: removeErr}
+
+	inFile := writeInput(t, &Worker{OutDir: w.OutDir}, "input", testInput)
+
+	_, err := w.Map(0, inFile, 2, WordCountMap)
+
+	if !errors.Is(err, ErrDiskFull) || !errors.Is(err, removeErr) {
+		t.Fatalf("map error %v, expected %v and %v", err, ErrDiskFull, removeErr)
+	}
+}
@@ -0,0 +1,92 @@
+//
+// Dispatch.go
+//
+// This file contains functionality for routing the keys of a Reduce task to different
+// Reduce functions, so that one reduce pass can serve several key families (see
+// Worker.Reducers and Worker.ReducerFor).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+)
+
+//
+// dispatchReduceFunc
+//
+// Wraps the Reduce function of a task so that each key is passed to the Reduce function
+// selected for it. The selection, in order of precedence, is:
+//
+//      1. the function returned by ReducerFor for the key, if ReducerFor is set and returns
+//         non-nil
+//      2. the function in Reducers under the longest prefix of the key, if any
+//      3. reduceFunc, the task's default Reduce function
+//
+//      reduceFunc - the default Reduce function, for keys no other function is selected for
+//
+// Returns reduceFunc itself if no dispatch is configured. Otherwise, the dispatching function.
+//
+func (w *Worker) dispatchReduceFunc(
+	reduceFunc func(key string, values []string) string,
+) func(key string, values []string) string {
+	if w.ReducerFor == nil && len(w.Reducers) == 0 {
+		return reduceFunc
+	}
+
+	return func(key string, values []string) string {
+		return w.reducerFor(key, reduceFunc)(key, values)
+	}
+}
+
+//
+// reducerFor
+//
+// Selects the Reduce function for a key (see dispatchReduceFunc).
+//
+//      key        - the key to be reduced
+//      reduceFunc - the default Reduce function
+//
+// Returns the selected Reduce function.
+//
+func (w *Worker) reducerFor(
+	key        string,
+	reduceFunc func(key string, values []string) string,
+) func(key string, values []string) string {
+	if w.ReducerFor != nil {
+		selected := w.ReducerFor(key)
+
+		if selected != nil {
+			return selected
+		}
+	}
+
+	var selected  func(key string, values []string) string = reduceFunc
+	var prefixLen int                                      = -1
+
+	for prefix, reducer := range w.Reducers {
+		if strings.HasPrefix(key, prefix) && len(prefix) > prefixLen {
+			selected  = reducer
+			prefixLen = len(prefix)
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,80 @@
+//
+// Dispatch_test.go
+//
+// This file contains tests for dispatching keys to Reduce functions.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestReduceDispatch
+//
+// Reduces two key families with different Reduce functions selected by prefix, and checks
+// the precedence of the longest prefix, of ReducerFor, and of the default reduceFunc.
+//
+func TestReduceDispatch(t *testing.T) {
+	keyValues := []KeyValue{
+		{"user:ann", "3"}, {"user:ann", "4"},
+		{"user:admin:root", "1"}, {"user:admin:root", "1"},
+		{"session:1", "a"}, {"session:1", "b"}, {"session:1", "c"},
+		{"vip:bob", "5"}, {"vip:bob", "6"},
+		{"other", "p"}, {"other", "q"},
+	}
+
+	count := func(key string, values []string) string {
+		return strconv.Itoa(len(values))
+	}
+
+	join := func(key string, values []string) string {
+		return strings.Join(values, "+")
+	}
+
+	w := newTestWorker(t, "dispatch")
+	w.Reducers = map[string]func(key string, values []string) string{
+		"user:":       WordCountReduce,
+		"user:admin:": join,
+		"session:":    count,
+	}
+	w.ReducerFor = func(key string) func(key string, values []string) string {
+		if key == "vip:bob" {
+			return WordCountReduce
+		}
+
+		return nil
+	}
+
+	runJob(t, w, 2, emitMap(keyValues...), func(key string, values []string) string {
+		return "default"
+	})
+
+	checkOutput(t, readOutput(t, w, 2), map[string]string{
+		"user:ann":        "7",
+		"user:admin:root": "1+1",
+		"session:1":       "3",
+		"vip:bob":         "11",
+		"other":           "default",
+	})
+}
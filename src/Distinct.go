@@ -0,0 +1,125 @@
+//
+// Distinct.go
+//
+// This file contains functionality for passing only the distinct values of each key to the
+// Reduce function (see Worker.DistinctValues), and for dropping duplicate KeyValues from a
+// final merge (see Worker.DedupFinalMerge).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"iter"
+)
+
+//
+// distinctValues
+//
+// Removes duplicate values, keeping the first occurrence of each in its original order.
+//
+//      values - the values of a key
+//
+// Returns the distinct values. The input array is not modified.
+//
+func distinctValues(values []string) []string {
+	seen     := make(map[string]struct{}, len(values))
+	distinct := make([]string, 0, len(values))
+
+	for _, value := range values {
+		if _, ok := seen[value]; !ok {
+			seen[value] = struct{}{}
+			distinct    = append(distinct, value)
+		}
+	}
+
+	return distinct
+}
+
+//
+// distinctSeq
+//
+// Filters a stream of values so that each distinct value is yielded once, at its first
+// occurrence (see distinctValues).
+// *NOTE* Holds every distinct value seen in memory, for as long as the stream is iterated
+//
+//      values - the stream of values of a key
+//
+// Returns the filtered stream.
+//
+func distinctSeq(values iter.Seq[string]) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		seen := make(map[string]struct{})
+
+		for value := range values {
+			if _, ok := seen[value]; ok {
+				continue
+			}
+
+			seen[value] = struct{}{}
+
+			if !yield(value) {
+				return
+			}
+		}
+	}
+}
+
+//
+// recordDeduper
+//
+// Detects exact duplicate KeyValues in a stream sorted by key. As duplicates share a key,
+// they fall in the same run of equal keys, so only the distinct values of the current run
+// are held: a run normally spans one KeyValue per merge file, so the memory is constant for
+// any number of keys (unlike distinctSeq, which holds every value of a key).
+//
+type recordDeduper struct {
+	key     string
+	values  map[string]struct{}
+	dropped int
+}
+
+//
+// duplicate
+//
+// Checks a KeyValue against those before it in its run of equal keys, counting it as dropped
+// if it repeats one of them.
+//
+//      kv - the next KeyValue of the sorted stream
+//
+// Returns whether the KeyValue is a duplicate.
+//
+func (d *recordDeduper) duplicate(kv KeyValue) bool {
+	if d.values == nil {
+		d.values = make(map[string]struct{})
+	} else if kv.Key != d.key {
+		clear(d.values)
+	}
+
+	d.key = kv.Key
+
+	if _, ok := d.values[kv.Value]; ok {
+		d.dropped++
+		return true
+	}
+
+	d.values[kv.Value] = struct{}{}
+
+	return false
+}
@@ -24,21 +24,29 @@
 // DEALINGS IN THE SOFTWARE.
 //
 import (
-	"bytes"
-	"encoding/json"
+	"cmp"
 	"errors"
 	"fmt"
 	"hash/fnv"
-	"io/fs"
-	"os"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+	"time"
 )
 
+//
+// defaultWriteBufferSize
+//
+// The size of the write buffer used when encoding an intermediate file, unless configured
+// otherwise.
+//
+const defaultWriteBufferSize = 64 * 1024
+
 //
 // doMap
 //
-// This function does the job of a map worker: it reads one of the input files
-// (inFile), calls the user-defined map function for that file's contents
-// (see mapFunc in main/wc.go), and partitions the output into nReduce intermediate files.
+// Does the job of a map worker with the default configuration (see Worker.Map).
 //
 // 		jobName       - the name of the MapReduce job
 //      mapTaskNumber - the unique number assigned to this Map task
@@ -46,162 +54,587 @@ import (
 //      nReduce       - the number of Reduce tasks that will be run
 //      mapFunc		  - the user-defined Map function
 //
+// Returns the outcome of the task (see TaskResult).
+//
 func doMap(
 	jobName       string,
 	mapTaskNumber int,
 	inFile        string,
 	nReduce       int,
 	mapFunc       func(file string, contents string) []KeyValue,
-) {
-	var status int   = 0
-	var err    error = nil
+) TaskResult {
+	return (&Worker{JobName: jobName}).MapTask(mapTaskNumber, inFile, nReduce, mapFunc)
+}
 
-	//
-	// Open and read the contents of the file:
-	//
-	var content string
-	
-	if status == 0 {
-		contentBytes, tempErr := os.ReadFile(inFile)
+//
+// Map
+//
+// This function does the job of a map worker: it reads one of the input files
+// (inFile), calls the user-defined map function for that file's contents
+// (see WordCountMap for an example), and partitions the output into nReduce intermediate files.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Combining (see Worker.Combiner) only runs on a partition whose buffered KeyValues have
+// grown past CombineThreshold bytes, so hot partitions are shrunk without spending time on
+// cold ones. The partition's KeyValues are then replaced by one KeyValue per key, and it is
+// combined again once it has grown past the threshold or twice its combined size, whichever
+// is larger. Since a key's values may be combined any number of times, in any grouping, the
+// Combiner must be associative and accept its own output as input (e.g. a sum).
+//
+// A memory budget (see Worker.CombineBudget) bounds the output Map holds in memory: once the
+// buffered KeyValues of all partitions have grown past CombineBudget bytes, each partition
+// is combined (if there is a Combiner) and flushed to its intermediate file, and buffering
+// starts over. A key may then appear once per flush in a file, so the Combiner must again be
+// associative, with Reduce completing the combining. Flushed partitions are not sorted as a
+// whole, so CombineBudget cannot be used with SortPartitions.
+//
+// In the combined layout (see Worker.CombinedLayout), the partitions are written as
+// consecutive segments of a single file, with an index of their byte offsets, so a job
+// writes nMap files (and indexes) rather than nMap*nReduce. Each Reduce task reads only its
+// own segment of each file. Segments are written in partition order, so the combined layout
+// cannot be used with CombineBudget either.
+//
+// A size threshold (see Worker.SegmentSize) bounds the size of each intermediate file: once
+// a partition's file has grown past SegmentSize bytes, it is completed, and the partition's
+// remaining KeyValues are written to a new file. A partition is then written to numbered
+// segment files (e.g. "mrtmp.job-0-1.0", "mrtmp.job-0-1.1") rather than one file, which
+// Reduce reads back in order as one stream. Sorted partitions stay sorted across their
+// segments. A compressed segment is measured before compression (see Worker.Compression).
+// Segments are not indexed, so SegmentSize cannot be used with the combined layout.
+//
+// An empty input file (e.g. a placeholder, or an input truncated to nothing) is not an
+// error: mapFunc is not called for it, as it has no contents to map, and an empty
+// intermediate file is written for every partition (an empty segment, in the combined
+// layout), so the task's Reduce tasks run as usual. MapStats.InputRecords is then zero.
+//
+// Sorting the partitions (see Worker.SortPartitions) shifts the cost of sorting from the
+// reduce side to the map side, but it allows a Reduce task to merge the sorted partition
+// files as streams, in constant memory, rather than loading every KeyValue into a map.
+//
+// Preallocating partitions (see Worker.PartitionCapacity) gives each partition room for its
+// estimated number of KeyValues when it receives its first one (again after each flush,
+// with CombineBudget), rather than growing it by repeated reallocation and copying. A
+// partition that outgrows its estimate grows as usual, so a low estimate only costs the
+// reallocations it did not save, while a high one holds unused memory until the task ends
+// (see EstimatePartitionCapacity).
+//
+// When sampling (see Worker.SampleRate), each input record is mapped with probability
+// SampleRate, selected deterministically from SampleSeed and the record's position, so the
+// same seed maps the same records. A record is a whole input file for Map, a record of a
+// RecordReader (e.g. a line; see MapRecordReader) or a row of MapCSV. The records left out
+// are counted in MapStats.SampledOutRecords, and MapStats.SampleRate gives the fraction
+// mapped, to scale approximate counts up by.
+//
+// Writing canonical partitions (see Worker.CanonicalPartitions) sorts each partition by key,
+// then value, just before it is written, purely so that intermediate files are reproducible:
+// the same input gives byte-identical files, whatever order mapFunc emitted its KeyValues
+// in, which makes them easy to diff while debugging. Reduce does not need it, and it costs
+// an O(n log n) sort of every partition in every task (of every flush, with CombineBudget,
+// which sorts each flush on its own).
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) Map(
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) []KeyValue,
+) (MapStats, error) {
+	return w.MapErr(mapTaskNumber, inFile, nReduce, func(file string, contents string) ([]KeyValue, error) {
+		return mapFunc(file, contents), nil
+	})
+}
 
-		if tempErr != nil {
-			// Error reading file
-			status = -1
-			err    = tempErr
-		} else {
-			content = string(contentBytes)
+//
+// MapErr
+//
+// This function does the job of a map worker like Map, but with a map function that can
+// fail. A map function that returns ErrSkipRecord (or an error wrapping it) skips its input
+// record: the record's output is discarded and counted in MapStats.SkippedRecords, and the
+// task continues. Any other error fails the task. This lets a job over dirty input skip a
+// few malformed records, rather than lose the whole task.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapErr(
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) ([]KeyValue, error),
+) (MapStats, error) {
+	return mapRecords(w, mapTaskNumber, inFile, 0, nReduce, fileContents(w.storage(), inFile), mapFunc)
+}
+
+//
+// MapReader
+//
+// This function does the job of a map worker like MapErr, but reads its input from a reader
+// rather than a file, e.g. a decompressing reader or a network stream.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      name          - the name of the input, passed to mapFunc as its file
+//      input         - the reader of the input
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapReader(
+	mapTaskNumber int,
+	name          string,
+	input         io.Reader,
+	nReduce       int,
+	mapFunc       func(file string, contents string) ([]KeyValue, error),
+) (MapStats, error) {
+	return mapRecords(w, mapTaskNumber, name, 0, nReduce, readerContents(input), mapFunc)
+}
+
+//
+// fileContents
+//
+// Reads the contents of an input file as a single record (see mapRecords). An empty file
+// has no records, so the Map function is not called for it.
+//
+//      storage - the storage backend holding the input file
+//      inFile  - the name of the input file
+//
+// Returns the sequence of the file's contents, or of the error reading it.
+//
+func fileContents(storage Storage, inFile string) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		contentBytes, err := readStorageFile(storage, inFile)
+
+		if err == nil && len(contentBytes) == 0 {
+			// Empty file: no records
+			return
 		}
+
+		yield(string(contentBytes), err)
 	}
+}
 
-	//
-	// Contruct KeyValue pairs from file content, and encode them to JSON in partitions:
-	//
-	encodingStrings := make([]string, nReduce)
+//
+// readerContents
+//
+// Reads the contents of an input reader as a single record (see mapRecords). An empty input
+// has no records, as with fileContents.
+//
+//      input - the reader of the input
+//
+// Returns the sequence of the reader's contents, or of the error reading it.
+//
+func readerContents(input io.Reader) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		contentBytes, err := io.ReadAll(input)
 
-	if status == 0 {
-		keyValues := mapFunc(inFile, content)
+		if err == nil && len(contentBytes) == 0 {
+			// Empty input: no records
+			return
+		}
 
-		//
-		// Create JSON encoder for each new Reduce file:
-		//
-		buffers  := make([]*bytes.Buffer, nReduce)
-		encoders := make([]*json.Encoder, nReduce)
+		yield(string(contentBytes), err)
+	}
+}
 
-		for i := 0; i < len(encoders); i++ {
-			buffers[i]   = new(bytes.Buffer)
-			encoders[i] = json.NewEncoder(buffers[i])
-		}
+//
+// mapRecords
+//
+// Does the job of a map worker over a sequence of input records: it calls the Map function
+// for each record, and partitions the output of every call into nReduce intermediate files.
+// The records are read as they are mapped, so the input itself is never held in memory as a
+// whole (unless it is a single record).
+//
+// 		w             - the Worker whose configuration is used
+//      mapTaskNumber - the unique number assigned to this Map task
+//      name          - the name of the input, passed to mapFunc as its file
+//      start         - the byte offset of the input within the file (zero unless mapping a
+//                      split), so that each split of a file is sampled apart (see sampled)
+//      nReduce       - the number of Reduce tasks that will be run
+//      records       - the input records, or the error that ended reading them
+//      mapFunc		  - the user-defined Map function, called once per record
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func mapRecords[R any](
+	w             *Worker,
+	mapTaskNumber int,
+	name          string,
+	start         int64,
+	nReduce       int,
+	records       iter.Seq2[R, error],
+	mapFunc       func(file string, record R) ([]KeyValue, error),
+) (MapStats, error) {
+	var status int      = 0
+	var err    error    = nil
+	var stats  MapStats = MapStats{PartitionRecords: make([]int, nReduce)}
 
-		//
-		// For each KeyValue pair, determine respective encoder and encode.
-		//
-		var encIndex uint32
-		var tempErr  error
+	//
+	// Contruct KeyValue pairs from each input record, and divide them into partitions:
+	//
+	var partitions [][]KeyValue         = make([][]KeyValue, nReduce)
+	var out        intermediateWriter = nil
+
+	if w.SortPartitions && w.CombineBudget > 0 {
+		// Partitions flushed in parts cannot be sorted as a whole
+		status = -1
+		err    = errors.New("SortPartitions cannot be used with CombineBudget")
+	} else if w.CombinedLayout && w.CombineBudget > 0 {
+		// Partitions flushed in parts cannot be written as consecutive segments
+		status = -1
+		err    = errors.New("CombinedLayout cannot be used with CombineBudget")
+	} else if w.CombinedLayout && w.SegmentSize > 0 {
+		// A combined file's segments are indexed by offset, so cannot be rolled over
+		status = -1
+		err    = errors.New("CombinedLayout cannot be used with SegmentSize")
+	}
+
+	if status == 0 {
+		partitionBytes := make([]int, nReduce)
+		combineBytes   := make([]int, nReduce)
 
-		for _, kv := range keyValues {
-			encIndex = ihash(kv.Key) % uint32(nReduce) // Why not use round robin?
-			tempErr  = encoders[encIndex].Encode(&kv)
+		var bufferedBytes int = 0
+		var recordIndex   int = 0
+		var encIndex      int
 
+		for record, tempErr := range records {
 			if tempErr != nil {
-				// Error encoding KeyValue
+				// Error reading input
 				status = -1
-				err    = tempErr
+				err    = stageError(ErrReadInput, tempErr)
 				break
 			}
-		}
 
-		if status == 0 {
-			for i := 0; i < len(encodingStrings); i++ {
-				encodingStrings[i] = buffers[i].String()
-			}
-		}
-	}
+			recordIndex++
 
-	//
-	// Creates Reduce files to store encodings:
-	//
-	var outFiles[] *os.File
+			if !w.sampled(name, start, recordIndex-1) {
+				// Record left out of the sample
+				stats.SampledOutRecords++
+				continue
+			}
 
-	if status == 0 {
-		outFiles = make([]*os.File, len(encodingStrings))
+			keyValues, tempErr := callMapFunc(mapFunc, name, record, w.Timeout)
 
-		var tempErr error
+			stats.InputRecords++
 
-		for i := 0; i < len(encodingStrings); i++ {
-			fileName := reduceName(jobName, mapTaskNumber, i)
+			if errors.Is(tempErr, ErrSkipRecord) {
+				// Map function skipped the record
+				stats.SkippedRecords++
+				continue
+			} else if tempErr != nil {
+				// Map function failed, panicked, or timed out
+				status = -1
+				err    = stageError(ErrMapFunc, tempErr)
+				break
+			} else if w.MaxOutputRecords > 0 && len(keyValues) > w.MaxOutputRecords {
+				// Map function emitted too many KeyValues
+				status = -1
+				err    = stageError(ErrMapFunc, fmt.Errorf("Map function emitted %d records on file %q, over the limit of %d", len(keyValues), name, w.MaxOutputRecords))
+				break
+			}
 
 			//
-			// Remove file if it already exists:
-			// *NOTE* Currently not treating this as an error
+			// For each KeyValue pair, determine respective partition:
 			//
-			_, tempErr = os.Stat(fileName)
+			for _, kv := range keyValues {
+				encIndex = w.partitionFor(w.partitionKey(kv), nReduce) // Why not use round robin?
 
-			if tempErr == nil {
-				// No error: file exists
-				tempErr = os.Remove(fileName)
+				if partitions[encIndex] == nil && w.PartitionCapacity > 0 {
+					// Preallocate the partition for its estimated KeyValues
+					partitions[encIndex] = make([]KeyValue, 0, w.PartitionCapacity)
+				}
 
-				if tempErr != nil {
-					// Error removing file
-					status = -1
-					err    = tempErr
-					break
+				partitions[encIndex] = append(partitions[encIndex], kv)
+
+				stats.OutputRecords++
+
+				partitionBytes[encIndex] += len(kv.Key) + len(kv.Value)
+				bufferedBytes            += len(kv.Key) + len(kv.Value)
+
+				//
+				// Combine the partition if it has grown past its threshold:
+				//
+				if w.Combiner != nil && w.CombineThreshold > 0 &&
+					partitionBytes[encIndex] > max(w.CombineThreshold, combineBytes[encIndex]) {
+					partitions[encIndex], tempErr = w.combineKeyValues(partitions[encIndex])
+
+					if tempErr != nil {
+						// Combiner failed, panicked, or timed out
+						status = -1
+						err    = tempErr
+						break
+					}
+
+					bufferedBytes           -= partitionBytes[encIndex]
+					partitionBytes[encIndex] = keyValuesBytes(partitions[encIndex])
+					combineBytes[encIndex]   = 2 * partitionBytes[encIndex]
+					bufferedBytes           += partitionBytes[encIndex]
 				}
-			} else if !errors.Is(tempErr, fs.ErrNotExist) {
-				// Unexpected error acquiring file stats
-				status = -1
-				err = tempErr
+			}
+
+			if status != 0 {
 				break
 			}
 
 			//
-			// Create new file and write:
+			// Flush every partition to its file if the buffered output has grown past the
+			// budget:
 			//
-			if status == 0 {
-				outFiles[i], tempErr = os.Create(fileName)
+			if w.CombineBudget > 0 && bufferedBytes > w.CombineBudget {
+				out, tempErr = w.writePartitions(out, mapTaskNumber, partitions, &stats)
 
 				if tempErr != nil {
-					// Error creating file
+					// Error creating or writing files, or Combiner failed
 					status = -1
 					err    = tempErr
 					break
 				}
 
-				outFiles[i].WriteString(encodingStrings[i])
-				outFiles[i].Close()
+				clear(partitionBytes)
+				clear(combineBytes)
+
+				bufferedBytes = 0
 			}
 		}
+
+		if status == 0 {
+			tempErr := checkFailPoint("map:after-read")
+
+			if tempErr != nil {
+				// Injected failure (see FailPoint.go)
+				status = -1
+				err    = tempErr
+			}
+		}
+
+		//
+		// Sort each partition by key if requested:
+		// *NOTE* Stable, so values of equal keys keep the order mapFunc emitted them in
+		//
+		if status == 0 && w.SortPartitions {
+			for i := 0; i < len(partitions); i++ {
+				sortKeyValues(partitions[i])
+			}
+		}
+	}
+
+	//
+	// Encode the remaining KeyValues of each partition to JSON in its Reduce file:
+	//
+	if status == 0 {
+		var tempErr error
+
+		out, tempErr = w.writePartitions(out, mapTaskNumber, partitions, &stats)
+
+		if tempErr == nil {
+			tempErr = checkFailPoint("map:after-write")
+		}
+
+		//
+		// Close the files once every partition is written:
+		// *NOTE* Files are left open until now so the error path can remove them
+		//
+		if tempErr == nil {
+			tempErr = out.close()
+		}
+
+		if tempErr != nil {
+			// Error creating, writing or closing files, Combiner failed, or injected failure
+			status = -1
+			err    = tempErr
+		}
 	}
 
 	//
 	// Handle any error, and return:
 	//
 	if status != 0 {
+		err = diskFullError(err)
+
 		//
 		// Remove any created intermediate file:
+		// *NOTE* Best effort: errors removing them are reported alongside the error that
+		//        failed the task, rather than in place of it
 		//
-		for i := 0; i < len(outFiles); i++ {
-			if outFiles[i] != nil {
-				fileInfo, tempErr := outFiles[i].Stat()
+		if out != nil {
+			tempErr := out.remove()
 
-				if tempErr != nil {
-					// Error acquiring file stats
-					status = -1
-					err    = tempErr
-				} else {
-					outFiles[i].Close()
-					tempErr = os.Remove(fileInfo.Name())
+			if tempErr != nil {
+				// Error removing files
+				err = errors.Join(err, tempErr)
+			}
+		}
 
-					if tempErr != nil {
-						// Error removing file
-						status = -1
-						err    = tempErr
-					}
-				}
+		w.logf("Function error [DoMap.Map]: %s\n", err.Error())
+	}
+
+	return stats, err
+}
+
+//
+// writePartitions
+//
+// Encodes the buffered KeyValues of every partition to its intermediate file, creating the
+// files first if they are not yet open, and empties the partitions. When flushing under a
+// budget (see Worker.CombineBudget), each partition is combined before it is written.
+//
+//      out           - the open intermediate files (nil means not yet created)
+//      mapTaskNumber - the unique number assigned to this Map task
+//      partitions    - the buffered KeyValues of each partition
+//      stats         - the task's statistics, whose PartitionRecords are counted
+//
+// Returns the open intermediate files, and any error creating or writing them, or from the
+// Combiner. The files are returned even on error, so that the caller can remove them.
+//
+func (w *Worker) writePartitions(
+	out           intermediateWriter,
+	mapTaskNumber int,
+	partitions    [][]KeyValue,
+	stats         *MapStats,
+) (intermediateWriter, error) {
+	var err error = nil
+
+	if out == nil {
+		err = checkFailPoint("map:before-write")
+
+		if err == nil && w.CombinedLayout {
+			out, err = w.createCombinedFile(mapTaskNumber, len(partitions))
+		} else if err == nil {
+			out, err = w.createPartitionFiles(mapTaskNumber, len(partitions))
+		}
+	}
+
+	for i := 0; i < len(partitions) && err == nil; i++ {
+		if w.Combiner != nil && w.CombineBudget > 0 {
+			partitions[i], err = w.combineKeyValues(partitions[i])
+		}
+
+		if err == nil && w.CanonicalPartitions {
+			sortKeyValuesCanonical(partitions[i])
+		}
+
+		if err == nil {
+			err = out.write(i, partitions[i])
+
+			stats.PartitionRecords[i] += len(partitions[i])
+			partitions[i]              = nil
+		}
+	}
+
+	return out, err
+}
+
+//
+// callMapFunc
+//
+// Calls the user-defined Map function for an input record, converting a panic into an error
+// so that one bad input does not crash the whole worker.
+//
+//      mapFunc - the user-defined Map function
+//      file    - the name of the input file
+//      record  - the input record (e.g. the contents of the input file)
+//      timeout - the maximum time the call may run (zero means no timeout)
+//
+// Returns the KeyValue pairs emitted, and the error returned by the Map function or
+// describing the panic or timeout if one occurred.
+//
+func callMapFunc[R any](
+	mapFunc func(file string, record R) ([]KeyValue, error),
+	file    string,
+	record  R,
+	timeout time.Duration,
+) (keyValues []KeyValue, err error) {
+	var result  []KeyValue = nil
+	var callErr error      = nil
+
+	finished := callWithTimeout(timeout, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				result  = nil
+				callErr = fmt.Errorf("Map function panicked on file %q: %v", file, r)
 			}
-		} 
+		}()
+
+		result, callErr = mapFunc(file, record)
+
+		if callErr != nil && !errors.Is(callErr, ErrSkipRecord) {
+			callErr = fmt.Errorf("Map function failed on file %q: %w", file, callErr)
+		}
+	})
 
-		fmt.Printf("Function error [DoMap.doMap]: %s\n", err.Error())
+	if finished {
+		keyValues = result
+		err       = callErr
+	} else {
+		err = fmt.Errorf("Map function timed out after %v on file %q", timeout, file)
 	}
+
+	return
+}
+
+//
+// callWithTimeout
+//
+// Runs a call and waits at most timeout for it to return. A zero timeout waits indefinitely.
+// *NOTE* A call that times out is abandoned, not stopped, as a goroutine cannot be killed.
+//        Its results must not be read once this function has returned false.
+//
+//      timeout - the maximum time to wait for the call
+//      call    - the call to run
+//
+// Returns true if the call returned in time. Otherwise, false.
+//
+func callWithTimeout(timeout time.Duration, call func()) bool {
+	if timeout <= 0 {
+		call()
+		return true
+	}
+
+	done  := make(chan struct{})
+	timer := time.NewTimer(timeout)
+
+	defer timer.Stop()
+
+	go func() {
+		call()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+//
+// partitionFor
+//
+// Determines the Reduce partition a key is assigned to by the default partitioner. This is
+// the same assignment doMap uses to choose the intermediate file of each KeyValue, so it can
+// be used to predict (and assert) which Reduce task will receive a key (see also
+// Worker.partitionFor, and MixedPartitionFor for key sets it spreads poorly).
+//
+//      key     - the key to be partitioned
+//      nReduce - the number of Reduce tasks that will be run
+//
+// Returns the partition number, in the range [0, nReduce).
+//
+func partitionFor(key string, nReduce int) int {
+	return int(ihash(key) % uint32(nReduce))
 }
 
 //
@@ -218,3 +651,30 @@ func ihash(s string) uint32 {
 	h.Write([]byte(s))
 	return h.Sum32()
 }
+
+//
+// sortKeyValues
+//
+// Sorts an array of KeyValue structs by key, keeping the relative order of equal keys.
+//
+//      keyValues - the array of KeyValue structs to sort in place
+//
+func sortKeyValues(keyValues []KeyValue) {
+	sort.SliceStable(keyValues, func(i, j int) bool {
+		return keyValues[i].Key < keyValues[j].Key
+	})
+}
+
+//
+// sortKeyValuesCanonical
+//
+// Sorts an array of KeyValue structs by key, then by value, so that the same KeyValues in any
+// order sort to the same array.
+//
+//      keyValues - the array of KeyValue structs to sort in place
+//
+func sortKeyValuesCanonical(keyValues []KeyValue) {
+	slices.SortFunc(keyValues, func(a KeyValue, b KeyValue) int {
+		return cmp.Or(cmp.Compare(a.Key, b.Key), cmp.Compare(a.Value, b.Value))
+	})
+}
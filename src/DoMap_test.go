@@ -0,0 +1,97 @@
+//
+// DoMap_test.go
+//
+// This file contains tests for the Map worker.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// TestMapSortPartitions
+//
+// Checks that with SortPartitions, every intermediate file Map writes is sorted by key.
+//
+func TestMapSortPartitions(t *testing.T) {
+	w := newTestWorker(t, "sorted")
+	w.SortPartitions = true
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 3, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	var total int = 0
+
+	for r := 0; r < 3; r++ {
+		keyValues := readKeyValueFile(t, w, w.reduceName(0, r))
+
+		sorted := slices.IsSortedFunc(keyValues, func(a, b KeyValue) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+
+		if !sorted {
+			t.Errorf("partition %d is not sorted by key: %v", r, keyValues)
+		}
+
+		total += len(keyValues)
+	}
+
+	if total != len(countWords(testInput)) {
+		t.Errorf("wrote %d KeyValues, expected one per distinct word (%d)", total, len(countWords(testInput)))
+	}
+}
+
+//
+// TestMapMaxOutputRecords
+//
+// Checks that a map function emitting more than MaxOutputRecords KeyValues fails the task
+// with an error naming the input file and the limit, while one emitting exactly the limit
+// succeeds.
+//
+func TestMapMaxOutputRecords(t *testing.T) {
+	w := newTestWorker(t, "limit")
+	w.MaxOutputRecords = 5
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	emit := func(n int) func(file string, contents string) []KeyValue {
+		return func(file string, contents string) []KeyValue {
+			return slices.Repeat([]KeyValue{{"k", "v"}}, n)
+		}
+	}
+
+	if _, err := w.Map(0, inFile, 1, emit(5)); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.Map(0, inFile, 1, emit(6))
+
+	if !errors.Is(err, ErrMapFunc) || !strings.Contains(err.Error(), inFile) || !strings.Contains(err.Error(), "limit of 5") {
+		t.Fatalf("error %v, expected the map function to exceed the limit of 5 on %s", err, inFile)
+	}
+}
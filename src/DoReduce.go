@@ -24,198 +24,514 @@
 // DEALINGS IN THE SOFTWARE.
 //
 import (
+	"bufio"
 	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"iter"
 	"os"
+	"slices"
+	"sync"
+	"time"
 )
 
+//
+// defaultReadBufferSize
+//
+// The size of the read buffer used when decoding a file, unless configured otherwise.
+//
+const defaultReadBufferSize = 64 * 1024
+
 //
 // doReduce
 //
+// Does the job of a reduce worker with the default configuration (see Worker.Reduce).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// When limiting values (see Worker.MaxValuesPerKey), a key's values are reduced to a single
+// partial value whenever MaxValuesPerKey of them have been decoded, and later values are
+// grouped with that partial value. This bounds the memory of a hot key, but is only correct
+// for an associative reduceFunc that accepts its own output as input (e.g. a sum).
+//
+// Returns the outcome of the task (see TaskResult), whose output path is the merge file.
+//
+func doReduce(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+) TaskResult {
+	return (&Worker{JobName: jobName}).ReduceTask(reduceTaskNumber, nMap, reduceFunc)
+}
+
+//
+// Reduce
+//
 // This function does the job of a reduce worker: it reads the intermediate
 // key/value pairs (produced by the map phase) for this task, sorts the
 // intermediate key/value pairs by key, calls the user-defined reduce function
 // (reduceF) for each key, and writes the output to disk.
 //
-// 		jobName          - the name of the MapReduce job
 //      reduceTaskNumber - the unique number assigned to this Reduce task
 //      nMap			 - the number of Map tasks that were run
 //      reduceFunc       - the user-defined Reduce function
 //
-func doReduce(
-	jobName          string,
+// Intermediate files that are empty (e.g. from a Map task over an empty input) hold no
+// KeyValues, and are not an error. If every intermediate file of the task is empty, or
+// missing, the task writes an empty merge file.
+//
+// The merge file is written atomically, through a temporary file that is renamed over it
+// once every output of the task is written (see stageOutputFile), so a failed task leaves
+// any previous merge file in place.
+//
+// The Worker's fields change how keys are grouped, reduced and written, e.g. AppendOutput,
+// Normalize, PartitionsSorted and KeyRange; each field's effect is described with Worker.
+//
+// Each intermediate file is decoded by the format version in its header (see
+// writeFormatHeader), so one task can read files written by Map workers of different
+// versions, e.g. during a rolling upgrade. Files without a header, written before headers
+// were added, are decoded as legacy files.
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task; any previous merge file is left in place.
+//
+func (w *Worker) Reduce(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+) (string, error) {
+	return w.reduceWithSides(reduceTaskNumber, nMap, reduceFunc, nil)
+}
+
+//
+// reduceWithSides
+//
+// Does the job of Reduce, also writing the side outputs emitted by reduceFunc (see
+// Worker.ReduceSide).
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      sides            - collects the side outputs of reduceFunc (nil means none)
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func (w *Worker) reduceWithSides(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	sides            *sideOutputs,
+) (string, error) {
+	reduceFunc = w.dispatchReduceFunc(reduceFunc)
+
+	return w.reduce(reduceTaskNumber, nMap, reduceFunc, w.producer(reduceTaskNumber, nMap, reduceFunc, nil), sides)
+}
+
+//
+// producer
+//
+// Builds the function that produces the results of a Reduce task: by grouping its
+// intermediate files in a map (see groupAndReduce), or by merging them as streams if they
+// are sorted (see Worker.PartitionsSorted).
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the (dispatching) Reduce function
+//      emit             - called with each result as it is produced, instead of collecting
+//                         the results (nil means collect them)
+//
+// Returns the function producing the KeyValue array of Reduce function results (nil when
+// emitting them).
+//
+func (w *Worker) producer(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	emit             func(kv KeyValue) error,
+) func() ([]KeyValue, error) {
+	if w.PartitionsSorted {
+		return func() ([]KeyValue, error) {
+			return w.mergeAndReduce(reduceTaskNumber, nMap, func(key string, values iter.Seq[string]) string {
+				return reduceFunc(key, slices.Collect(values))
+			}, emit)
+		}
+	}
+
+	if w.PipelineReads {
+		return func() ([]KeyValue, error) {
+			// Only a sorted merge can be grouped as it streams
+			return nil, errors.New("PipelineReads requires PartitionsSorted")
+		}
+	}
+
+	return func() ([]KeyValue, error) {
+		return w.groupAndReduce(reduceTaskNumber, nMap, reduceFunc, emit)
+	}
+}
+
+//
+// groupAndReduce
+//
+// Decodes the intermediate files of a Reduce task into a key-to-values map, and calls the
+// user-defined Reduce function for each key.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      emit             - called with each result as it is produced, instead of collecting
+//                         the results (nil means collect them); when reducing in parallel,
+//                         it is called by one goroutine at a time
+//
+// Returns the KeyValue array of Reduce function results (nil when emitting them), and any
+// error producing them.
+//
+func (w *Worker) groupAndReduce(
 	reduceTaskNumber int,
 	nMap             int,
 	reduceFunc       func(key string, values []string) string,
-) {
+	emit             func(kv KeyValue) error,
+) ([]KeyValue, error) {
 	var status int   = 0
 	var err    error = nil
 
 	//
-	// Decode files:
+	// Decode files into a key-to-values map for Reduce function input:
 	//
-	var keyValues []KeyValue = nil
+	var keyValuesMap map[string][]string = nil
+	var keys         []string            = nil
 
 	if status == 0 {
-		for i := 0; i < nMap; i++ {
-			fileName := reduceName(jobName, i, reduceTaskNumber)
+		keyValuesMap = make(map[string][]string)
+		grouper     := newKeyGrouper(w.KeyEquality)
+
+		var tempErr error
 
-			_, tempErr := os.Stat(fileName)
+		for _, i := range w.intermediateTasks(nMap) {
+			tempErr = w.forEachIntermediate(i, reduceTaskNumber, func(kv KeyValue) error {
+				if w.OnIntermediate != nil {
+					w.OnIntermediate(kv)
+				}
+
+				return w.groupKeyValue(keyValuesMap, &keys, grouper, kv, reduceFunc)
+			})
 
 			if tempErr != nil {
-				if errors.Is(tempErr, fs.ErrNotExist) {
-					// File does not exist
-					// *NOTE* Currently not treating this as an error
-				} else {
-					// Some other error acquiring file stats			
-					status = -1
-					err    = tempErr
-					break
+				// Error reading file, or reducing a chunk of values
+				status = -1
+				err    = stageError(ErrDecodeIntermediate, tempErr)
+				break
+			}
+		}
+	}
+
+	//
+	// Create new KeyValue array with Reduce function results, in the order the keys were first
+	// seen if requested:
+	// *NOTE* Otherwise in map order, which is random
+	//
+	var newKeyValues []KeyValue = nil
+
+	if status == 0 && !w.InsertionOrder {
+		keys = keys[:0]
+
+		for key := range keyValuesMap {
+			keys = append(keys, key)
+		}
+	}
+
+	if status == 0 {
+		var emitMutex sync.Mutex
+		var emitErr   error = nil
+
+		if emit == nil {
+			newKeyValues = make([]KeyValue, len(keys))
+		}
+
+		//
+		// Reduce the values of the i-th key into the i-th result:
+		// *NOTE* Each call writes only its own result, so calls may run concurrently
+		//
+		reduceKey := func(i int) error {
+			key   := keys[i]
+			value := keyValuesMap[key]
+
+			if w.DistinctValues {
+				value = distinctValues(value)
+			}
+
+			newValue, tempErr := callReduceFunc(reduceFunc, key, value, w.Timeout)
+
+			if tempErr != nil {
+				// Reduce function panicked or timed out
+				return stageError(ErrReduceFunc, tempErr)
+			}
+
+			if newValue == "error" {
+				return stageError(ErrReduceFunc, errors.New("Reduce Function Error"))
+			}
+
+			if emit != nil {
+				emitMutex.Lock()
+				defer emitMutex.Unlock()
+
+				if emitErr != nil {
+					// An earlier result failed to emit, so emit no more
+					return nil
 				}
-			} else {
-				// No error: file exists
-				var file* os.File = nil
 
-				file, tempErr = os.Open(fileName)
+				emitErr = emit(KeyValue{key, newValue})
 
-				if tempErr != nil {
-					// Error opening file
-					status = -1
-					err    = tempErr
-					break
+				if emitErr != nil {
+					// Emitting the result failed
+					return stageError(ErrWriteMerge, emitErr)
 				}
-	
-				decoder := json.NewDecoder(file)
 
-				var tempKV KeyValue
+				return nil
+			}
 
-				for decoder.More() {
-					tempErr = decoder.Decode(&tempKV)
+			newKeyValues[i] = KeyValue{key, newValue}
 
-					if tempErr != nil {
-						// Error decoding
-						status = -1
-						err    = tempErr
-						file.Close()	
-						break
-					}
+			return nil
+		}
 
-					keyValues = append(keyValues, tempKV)
-				}
+		var tempErr error = nil
 
-				file.Close()
+		if w.ReduceParallelism > 1 {
+			tempErr = runTasks(w.ReduceParallelism, len(keys), reduceKey)
+		} else {
+			for i := 0; i < len(keys) && tempErr == nil; i++ {
+				tempErr = reduceKey(i)
 			}
 		}
+
+		if tempErr != nil {
+			status       = -1
+			err          = tempErr
+			newKeyValues = nil
+		}
 	}
 
+	return newKeyValues, err
+}
+
+//
+// reduce
+//
+// Produces the results of a Reduce task, and writes them to the task's merge file (folding,
+// sorting, and cleaning up as configured; see Worker.Reduce).
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function, used to re-reduce when appending
+//      produce          - produces the KeyValue array of Reduce function results
+//      sides            - collects the side outputs of reduceFunc (nil means none)
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func (w *Worker) reduce(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	produce          func() ([]KeyValue, error),
+	sides            *sideOutputs,
+) (string, error) {
+	var status       int        = 0
+	var err          error      = nil
+	var newKeyValues []KeyValue = nil
+	var tempErr      error      = nil
+
 	//
-	// Create key-to-values map for Reduce function input:
+	// Check that the existing Merge file can be read back, and will be, if appending:
+	// *NOTE* A completion marker only covers the merge file, not the intermediate files
+	//        folded into it, so skipping would drop every later run's new KeyValues
 	//
-	var keyValuesMap map[string][]string = nil
+	if w.AppendOutput {
+		tempErr = w.checkJSONOutput("AppendOutput")
 
-	if status == 0 {
-		keyValuesMap = make(map[string][]string)
+		if tempErr == nil && w.SkipCompleted {
+			tempErr = errors.New("SkipCompleted cannot be used with AppendOutput")
+		}
 
-		for _, kv := range keyValues {
-			keyValuesMap[kv.Key] = append(keyValuesMap[kv.Key], kv.Value)
+		if tempErr != nil {
+			// Output not readable as JSON, or never re-read
+			status = -1
+			err    = tempErr
 		}
 	}
 
 	//
-	// Create new KeyValue array with Reduce function results:
+	// Skip the task if its merge file is already complete, if requested:
 	//
-	var newKeyValues []KeyValue = nil
+	if status == 0 && w.SkipCompleted && w.completedMerge(reduceTaskNumber) {
+		return w.mergeName(reduceTaskNumber), nil
+	}
 
+	//
+	// Produce the Reduce function results:
+	//
 	if status == 0 {
-		var newValue string = ""
+		newKeyValues, tempErr = produce()
 
-		for key, value := range keyValuesMap {
-			
-			newValue = reduceFunc(key, value)
-
-			if newValue == "error" {
-				status = -1
-				err    = errors.New("Reduce Function Error")
-				break
-			}
+		if tempErr == nil {
+			tempErr = checkFailPoint("reduce:after-read")
+		}
 
-			newKeyValues = append(newKeyValues, KeyValue{key, newValue})
+		if tempErr != nil {
+			// Error producing results, or injected failure (see FailPoint.go)
+			status = -1
+			err    = tempErr
 		}
 	}
 
 	//
-	// Encode to JSON:
+	// Fold new results into the existing Merge file's KeyValues, if appending:
 	//
-	var encodingString string = ""
-
-	if status == 0 {
-		buffer  := new(bytes.Buffer)
-		encoder := json.NewEncoder(buffer)
-
-		var tempErr error
+	if status == 0 && w.AppendOutput {
+		existingKeyValues, tempErr := readKeyValues(w.storage(), w.mergeName(reduceTaskNumber), nil, w.ReadBufferSize)
 
-		for _, kv := range newKeyValues {
-			tempErr = encoder.Encode(&kv)
+		if tempErr != nil {
+			// Error reading existing Merge file
+			status = -1
+			err    = tempErr
+		} else {
+			newKeyValues, tempErr = foldKeyValues(existingKeyValues, newKeyValues, reduceFunc, w.Timeout)
 
 			if tempErr != nil {
-				// Error encoding
+				// Error re-reducing a key
 				status = -1
-				err    = tempErr
-				break
+				err    = stageError(ErrReduceFunc, tempErr)
 			}
 		}
+	}
 
-		if status == 0 {
-			encodingString = buffer.String()
+	//
+	// Finalize, filter and sort the results, as requested:
+	//
+	if status == 0 {
+		newKeyValues, tempErr = w.finishResults(newKeyValues)
+
+		if tempErr != nil {
+			// Error finalizing a key
+			status = -1
+			err    = tempErr
 		}
 	}
 
 	//
-	// Create and write encoding to new Merge file:
+	// Stage the results, the side files and the completion marker as temporary files:
+	// *NOTE* Nothing is renamed into place until every one of them is written, so a failed
+	//        task leaves the previous merge file (and its side files) as they were
 	//
-	var outFile *os.File = nil
+	var staged []stagedFile = nil
+
+	if status == 0 {
+		tempErr = checkFailPoint("reduce:before-write")
+
+		if tempErr != nil {
+			// Injected failure (see FailPoint.go)
+			status = -1
+			err    = tempErr
+		}
+	}
 
 	if status == 0 {
-		fileName := mergeName(jobName, reduceTaskNumber)
+		fileName := w.mergeName(reduceTaskNumber)
 		
 		//
-		// Remove file if it already exists:
-		// *NOTE* Currently not treating this as an error
+		// Remove any completion marker first, so it never outlives the file it marks:
 		//
-		_, tempErr := os.Stat(fileName)
+		tempErr := removeCompletionMarker(w.storage(), fileName)
+
+		var mergeFile stagedFile
 
 		if tempErr == nil {
-			// No error: file exists
-			tempErr = os.Remove(fileName)
+			mergeFile, tempErr = w.stageOutputFile(fileName, newKeyValues)
+		}
 
-			if tempErr != nil {
-				// Error removing file
-				status = -1
-				err    = tempErr
-			}
-		} else if !errors.Is(tempErr, fs.ErrNotExist) {
-			// Unexpected error acquiring file stats
+		if tempErr != nil {
+			// Error removing marker, or writing file
+			status = -1
+			err    = stageError(ErrWriteMerge, tempErr)
+		} else {
+			staged = append(staged, mergeFile)
+		}
+	}
+
+	if status == 0 && sides != nil {
+		sideFiles, tempErr := w.stageSideOutputs(reduceTaskNumber, sides)
+
+		staged = append(staged, sideFiles...)
+
+		if tempErr != nil {
+			// Error writing side files
+			status = -1
+			err    = stageError(ErrWriteMerge, tempErr)
+		}
+	}
+
+	if status == 0 {
+		tempErr = checkFailPoint("reduce:after-write")
+
+		if tempErr != nil {
+			// Injected failure (see FailPoint.go)
 			status = -1
 			err    = tempErr
 		}
+	}
 
-		//
-		// Create new file and write:
-		//
-		if status == 0 {
-			outFile, tempErr = os.Create(fileName)
+	//
+	// Mark the merge file complete, if skipping completed tasks:
+	// *NOTE* Staged last, so the marker is renamed into place after the files it covers
+	//
+	if status == 0 && w.SkipCompleted {
+		markerFile, tempErr := w.stageCompletionMarker(staged[0].fileName, staged[0].tempName)
 
-			if tempErr != nil {
-				// Error creating file
-				status = -1
-				err    = tempErr
-			} else {
-				outFile.WriteString(encodingString)
-				outFile.Close()
-			}
+		if tempErr != nil {
+			// Error writing marker
+			status = -1
+			err    = stageError(ErrWriteMerge, tempErr)
+		} else {
+			staged = append(staged, markerFile)
+		}
+	}
+
+	//
+	// Rename the staged files into place:
+	//
+	var outputPath string = ""
+
+	if status == 0 {
+		published, tempErr := w.publishFiles(staged)
+
+		staged = staged[published:]
+
+		if tempErr != nil {
+			// Error renaming or syncing files
+			status = -1
+			err    = stageError(ErrWriteMerge, tempErr)
+		} else {
+			outputPath = w.mergeName(reduceTaskNumber)
+		}
+	}
+
+	//
+	// Remove the intermediate files that were read, if requested:
+	//
+	if status == 0 && w.CleanupIntermediate {
+		tempErr = w.removeIntermediate(reduceTaskNumber, nMap)
+
+		if tempErr != nil {
+			// Error removing files
+			// *NOTE* Not failing the task, as its merge file is complete
+			w.logf("Function warning [DoReduce.Reduce]: %s\n", tempErr.Error())
 		}
 	}
 
@@ -223,28 +539,801 @@ func doReduce(
 	// Handle any error, and return:
 	//
 	if status != 0 {
+		err = diskFullError(err)
+
 		//
-		// Remove intermediate file if created:
+		// Remove the staged files that were not renamed into place:
+		// *NOTE* Best effort: an error removing them is reported alongside the error that
+		//        failed the task, rather than in place of it. Files already renamed into
+		//        place are never removed, as they replaced the previous ones
 		//
-		if outFile != nil {
-			fileInfo, tempErr := outFile.Stat()
+		tempErr := w.discardFiles(staged)
 
-			if tempErr != nil {
-				// Error acquiring file stats
-				status = -1
-				err    = tempErr
-			} else {
-				outFile.Close()
-				tempErr = os.Remove(fileInfo.Name())
-
-				if tempErr != nil {
-					// Error removing file
-					status = -1
-					err    = tempErr
+		if tempErr != nil {
+			// Error removing files
+			err = errors.Join(err, tempErr)
+		}
+
+		w.logf("Function error [DoReduce.Reduce]: %s\n", err.Error())
+	}
+
+	return outputPath, err
+}
+
+//
+// finishResults
+//
+// Prepares the results of a Reduce task for output: finalizes them (see Worker.Finalize),
+// filters them (see Worker.OutputFilter), and sorts them by key (see Worker.SortOutput), as
+// requested.
+//
+//      keyValues - the Reduce function results (after appending)
+//
+// Returns the results to be written, and nil on success. Otherwise, the error finalizing a
+// key.
+//
+func (w *Worker) finishResults(keyValues []KeyValue) ([]KeyValue, error) {
+	if w.Finalize != nil {
+		tempErr := w.finalizeKeyValues(keyValues)
+
+		if tempErr != nil {
+			// Error finalizing a key
+			return keyValues, stageError(ErrReduceFunc, tempErr)
+		}
+	}
+
+	if w.OutputFilter != nil {
+		keyValues = w.OutputFilter(keyValues)
+	}
+
+	if w.SortOutput {
+		sortKeyValues(keyValues)
+	}
+
+	return keyValues, nil
+}
+
+//
+// finalizeKeyValues
+//
+// Replaces the reduced value of each key with its finalized value (see Worker.Finalize).
+//
+//      keyValues - the Reduce function results, finalized in place
+//
+// Returns nil on success. Otherwise, the error finalizing a key (see finalizeKeyValue).
+//
+func (w *Worker) finalizeKeyValues(keyValues []KeyValue) error {
+	var err error = nil
+
+	for i := 0; i < len(keyValues) && err == nil; i++ {
+		err = w.finalizeKeyValue(&keyValues[i])
+	}
+
+	return err
+}
+
+//
+// finalizeKeyValue
+//
+// Replaces the reduced value of a key with its finalized value (see Worker.Finalize).
+//
+//      kv - the Reduce function result, finalized in place
+//
+// Returns nil on success. Otherwise, the error finalizing the key: a panic, a timeout, or
+// the value "error".
+//
+func (w *Worker) finalizeKeyValue(kv *KeyValue) error {
+	newValue, err := callReduce(kv.Key, w.Timeout, func() string {
+		return w.Finalize(kv.Key, kv.Value)
+	})
+
+	if err == nil && newValue == "error" {
+		err = errors.New("Finalize Function Error")
+	}
+
+	if err == nil {
+		kv.Value = newValue
+	}
+
+	return err
+}
+
+//
+// stagedFile
+//
+// An output file written under a temporary name, to be renamed into place once every output
+// file of its task has been written (see publishFiles).
+//
+//      tempName - the path the file was written to
+//      fileName - the path of the output file
+//
+type stagedFile struct {
+	tempName string
+	fileName string
+}
+
+//
+// writeOutputFile
+//
+// Writes KeyValue pairs to an output file (a merge or side file) atomically: they are
+// written to a temporary file, <fileName>.tmp, which is then renamed over the output file,
+// so a reader sees either the previous output file or the complete new one.
+//
+//      fileName  - the name of the output file
+//      keyValues - the KeyValue pairs to be written
+//
+// Returns nil on success. Otherwise, the error that failed the write; the temporary file is
+// removed, and any previous output file is left in place.
+//
+func (w *Worker) writeOutputFile(fileName string, keyValues []KeyValue) error {
+	staged, err := w.stageOutputFile(fileName, keyValues)
+
+	if err == nil {
+		_, err = w.publishFiles([]stagedFile{staged})
+	}
+
+	return err
+}
+
+//
+// stageOutputFile
+//
+// Writes KeyValue pairs to the temporary file of an output file, <fileName>.tmp, leaving any
+// previous output file in place until the temporary file is renamed over it (see
+// publishFiles).
+//
+//      fileName  - the name of the output file
+//      keyValues - the KeyValue pairs to be written
+//
+// Returns the staged file, and nil on success. Otherwise, the error that failed the write;
+// the temporary file is removed.
+//
+func (w *Worker) stageOutputFile(fileName string, keyValues []KeyValue) (stagedFile, error) {
+	storage := w.storage()
+	staged  := stagedFile{tempName: fileName + ".tmp", fileName: fileName}
+
+	file, err := storage.Create(staged.tempName)
+
+	if err == nil {
+		err = w.encodeKeyValues(file, keyValues)
+
+		tempErr := file.Close()
+
+		if err == nil {
+			err = tempErr
+		}
+
+		if err != nil {
+			// Error writing file
+			storage.Remove(staged.tempName)
+		}
+	}
+
+	return staged, err
+}
+
+//
+// publishFiles
+//
+// Renames staged files into place, in order, then syncs their directory (if durable).
+//
+//      files - the staged files
+//
+// Returns the number of files renamed into place, and nil on success. Otherwise, the error
+// that failed a rename or the sync; the files after the last one renamed are left staged,
+// for the caller to discard (see discardFiles).
+//
+func (w *Worker) publishFiles(files []stagedFile) (int, error) {
+	var err       error = nil
+	var published int   = 0
+
+	for _, file := range files {
+		err = w.storage().Rename(file.tempName, file.fileName)
+
+		if err != nil {
+			// Error renaming file
+			break
+		}
+
+		published++
+	}
+
+	if err == nil && w.Durable && len(files) > 0 {
+		err = syncDir(w.storage(), files[0].fileName)
+	}
+
+	return published, err
+}
+
+//
+// discardFiles
+//
+// Removes the temporary files of staged files that were not renamed into place.
+//
+//      files - the staged files
+//
+// Returns nil on success, or if a file no longer exists. Otherwise, the errors removing them.
+//
+func (w *Worker) discardFiles(files []stagedFile) error {
+	var errs []error = nil
+
+	for _, file := range files {
+		tempErr := w.storage().Remove(file.tempName)
+
+		if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+			errs = append(errs, tempErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// encodeKeyValues
+//
+// Encodes KeyValue pairs to an output file in the output format (see Worker.OutputFormat),
+// compressed as configured (see Worker.OutputCompression). The pairs are encoded straight
+// to the file through a buffered writer, so the encoding is never held in memory as a
+// whole. The compression layer is closed and the buffer flushed before the file is synced
+// to disk (if durable), so the file is complete once this returns.
+//
+//      file      - the output file
+//      keyValues - the KeyValue pairs to be encoded
+//
+// Returns nil on success. Otherwise, the error encoding, writing or syncing.
+//
+func (w *Worker) encodeKeyValues(file io.Writer, keyValues []KeyValue) error {
+	var err error = nil
+
+	writer     := bufio.NewWriter(file)
+	compressor := compressWriter(writer, w.OutputCompression)
+	encode, finish := keyValueEncoder(compressor, w.OutputFormat, !w.DisableHTMLEscape, w.recordSeparator())
+
+	for _, kv := range keyValues {
+		err = encode(kv)
+
+		if err != nil {
+			// Error encoding or writing
+			break
+		}
+	}
+
+	if err == nil {
+		err = finish()
+	}
+
+	if err == nil {
+		err = compressor.Close()
+	}
+
+	if err == nil {
+		err = writer.Flush()
+	}
+
+	if err == nil && w.Durable {
+		err = syncFile(file)
+	}
+
+	return err
+}
+
+//
+// callReduceFunc
+//
+// Calls the user-defined Reduce function for a key, converting a panic into an error so that
+// one bad record does not crash the whole worker.
+//
+//      reduceFunc - the user-defined Reduce function
+//      key        - the key to reduce
+//      values     - the list of string values of key
+//      timeout    - the maximum time the call may run (zero means no timeout)
+//
+// Returns the reduced value, and the error describing the panic or timeout if one occurred.
+//
+func callReduceFunc(
+	reduceFunc func(key string, values []string) string,
+	key        string,
+	values     []string,
+	timeout    time.Duration,
+) (string, error) {
+	return callReduce(key, timeout, func() string {
+		return reduceFunc(key, values)
+	})
+}
+
+//
+// callReduce
+//
+// Runs a call of a user-defined Reduce function for a key, converting a panic or timeout
+// into an error (see callReduceFunc).
+//
+//      key     - the key being reduced
+//      timeout - the maximum time the call may run (zero means no timeout)
+//      call    - calls the Reduce function
+//
+// Returns the reduced value, and the error describing the panic or timeout if one occurred.
+//
+func callReduce(key string, timeout time.Duration, call func() string) (value string, err error) {
+	var result  string = ""
+	var callErr error  = nil
+
+	finished := callWithTimeout(timeout, func() {
+		defer func() {
+			if r := recover(); r != nil {
+				if codecErr, ok := r.(valueCodecError); ok {
+					// Value could not be decoded (see ReduceTyped)
+					callErr = fmt.Errorf("Value codec failed on key %q: %w", key, codecErr.err)
+				} else {
+					callErr = fmt.Errorf("Reduce function panicked on key %q: %v", key, r)
 				}
 			}
+		}()
+
+		result = call()
+	})
+
+	if finished {
+		value = result
+		err   = callErr
+	} else {
+		err = fmt.Errorf("Reduce function timed out after %v on key %q", timeout, key)
+	}
+
+	return
+}
+
+//
+// groupKeyValue
+//
+// Adds a decoded KeyValue to the values of its (normalized, then grouped) key, unless the
+// key is outside the Worker's key range, or rejected by its key filter. If the key then has
+// MaxValuesPerKey values, they are reduced to a single partial value (see
+// Worker.MaxValuesPerKey).
+//
+//      keyValuesMap - the key-to-values map to add the KeyValue to
+//      keys         - the keys of the map, in the order first seen, to add a new key to
+//      grouper      - groups keys by the Worker's key equality (nil means string equality)
+//      kv           - the decoded KeyValue
+//      reduceFunc   - the user-defined Reduce function
+//
+// Returns nil on success. Otherwise, the error reducing the key's values.
+//
+func (w *Worker) groupKeyValue(
+	keyValuesMap map[string][]string,
+	keys         *[]string,
+	grouper      *keyGrouper,
+	kv           KeyValue,
+	reduceFunc   func(key string, values []string) string,
+) error {
+	var err error = nil
+
+	key := kv.Key
+
+	if w.Normalize != nil {
+		key = w.Normalize(key)
+	}
+
+	key = grouper.group(key)
+
+	if !w.KeyRange.contains(key) {
+		// Key outside the range being reduced
+		return nil
+	}
+
+	if w.KeyFilter != nil && !w.KeyFilter(key) {
+		// Key not among those being reduced
+		return nil
+	}
+
+	values, exists := keyValuesMap[key]
+
+	if !exists {
+		*keys = append(*keys, key)
+	}
+
+	values = append(values, kv.Value)
+
+	if w.MaxValuesPerKey > 0 && len(values) >= w.MaxValuesPerKey {
+		if w.DistinctValues {
+			values = distinctValues(values)
 		}
 
-		fmt.Printf("Function error [DoReduce.doReduce]: %s\n", err.Error())
+		partialValue, tempErr := callReduceFunc(reduceFunc, key, values, w.Timeout)
+
+		if tempErr != nil {
+			err = stageError(ErrReduceFunc, tempErr)
+		} else if partialValue == "error" {
+			err = stageError(ErrReduceFunc, errors.New("Reduce Function Error"))
+		} else {
+			values = []string{partialValue}
+		}
 	}
-}
\ No newline at end of file
+
+	keyValuesMap[key] = values
+
+	return err
+}
+
+//
+// readKeyValues
+//
+// Decodes the KeyValue pairs of a file, appending them to an array (see forEachKeyValue).
+// *NOTE* A file that does not exist is not treated as an error, and appends nothing
+//
+//      storage    - the storage backend holding the file
+//      fileName   - the name of the file to be decoded
+//      keyValues  - the array to append the decoded KeyValue pairs to
+//      bufferSize - the size of the read buffer (zero means defaultReadBufferSize)
+//
+// Returns the appended array, and any error reading the file.
+//
+func readKeyValues(
+	storage    Storage,
+	fileName   string,
+	keyValues  []KeyValue,
+	bufferSize int,
+) ([]KeyValue, error) {
+	err := forEachKeyValue(storage, fileName, bufferSize, func(kv KeyValue) error {
+		keyValues = append(keyValues, kv)
+		return nil
+	})
+
+	return keyValues, err
+}
+
+//
+// forEachKeyValue
+//
+// Decodes the JSON-encoded KeyValue pairs of a file one at a time, so that a caller can
+// process them without holding the whole file in memory (see openKeyValueFile). The file may
+// be an object stream or an array (see keyValueDecoder).
+// *NOTE* A file that does not exist is not treated as an error, and visits nothing
+//
+//      storage    - the storage backend holding the file
+//      fileName   - the name of the file to be decoded
+//      bufferSize - the size of the read buffer (zero means defaultReadBufferSize)
+//      visit      - called with each decoded KeyValue; an error stops the decoding
+//
+// Returns nil on success. Otherwise, the error reading the file or returned by visit.
+//
+func forEachKeyValue(
+	storage    Storage,
+	fileName   string,
+	bufferSize int,
+	visit      func(kv KeyValue) error,
+) error {
+	file, reader, err := openKeyValueFile(storage, fileName, bufferSize)
+
+	if file != nil {
+		err = decodeKeyValues(reader, visit)
+
+		file.Close()
+	}
+
+	return err
+}
+
+//
+// forEachIntermediate
+//
+// Decodes the KeyValue pairs a Map task wrote for a Reduce task one at a time, from either
+// intermediate layout (see forEachKeyValue and Worker.CombinedLayout).
+// *NOTE* Output that does not exist is not treated as an error, and visits nothing
+//
+// Output cut short part way through a KeyValue, e.g. by a Map task that crashed, fails the
+// decoding, unless tolerating truncation (see Worker.TolerateTruncation); then the KeyValue
+// pairs before the cut are visited, and a warning is logged.
+//
+//      mapTaskNumber    - the number of the Map task that wrote the KeyValue pairs
+//      reduceTaskNumber - the number of the Reduce task that reads them
+//      visit            - called with each decoded KeyValue; an error stops the decoding
+//
+// Returns nil on success. Otherwise, the error reading the output or returned by visit.
+//
+func (w *Worker) forEachIntermediate(
+	mapTaskNumber    int,
+	reduceTaskNumber int,
+	visit            func(kv KeyValue) error,
+) error {
+	file, reader, name, err := w.openIntermediate(mapTaskNumber, reduceTaskNumber)
+
+	if file != nil {
+		var count     int  = 0
+		var truncated bool = false
+
+		truncated, err = decodeKeyValuesWith(reader, name, w.decodeOptions(), func(kv KeyValue) error {
+			count++
+			return visit(kv)
+		})
+
+		file.Close()
+
+		if truncated {
+			w.logf("Function warning [DoReduce.forEachIntermediate]: %s is truncated, keeping the %d KeyValues before its end\n", name, count)
+		}
+	}
+
+	return err
+}
+
+//
+// openIntermediate
+//
+// Opens the KeyValue pairs a Map task wrote for a Reduce task for decoding: its intermediate
+// file, or its partition of the Map task's combined file (see Worker.CombinedLayout), or
+// the Reduce task's pre-shuffled file, in place of every Map task's (see Worker.PreShuffled).
+// *NOTE* Output that does not exist is not treated as an error, and returns a nil file
+//
+//      mapTaskNumber    - the number of the Map task that wrote the KeyValue pairs
+//      reduceTaskNumber - the number of the Reduce task that reads them
+//
+// When memory mapping (see Worker.MmapIntermediate), an intermediate file is decoded from
+// its mapping (see openMappedFile), unless it is too small to gain from it or cannot be
+// mapped (e.g. it is not on the local filesystem), in which case it is read normally.
+//
+// An intermediate file that was rolled over (see Worker.SegmentSize) is read from
+// its segments in order, as one stream (see segmentReader); segments are never mapped.
+//
+// Returns the opened file (which the caller must close), the reader to decode it from, the
+// name of the output (for errors), and any error opening it.
+//
+func (w *Worker) openIntermediate(
+	mapTaskNumber    int,
+	reduceTaskNumber int,
+) (io.Closer, io.Reader, string, error) {
+	var file   io.ReadCloser = nil
+	var reader io.Reader     = nil
+	var name   string        = ""
+	var err    error         = nil
+
+	if w.PreShuffled {
+		file, reader, name, err = w.openShuffled(reduceTaskNumber)
+	} else if w.CombinedLayout {
+		file, reader, name, err = w.openCombinedPartition(mapTaskNumber, reduceTaskNumber)
+	} else {
+		name = w.reduceName(mapTaskNumber, reduceTaskNumber)
+
+		//
+		// Read the file's segments as one stream, if it was rolled over:
+		//
+		fileNames, tempErr := w.intermediateFiles(name)
+
+		if tempErr != nil {
+			// Error finding segments
+			return nil, nil, name, tempErr
+		}
+
+		if len(fileNames) > 0 && fileNames[0] != name {
+			segments := &segmentReader{storage: w.storage(), fileNames: fileNames, bufferSize: w.ReadBufferSize}
+
+			return segments, segments, name, nil
+		}
+
+		if w.MmapIntermediate {
+			mapped, mappedReader, tempErr := openMappedFile(w.storage(), name)
+
+			if mapped != nil || tempErr != nil {
+				// Mapped, or failed to open
+				return mapped, mappedReader, name, tempErr
+			}
+		}
+
+		file, reader, err = openKeyValueFile(w.storage(), name, w.ReadBufferSize)
+	}
+
+	return file, reader, name, err
+}
+
+//
+// mmapMinSize
+//
+// The size under which an intermediate file is read normally rather than memory mapped, as
+// it fits in a read buffer anyway.
+//
+const mmapMinSize = defaultReadBufferSize
+
+//
+// mappedFile
+//
+// A file whose contents are memory mapped (see mmapFile). Closing it unmaps the contents,
+// and closes the file.
+//
+type mappedFile struct {
+	file *os.File
+	data []byte
+}
+
+//
+// Close
+//
+// Unmaps the file's contents, and closes it.
+//
+// Returns any error unmapping or closing.
+//
+func (m *mappedFile) Close() error {
+	err := munmapFile(m.data)
+
+	tempErr := m.file.Close()
+
+	if err == nil {
+		err = tempErr
+	}
+
+	return err
+}
+
+//
+// openMappedFile
+//
+// Opens a file of KeyValue pairs for decoding from a memory mapping of its contents, which
+// avoids copying them through a read buffer. The file may be compressed; its compression is
+// detected from its first bytes (see Compression.go).
+// *NOTE* A file that does not exist, is smaller than mmapMinSize, or cannot be mapped (e.g.
+//        on a platform without mmap, see MmapOff.go, or in a storage backend other than the
+//        local filesystem) is not treated as an error, and returns a nil file, so that the
+//        caller can read it normally
+//
+//      storage  - the storage backend holding the file
+//      fileName - the name of the file to be opened
+//
+// Returns the mapped file (which the caller must close), the reader to decode it from, and
+// any error opening it.
+//
+func openMappedFile(storage Storage, fileName string) (*mappedFile, io.Reader, error) {
+	opened, err := storage.Open(fileName)
+
+	if err != nil {
+		// Error opening file, or file does not exist (left to the normal read to report)
+		return nil, nil, nil
+	}
+
+	file, ok := opened.(*os.File)
+
+	if !ok {
+		// Not on the local filesystem
+		opened.Close()
+		return nil, nil, nil
+	}
+
+	fileInfo, err := file.Stat()
+
+	var data []byte = nil
+
+	if err == nil && fileInfo.Size() >= mmapMinSize {
+		data, err = mmapFile(file, fileInfo.Size())
+	}
+
+	if err != nil || data == nil {
+		// Cannot be mapped, or too small to gain from it
+		file.Close()
+		return nil, nil, nil
+	}
+
+	mapped := &mappedFile{file: file, data: data}
+
+	var reader io.Reader = bytes.NewReader(data)
+
+	if compression := detectCompression(data); compression != CompressionNone {
+		reader, err = decompress(reader, compression)
+
+		if err != nil {
+			// Error reading compression header
+			mapped.Close()
+			return nil, nil, err
+		}
+	}
+
+	return mapped, reader, nil
+}
+
+//
+// openKeyValueFile
+//
+// Opens a file of KeyValue pairs for decoding. The file may be compressed; its compression
+// is detected from its first bytes (see Compression.go).
+// *NOTE* A file that does not exist is not treated as an error, and returns a nil file
+//
+//      storage    - the storage backend holding the file
+//      fileName   - the name of the file to be opened
+//      bufferSize - the size of the read buffer (zero means defaultReadBufferSize)
+//
+// Returns the opened file (which the caller must close), the reader to decode it from, and
+// any error opening it.
+//
+func openKeyValueFile(
+	storage    Storage,
+	fileName   string,
+	bufferSize int,
+) (io.ReadCloser, io.Reader, error) {
+	var file   io.ReadCloser = nil
+	var reader io.Reader     = nil
+	var err    error         = nil
+
+	_, tempErr := storage.Stat(fileName)
+
+	if tempErr != nil {
+		if errors.Is(tempErr, fs.ErrNotExist) {
+			// File does not exist
+		} else {
+			// Some other error acquiring file stats
+			err = tempErr
+		}
+	} else {
+		// No error: file exists
+		file, tempErr = storage.Open(fileName)
+
+		if tempErr != nil {
+			// Error opening file
+			file = nil
+			err  = tempErr
+		} else {
+			if bufferSize <= 0 {
+				bufferSize = defaultReadBufferSize
+			}
+
+			reader, tempErr = decompressReader(bufio.NewReaderSize(file, bufferSize))
+
+			if tempErr != nil {
+				// Error reading compression header
+				file.Close()
+				file = nil
+				err  = tempErr
+			}
+		}
+	}
+
+	return file, reader, err
+}
+
+//
+// foldKeyValues
+//
+// Folds the results of a Reduce task into the KeyValues of an existing Merge file. Keys in
+// both are re-reduced with the values [existing, new] (see Worker.AppendOutput).
+//
+//      existingKeyValues - the KeyValue pairs already in the Merge file
+//      newKeyValues      - the KeyValue pairs produced by the Reduce task
+//      reduceFunc        - the user-defined Reduce function
+//      timeout           - the maximum time each reduceFunc call may run (zero means no timeout)
+//
+// Returns the folded KeyValue pairs, and any error re-reducing a key.
+//
+func foldKeyValues(
+	existingKeyValues []KeyValue,
+	newKeyValues      []KeyValue,
+	reduceFunc        func(key string, values []string) string,
+	timeout           time.Duration,
+) ([]KeyValue, error) {
+	var err error = nil
+
+	indices := make(map[string]int, len(existingKeyValues))
+
+	for i, kv := range existingKeyValues {
+		indices[kv.Key] = i
+	}
+
+	for _, kv := range newKeyValues {
+		i, exists := indices[kv.Key]
+
+		if !exists {
+			existingKeyValues = append(existingKeyValues, kv)
+			continue
+		}
+
+		newValue, tempErr := callReduceFunc(reduceFunc, kv.Key, []string{existingKeyValues[i].Value, kv.Value}, timeout)
+
+		if tempErr != nil {
+			err = stageError(ErrReduceFunc, tempErr)
+			break
+		}
+
+		if newValue == "error" {
+			err = stageError(ErrReduceFunc, errors.New("Reduce Function Error"))
+			break
+		}
+
+		existingKeyValues[i].Value = newValue
+	}
+
+	return existingKeyValues, err
+}
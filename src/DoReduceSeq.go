@@ -0,0 +1,339 @@
+//
+// DoReduceSeq.go
+//
+// This file contains functionality for a 'reduce' worker whose reduce function streams the
+// values of each key, rather than receiving them all at once (see DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"iter"
+	"slices"
+	"sync"
+)
+
+//
+// doReduceSeq
+//
+// Does the job of a streaming reduce worker with the default configuration (see
+// Worker.ReduceSeq).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func doReduceSeq(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values iter.Seq[string]) string,
+) (string, error) {
+	return (&Worker{JobName: jobName}).ReduceSeq(reduceTaskNumber, nMap, reduceFunc)
+}
+
+//
+// ReduceSeq
+//
+// This function does the job of a reduce worker like Reduce, but streams the values of each
+// key to reduceFunc one at a time, rather than collecting them into a slice. This lets a
+// reducer compute a running aggregate over a key with any number of values. The values of a
+// key can only be iterated once, and a reducer may stop iterating early.
+//
+// The values are streamed from a k-way merge of the task's intermediate files (see
+// sortedMerger), so every intermediate file must be sorted by key (see
+// Worker.SortPartitions); an unsorted file fails the task. Since keys are grouped by their
+// sorted order, Normalize and MaxValuesPerKey do not apply. The merge file is written in key
+// order. When appending, keys are re-reduced as in Reduce. When deduplicating, each distinct
+// value is streamed once, at its first occurrence.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task; any previous merge file is left in place.
+//
+func (w *Worker) ReduceSeq(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values iter.Seq[string]) string,
+) (string, error) {
+	sliceReduceFunc := func(key string, values []string) string {
+		return reduceFunc(key, slices.Values(values))
+	}
+
+	return w.reduce(reduceTaskNumber, nMap, sliceReduceFunc, func() ([]KeyValue, error) {
+		return w.mergeAndReduce(reduceTaskNumber, nMap, reduceFunc, nil)
+	}, nil)
+}
+
+//
+// mergeAndReduce
+//
+// Merges the sorted intermediate files of a Reduce task, and calls the user-defined Reduce
+// function for each key with a stream of its values.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      emit             - called with each result as it is produced, instead of collecting
+//                         the results (nil means collect them)
+//
+// Returns the KeyValue array of Reduce function results (nil when emitting them), and any
+// error producing them.
+//
+func (w *Worker) mergeAndReduce(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values iter.Seq[string]) string,
+	emit             func(kv KeyValue) error,
+) ([]KeyValue, error) {
+	var status int   = 0
+	var err    error = nil
+
+	if w.KeyEquality != nil {
+		// Keys equal under the equality need not be adjacent in sorted order
+		return nil, errors.New("KeyEquality cannot be used with a sorted merge")
+	}
+
+	//
+	// Open intermediate files:
+	//
+	var files   []io.Closer = nil
+	var readers []io.Reader = nil
+	var names   []string    = nil
+
+	for _, i := range w.intermediateTasks(nMap) {
+		file, reader, fileName, tempErr := w.openIntermediate(i, reduceTaskNumber)
+
+		if tempErr != nil {
+			// Error opening file
+			status = -1
+			err    = stageError(ErrDecodeIntermediate, tempErr)
+			break
+		}
+
+		if file != nil {
+			files   = append(files, file)
+			readers = append(readers, reader)
+			names   = append(names, fileName)
+		}
+	}
+
+	//
+	// Merge the files, and call the Reduce function with each key's run of values:
+	//
+	var newKeyValues []KeyValue = nil
+
+	if status == 0 {
+		merger, tempErr := newSortedMerger(readers, names, nil, w.decodeOptions())
+
+		if tempErr != nil {
+			// Error decoding
+			status = -1
+			err    = stageError(ErrDecodeIntermediate, tempErr)
+		}
+
+		//
+		// Decode the files ahead of the Reduce function, if requested:
+		//
+		var source   mergeSource      = merger
+		var pipeline *pipelinedMerger = nil
+
+		if status == 0 && w.PipelineReads {
+			pipeline = newPipelinedMerger(merger)
+			source   = pipeline
+		}
+
+		for status == 0 {
+			first, more := source.peek()
+
+			if !more || w.KeyRange.beyond(first.Key) {
+				break
+			}
+
+			group := &valueGroup{merger: source, key: first.Key, onIntermediate: w.OnIntermediate}
+			values := iter.Seq[string](group.values)
+
+			if !w.KeyRange.contains(group.key) || (w.KeyFilter != nil && !w.KeyFilter(group.key)) {
+				//
+				// Skip the values of a key before the range being reduced, or rejected by the
+				// key filter:
+				//
+				for _, more = group.next(); more; _, more = group.next() {
+				}
+
+				if group.err != nil {
+					// Error decoding
+					status = -1
+					err    = stageError(ErrDecodeIntermediate, group.err)
+				}
+
+				continue
+			}
+
+			if w.DistinctValues {
+				values = distinctSeq(values)
+			}
+
+			newValue, tempErr := callReduce(group.key, w.Timeout, func() string {
+				return reduceFunc(group.key, values)
+			})
+
+			if tempErr != nil {
+				// Reduce function panicked or timed out
+				group.abandon()
+				status = -1
+				err    = stageError(ErrReduceFunc, tempErr)
+				break
+			}
+
+			//
+			// Skip any values the Reduce function did not consume:
+			//
+			for _, more = group.next(); more; _, more = group.next() {
+			}
+
+			if group.err != nil {
+				// Error decoding
+				status = -1
+				err    = stageError(ErrDecodeIntermediate, group.err)
+				break
+			}
+
+			if newValue == "error" {
+				status = -1
+				err    = stageError(ErrReduceFunc, errors.New("Reduce Function Error"))
+				break
+			}
+
+			if emit != nil {
+				tempErr = emit(KeyValue{group.key, newValue})
+
+				if tempErr != nil {
+					// Emitting the result failed
+					status = -1
+					err    = stageError(ErrWriteMerge, tempErr)
+					break
+				}
+
+				continue
+			}
+
+			newKeyValues = append(newKeyValues, KeyValue{group.key, newValue})
+		}
+
+		if pipeline != nil {
+			pipeline.stop()
+		}
+
+		for _, name := range merger.truncated {
+			w.logf("Function warning [DoReduceSeq.mergeAndReduce]: %s is truncated, keeping the KeyValues before its end\n", name)
+		}
+	}
+
+	for _, file := range files {
+		file.Close()
+	}
+
+	return newKeyValues, err
+}
+
+//
+// valueGroup
+//
+// The run of values of one key in a merge, streamed to a Reduce function. Access to the
+// merger is locked, since a Reduce function that times out is abandoned while it may still
+// be iterating (see callWithTimeout).
+//
+type valueGroup struct {
+	merger         mergeSource
+	key            string
+	mutex          sync.Mutex
+	abandoned      bool
+	err            error
+	onIntermediate func(kv KeyValue)
+}
+
+//
+// values
+//
+// Yields the remaining values of the group's key (see iter.Seq).
+//
+func (g *valueGroup) values(yield func(value string) bool) {
+	for {
+		value, more := g.next()
+
+		if !more || !yield(value) {
+			return
+		}
+	}
+}
+
+//
+// next
+//
+// Consumes the next value of the group's key from the merger, passing its KeyValue to the
+// group's hook, if any (see Worker.OnIntermediate).
+//
+// Returns the value, and false if there are no more values (or the group was abandoned, or
+// decoding failed).
+//
+func (g *valueGroup) next() (string, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if g.abandoned || g.err != nil {
+		return "", false
+	}
+
+	kv, more := g.merger.peek()
+
+	if !more || kv.Key != g.key {
+		return "", false
+	}
+
+	g.err = g.merger.advance()
+
+	if g.err == nil && g.onIntermediate != nil {
+		g.onIntermediate(kv)
+	}
+
+	return kv.Value, g.err == nil
+}
+
+//
+// abandon
+//
+// Stops the group from consuming any more values from the merger.
+//
+func (g *valueGroup) abandon() {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	g.abandoned = true
+}
@@ -0,0 +1,153 @@
+//
+// DoReduce_test.go
+//
+// This file contains tests for the Reduce worker.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestReduceAppendOutput
+//
+// Checks that with AppendOutput, a second run folds its counts into the merge files of the
+// first, re-reducing the keys both runs saw.
+//
+func TestReduceAppendOutput(t *testing.T) {
+	w := newTestWorker(t, "append")
+	w.AppendOutput = true
+
+	first  := "a b c a"
+	second := "a c d"
+
+	checkOutput(t, runWordCount(t, w, []string{first}, 2), countWords(first))
+	checkOutput(t, runWordCount(t, w, []string{second}, 2), countWords(first, second))
+}
+
+//
+// TestReduceNormalize
+//
+// Checks that with Normalize, keys that differ only in case are grouped and reduced
+// together, under their normalized key.
+//
+func TestReduceNormalize(t *testing.T) {
+	w := newTestWorker(t, "normalize")
+	w.Normalize = strings.ToLower
+
+	// One Reduce task, so keys that normalize alike share a partition
+	output := runWordCount(t, w, []string{"Apple apple APPLE pear", "aPPle Pear"}, 1)
+
+	checkOutput(t, output, map[string]string{"apple": "4", "pear": "2"})
+}
+
+//
+// TestReducePartitionsSorted
+//
+// Runs Map with SortPartitions into Reduce with PartitionsSorted end to end, and checks that
+// the streaming merge gives the same counts as grouping in a map, written in key order.
+//
+func TestReducePartitionsSorted(t *testing.T) {
+	w := newTestWorker(t, "merge")
+	w.SortPartitions   = true
+	w.PartitionsSorted = true
+
+	inputs := []string{testInput, "a fox and a dog", "the fox"}
+
+	checkOutput(t, runWordCount(t, w, inputs, 2), countWords(inputs...))
+
+	for r := 0; r < 2; r++ {
+		keyValues := readKeyValueFile(t, w, w.mergeName(r))
+
+		sorted := slices.IsSortedFunc(keyValues, func(a, b KeyValue) int {
+			return strings.Compare(a.Key, b.Key)
+		})
+
+		if !sorted {
+			t.Errorf("merge file %d is not sorted by key: %v", r, keyValues)
+		}
+	}
+}
+
+//
+// TestReduceDistinctValues
+//
+// Checks that with DistinctValues, reduceFunc sees each distinct value of a key once, in
+// the order first seen, and otherwise sees every duplicate.
+//
+func TestReduceDistinctValues(t *testing.T) {
+	keyValues := []KeyValue{{"k", "x"}, {"k", "y"}, {"k", "x"}, {"k", "z"}, {"k", "y"}}
+
+	for _, test := range []struct {
+		distinct bool
+		expected []string
+	}{
+		{false, []string{"x", "y", "x", "z", "y"}},
+		{true, []string{"x", "y", "z"}},
+	} {
+		var seen []string = nil
+
+		w := newTestWorker(t, "distinct")
+		w.DistinctValues = test.distinct
+
+		runJob(t, w, 1, emitMap(keyValues...), func(key string, values []string) string {
+			seen = slices.Clone(values)
+			return strconv.Itoa(len(values))
+		})
+
+		if !slices.Equal(seen, test.expected) {
+			t.Fatalf("distinct %v: reduceFunc saw %q, expected %q", test.distinct, seen, test.expected)
+		}
+	}
+}
+
+//
+// TestReduceMapTasks
+//
+// Checks that with MapTasks, Reduce reads only the intermediate files of a non-contiguous
+// subset of the Map tasks, tolerating a task in the subset whose file is missing.
+//
+func TestReduceMapTasks(t *testing.T) {
+	w := newTestWorker(t, "subset")
+
+	inputs := []string{"a b", "c d", "e f", "g h"}
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := w.Map(m, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Map task 5 never ran, so its file is missing
+	w.MapTasks = []int{0, 2, 5}
+
+	if _, err := w.Reduce(0, len(inputs), WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), countWords(inputs[0], inputs[2]))
+}
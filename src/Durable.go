@@ -0,0 +1,101 @@
+//
+// Durable.go
+//
+// This file contains functionality for making the files written by 'map' and 'reduce'
+// workers durable before a task reports completion (see Worker.Durable).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+)
+
+//
+// writeFileDurable
+//
+// Writes data to a file like os.WriteFile, through a storage backend, syncing it to disk
+// before closing it if durable.
+//
+//      storage  - the storage backend to write the file to
+//      fileName - the name of the file to be written
+//      data     - the data to be written
+//      durable  - whether the file is synced before it is closed
+//
+// Returns nil on success. Otherwise, the error creating, writing, syncing or closing the
+// file.
+//
+func writeFileDurable(storage Storage, fileName string, data []byte, durable bool) error {
+	file, err := storage.Create(fileName)
+
+	if err == nil {
+		_, err = file.Write(data)
+
+		if err == nil && durable {
+			err = syncFile(file)
+		}
+
+		tempErr := file.Close()
+
+		if err == nil {
+			err = tempErr
+		}
+	}
+
+	return err
+}
+
+//
+// syncFile
+//
+// Syncs a created file to disk, if its storage backend can (see Storage). Backends that
+// cannot are assumed to make a file durable once it is closed.
+//
+//      file - the file to be synced
+//
+// Returns nil on success. Otherwise, the error syncing the file.
+//
+func syncFile(file io.Writer) error {
+	if syncer, ok := file.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
+
+//
+// syncDir
+//
+// Syncs the directory holding a file, so that the file's directory entry (e.g. after a
+// rename) survives a crash, if its storage backend has directories to sync (see
+// OSStorage.SyncDir).
+//
+//      storage  - the storage backend holding the file
+//      fileName - the name of the file whose directory is synced
+//
+// Returns nil on success. Otherwise, the error syncing the directory.
+//
+func syncDir(storage Storage, fileName string) error {
+	if dirs, ok := storage.(interface{ SyncDir(string) error }); ok {
+		return dirs.SyncDir(fileName)
+	}
+
+	return nil
+}
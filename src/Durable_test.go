@@ -0,0 +1,153 @@
+//
+// Durable_test.go
+//
+// This file contains tests for syncing files to disk.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+//
+// syncStorage
+//
+// Wraps the local filesystem to count the files and directories synced.
+//
+//      fileSyncs - the number of files synced
+//      dirSyncs  - the number of directories synced
+//      dirErr    - the error syncing a directory returns (nil means it is synced)
+//
+type syncStorage struct {
+	OSStorage
+	fileSyncs int
+	dirSyncs  int
+	dirErr    error
+}
+
+//
+// countedFile
+//
+// A file created by a syncStorage, counting its syncs.
+//
+type countedFile struct {
+	*os.File
+	storage *syncStorage
+}
+
+func (s *syncStorage) Create(name string) (io.WriteCloser, error) {
+	file, err := s.OSStorage.Create(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &countedFile{File: file.(*os.File), storage: s}, nil
+}
+
+func (s *syncStorage) SyncDir(name string) error {
+	s.dirSyncs++
+
+	if s.dirErr != nil {
+		return s.dirErr
+	}
+
+	return s.OSStorage.SyncDir(name)
+}
+
+func (f *countedFile) Sync() error {
+	f.storage.fileSyncs++
+
+	return f.File.Sync()
+}
+
+//
+// TestDurableSync
+//
+// Checks that with Durable, every file a job writes is synced, as is the directory of each
+// renamed merge file, and that nothing is synced without it.
+//
+func TestDurableSync(t *testing.T) {
+	for _, durable := range []bool{false, true} {
+		storage := &syncStorage{}
+
+		w := newTestWorker(t, "durable")
+		w.Storage = storage
+		w.Durable = durable
+
+		checkOutput(t, runWordCount(t, w, []string{testInput}, 2), countWords(testInput))
+
+		// 2 intermediate files and 2 merge files were written, and the merge files renamed
+		if durable && (storage.fileSyncs < 4 || storage.dirSyncs < 2) {
+			t.Fatalf("durable: %d files and %d directories synced", storage.fileSyncs, storage.dirSyncs)
+		}
+
+		if !durable && (storage.fileSyncs != 0 || storage.dirSyncs != 0) {
+			t.Fatalf("not durable: %d files and %d directories synced", storage.fileSyncs, storage.dirSyncs)
+		}
+	}
+}
+
+//
+// TestDurableSyncDirError
+//
+// Checks that an error syncing the directory of a merge file fails the Reduce task.
+//
+func TestDurableSyncDirError(t *testing.T) {
+	dirErr := errors.New("injected sync error")
+
+	w := newTestWorker(t, "durable")
+	w.Storage = &syncStorage{dirErr: dirErr}
+	w.Durable = true
+
+	writeIntermediate(t, w, 0, 0, `{"Key":"a","Value":"1"}`)
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); !errors.Is(err, dirErr) {
+		t.Fatalf("reduce error %v, expected %v", err, dirErr)
+	}
+}
+
+//
+// TestOSStorageSyncDir
+//
+// Checks that OSStorage syncs the directory of an existing file, and reports the error of
+// a directory that cannot be opened.
+//
+func TestOSStorageSyncDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("directories are not synced on Windows")
+	}
+
+	dir := t.TempDir()
+
+	if err := (OSStorage{}).SyncDir(filepath.Join(dir, "file")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (OSStorage{}).SyncDir(filepath.Join(dir, "missing", "file")); err == nil {
+		t.Fatal("missing directory synced")
+	}
+}
@@ -0,0 +1,124 @@
+//
+// Empty_test.go
+//
+// This file contains tests for empty input files and partitions.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"testing"
+)
+
+//
+// TestEmptyInput
+//
+// Runs a job over an empty input file, and checks that its Map function is never called,
+// that every intermediate file is still written, empty, and that every Reduce task writes
+// an empty merge file.
+//
+func TestEmptyInput(t *testing.T) {
+	w := newTestWorker(t, "empty")
+
+	inFile := writeInput(t, w, "input", "")
+	calls  := 0
+
+	stats, err := w.Map(0, inFile, 3, func(file string, contents string) []KeyValue {
+		calls++
+		return WordCountMap(file, contents)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 0 || stats.InputRecords != 0 {
+		t.Fatalf("Map function called %d times over %d records, expected none", calls, stats.InputRecords)
+	}
+
+	for r := 0; r < 3; r++ {
+		if keyValues := readKeyValueFile(t, w, w.reduceName(0, r)); len(keyValues) != 0 {
+			t.Fatalf("intermediate file %d holds %v, expected none", r, keyValues)
+		}
+
+		if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+
+		if contents := readFile(t, w, w.mergeName(r)); contents != "" {
+			t.Fatalf("merge file %d holds %q, expected nothing", r, contents)
+		}
+	}
+}
+
+//
+// TestEmptyPartitions
+//
+// Runs a job whose Map function emits nothing for a non-empty input, so that every partition
+// is empty, and checks that every Reduce task still succeeds without calling its Reduce
+// function.
+//
+func TestEmptyPartitions(t *testing.T) {
+	w := newTestWorker(t, "nothing")
+
+	runJob(t, w, 4, emitMap(), func(key string, values []string) string {
+		t.Fatalf("Reduce function called for key %q", key)
+		return ""
+	})
+
+	checkOutput(t, readOutput(t, w, 4), map[string]string{})
+}
+
+//
+// TestEmptySplit
+//
+// Checks that a split in which no line starts, inside one long line, has no records, so its
+// Map function is not called, and that the split holding the line maps it whole.
+//
+func TestEmptySplit(t *testing.T) {
+	w := newTestWorker(t, "emptysplit")
+
+	input  := "a long line without a line ending\nend\n"
+	inFile := writeInput(t, w, "input", input)
+	calls  := 0
+
+	mapFunc := func(file string, contents string) []KeyValue {
+		calls++
+		return wordOnesMap(file, contents)
+	}
+
+	if _, err := w.MapSplit(1, InputSplit{File: inFile, Start: 5, Length: 10}, 1, mapFunc); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 0 {
+		t.Fatalf("Map function called %d times for an empty split, expected none", calls)
+	}
+
+	if _, err := w.MapSplit(0, InputSplit{File: inFile, Start: 0, Length: 5}, 1, mapFunc); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Reduce(0, 2, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), countWords("a long line without a line ending"))
+}
@@ -0,0 +1,75 @@
+//
+// Encode_test.go
+//
+// This file contains benchmarks of encoding Reduce output.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"io"
+	"strconv"
+	"testing"
+)
+
+//
+// BenchmarkEncodeKeyValues
+//
+// Compares encoding a Reduce task's results straight to the output file (see
+// encodeKeyValues) with encoding them to a buffer, converting it to a string and writing
+// that, as Reduce used to. Run with -benchmem: streaming never holds the output in memory,
+// where buffering holds it twice, in the buffer and in the string.
+//
+func BenchmarkEncodeKeyValues(b *testing.B) {
+	w := &Worker{}
+
+	keyValues := make([]KeyValue, 100000)
+
+	for i := range keyValues {
+		keyValues[i] = KeyValue{"key-" + strconv.Itoa(i), strconv.Itoa(i * 7)}
+	}
+
+	b.Run("stream", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			if err := w.encodeKeyValues(io.Discard, keyValues); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("string", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			var buffer bytes.Buffer
+
+			if err := w.encodeKeyValues(&buffer, keyValues); err != nil {
+				b.Fatal(err)
+			}
+
+			if _, err := io.WriteString(io.Discard, buffer.String()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
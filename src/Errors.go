@@ -0,0 +1,148 @@
+//
+// Errors.go
+//
+// This file contains the errors that 'map' and 'reduce' workers return for conditions an
+// operator or scheduler can act on (see DoMap.go and DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+//
+// Stage errors
+//
+// Wrapped by the error of a task to identify the stage that failed, so that callers can test
+// for it with errors.Is, e.g. to retry a task on another replica after ErrReadInput, but not
+// after ErrMapFunc or ErrReduceFunc, which would fail again. The underlying error is wrapped
+// too.
+//
+//      ErrReadInput          - Map could not read its input
+//      ErrMapFunc            - the Map function failed, panicked, or timed out
+//      ErrEncodeIntermediate - Map could not encode a KeyValue to an intermediate file
+//      ErrWriteIntermediate  - Map could not create, write or close an intermediate file
+//      ErrDecodeIntermediate - Reduce could not open or decode an intermediate file
+//      ErrReduceFunc         - the Reduce function (or the Combiner) failed, panicked, or
+//                              timed out
+//      ErrWriteMerge         - Reduce could not encode, create or write its merge file
+//
+var (
+	ErrReadInput          = errors.New("read input")
+	ErrMapFunc            = errors.New("map function")
+	ErrEncodeIntermediate = errors.New("encode intermediate")
+	ErrWriteIntermediate  = errors.New("write intermediate")
+	ErrDecodeIntermediate = errors.New("decode intermediate")
+	ErrReduceFunc         = errors.New("reduce function")
+	ErrWriteMerge         = errors.New("write merge")
+)
+
+//
+// stageErrors
+//
+// Every stage error, for checking whether an error has already been attributed to a stage.
+//
+var stageErrors = []error{
+	ErrReadInput,
+	ErrMapFunc,
+	ErrEncodeIntermediate,
+	ErrWriteIntermediate,
+	ErrDecodeIntermediate,
+	ErrReduceFunc,
+	ErrWriteMerge,
+}
+
+//
+// stageError
+//
+// Attributes an error to the stage that failed.
+//
+//      stage - the stage error (e.g. ErrReadInput)
+//      err   - the error that failed the stage
+//
+// Returns the error wrapped with the stage error, or unchanged if it is nil or already
+// attributed to a stage (e.g. a Reduce function error returned through a decoding loop).
+//
+func stageError(stage error, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	for _, attributed := range stageErrors {
+		if errors.Is(err, attributed) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("%w: %w", stage, err)
+}
+
+//
+// encodeError
+//
+// Attributes an error from encoding a KeyValue to an intermediate file: an error marshaling
+// it to JSON to ErrEncodeIntermediate, and an error writing the encoding (which the encoder
+// returns too) to ErrWriteIntermediate.
+//
+//      err - the error returned by the encoder
+//
+// Returns the attributed error.
+//
+func encodeError(err error) error {
+	var unsupportedTypeErr  *json.UnsupportedTypeError
+	var unsupportedValueErr *json.UnsupportedValueError
+	var marshalerErr        *json.MarshalerError
+
+	if errors.As(err, &unsupportedTypeErr) || errors.As(err, &unsupportedValueErr) || errors.As(err, &marshalerErr) {
+		return stageError(ErrEncodeIntermediate, err)
+	}
+
+	return stageError(ErrWriteIntermediate, err)
+}
+
+//
+// ErrDiskFull
+//
+// Wrapped by the error of a task that failed because the disk its files are written to is
+// full (ENOSPC), so that callers can test for it with errors.Is, e.g. to free space or move
+// the job to another disk rather than retry it as is. The underlying error is wrapped too.
+//
+var ErrDiskFull = errors.New("disk full")
+
+//
+// diskFullError
+//
+// Marks an error caused by a full disk with ErrDiskFull (see isDiskFull).
+//
+//      err - the error that failed a task
+//
+// Returns the error wrapped with ErrDiskFull if it is caused by a full disk. Otherwise, the
+// error unchanged.
+//
+func diskFullError(err error) error {
+	if isDiskFull(err) && !errors.Is(err, ErrDiskFull) {
+		return fmt.Errorf("%w: %w", ErrDiskFull, err)
+	}
+
+	return err
+}
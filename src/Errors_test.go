@@ -0,0 +1,90 @@
+//
+// Errors_test.go
+//
+// This file contains tests for the errors of failed tasks.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+//
+// TestStageErrors
+//
+// Injects a failure at each stage of a task, and checks that errors.Is matches the stage's
+// sentinel error, and no other stage's.
+//
+func TestStageErrors(t *testing.T) {
+	faulty := &faultyStorage{Storage: OSStorage{}, writeErr: errTestWrite}
+
+	for _, test := range []struct {
+		stage error
+		fail  func(w *Worker) error
+	}{
+		{ErrReadInput, func(w *Worker) error {
+			_, err := w.Map(0, filepath.Join(w.OutDir, "missing"), 1, WordCountMap)
+			return err
+		}},
+		{ErrMapFunc, func(w *Worker) error {
+			_, err := w.Map(0, writeInput(t, w, "input", testInput), 1, func(file string, contents string) []KeyValue {
+				panic("malformed record")
+			})
+			return err
+		}},
+		{ErrEncodeIntermediate, func(w *Worker) error {
+			return encodeError(&json.UnsupportedValueError{Str: "NaN"})
+		}},
+		{ErrWriteIntermediate, func(w *Worker) error {
+			inFile := writeInput(t, w, "input", testInput)
+			w.Storage = faulty
+			_, err := w.Map(0, inFile, 1, WordCountMap)
+			return err
+		}},
+		{ErrDecodeIntermediate, func(w *Worker) error {
+			writeIntermediate(t, w, 0, 0, "{not json")
+			_, err := w.Reduce(0, 1, WordCountReduce)
+			return err
+		}},
+		{ErrReduceFunc, func(w *Worker) error {
+			writeIntermediate(t, w, 0, 0, `{"Key":"a","Value":"not a count"}`)
+			_, err := w.Reduce(0, 1, WordCountReduce)
+			return err
+		}},
+		{ErrWriteMerge, func(w *Worker) error {
+			writeIntermediate(t, w, 0, 0, `{"Key":"a","Value":"1"}`)
+			w.Storage = faulty
+			_, err := w.Reduce(0, 1, WordCountReduce)
+			return err
+		}},
+	} {
+		err := test.fail(newTestWorker(t, "errors"))
+
+		for _, stage := range stageErrors {
+			if matched := errors.Is(err, stage); matched != (stage == test.stage) {
+				t.Errorf("error %v: errors.Is(err, %v) is %v", err, stage, matched)
+			}
+		}
+	}
+}
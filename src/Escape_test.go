@@ -0,0 +1,59 @@
+//
+// Escape_test.go
+//
+// This file contains tests for the HTML escaping of intermediate and merge files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+	"testing"
+)
+
+//
+// TestDisableHTMLEscape
+//
+// Runs a job with keys and values containing '<', '>' and '&', and checks that they are
+// escaped in the intermediate and merge files by default, left as is with DisableHTMLEscape,
+// and decode to the same output either way.
+//
+func TestDisableHTMLEscape(t *testing.T) {
+	mapFunc := emitMap(KeyValue{"<a&b>", "x<y"}, KeyValue{"c&d", "1 & 2"})
+
+	for _, disable := range []bool{false, true} {
+		w := newTestWorker(t, "escape")
+		w.DisableHTMLEscape = disable
+
+		runJob(t, w, 1, mapFunc, firstValue)
+
+		for _, fileName := range []string{w.reduceName(0, 0), w.mergeName(0)} {
+			contents := readFile(t, w, fileName)
+			raw      := strings.Contains(contents, "<a&b>") && strings.Contains(contents, "1 & 2")
+			escaped  := strings.Contains(contents, `\u003ca\u0026b\u003e`)
+
+			if raw != disable || escaped == disable {
+				t.Errorf("disable %v: %s holds %q", disable, fileName, contents)
+			}
+		}
+
+		checkOutput(t, readOutput(t, w, 1), map[string]string{"<a&b>": "x<y", "c&d": "1 & 2"})
+	}
+}
@@ -0,0 +1,146 @@
+//
+// Events.go
+//
+// This file contains the lifecycle events that Map and Reduce tasks send to an optional
+// channel (see Worker.Events), e.g. to drive a live dashboard of a job.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"time"
+)
+
+//
+// progressEventRecords
+//
+// The number of records a task processes between its progress events.
+//
+const progressEventRecords = 10000
+
+//
+// TaskEventKind
+//
+// Identifies the point in a task's lifecycle that a TaskEvent reports.
+//
+type TaskEventKind int
+
+const (
+	TaskStarted   TaskEventKind = iota // The task has started
+	TaskProgress                       // The task has processed another progressEventRecords records
+	TaskCompleted                      // The task has succeeded
+	TaskFailed                         // The task has failed (see TaskEvent.Err)
+)
+
+//
+// String
+//
+// Returns the name of the event kind.
+//
+func (k TaskEventKind) String() string {
+	switch k {
+	case TaskStarted:
+		return "started"
+	case TaskProgress:
+		return "progress"
+	case TaskCompleted:
+		return "completed"
+	case TaskFailed:
+		return "failed"
+	default:
+		return "unknown"
+	}
+}
+
+//
+// TaskEvent
+//
+// A lifecycle event of a Map or Reduce task (see Worker.Events).
+//
+//      Kind    - the point in the task's lifecycle
+//      Time    - when the event occurred
+//      JobName - the name of the task's MapReduce job
+//      Reduce  - whether the task is a Reduce task, rather than a Map task
+//      Task    - the number of the task
+//      Records - the number of records processed so far: input records for Map,
+//                intermediate KeyValue pairs for Reduce
+//      Err     - the error that failed the task (TaskFailed only)
+//
+type TaskEvent struct {
+	Kind    TaskEventKind
+	Time    time.Time
+	JobName string
+	Reduce  bool
+	Task    int
+	Records int
+	Err     error
+}
+
+//
+// sendEvent
+//
+// Sends a lifecycle event of a task to the Worker's events channel, if any. The send never
+// blocks: the event is dropped if the channel is full, so a slow consumer cannot stall the
+// task.
+//
+//      kind    - the point in the task's lifecycle
+//      reduce  - whether the task is a Reduce task
+//      task    - the number of the task
+//      records - the number of records processed so far
+//      err     - the error that failed the task, or nil
+//
+func (w *Worker) sendEvent(kind TaskEventKind, reduce bool, task int, records int, err error) {
+	if w.Events == nil {
+		return
+	}
+
+	event := TaskEvent{
+		Kind:    kind,
+		Time:    time.Now(),
+		JobName: w.JobName,
+		Reduce:  reduce,
+		Task:    task,
+		Records: records,
+		Err:     err,
+	}
+
+	select {
+	case w.Events <- event:
+	default:
+		// Channel full, drop the event
+	}
+}
+
+//
+// sendResultEvent
+//
+// Sends the event that ends a task, TaskCompleted or TaskFailed, from the task's outcome.
+//
+//      reduce - whether the task is a Reduce task
+//      task   - the number of the task
+//      result - the outcome of the task
+//
+func (w *Worker) sendResultEvent(reduce bool, task int, result TaskResult) {
+	if result.Success {
+		w.sendEvent(TaskCompleted, reduce, task, result.RecordsIn, nil)
+	} else {
+		w.sendEvent(TaskFailed, reduce, task, result.RecordsIn, result.Err)
+	}
+}
@@ -0,0 +1,165 @@
+//
+// Events_test.go
+//
+// This file contains tests for task lifecycle events.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//
+// drainEvents
+//
+// Receives the events buffered in a channel, without waiting for more.
+//
+//      events - the channel
+//
+// Returns the events, in the order sent.
+//
+func drainEvents(events chan TaskEvent) []TaskEvent {
+	var drained []TaskEvent = nil
+
+	for {
+		select {
+		case event := <-events:
+			drained = append(drained, event)
+		default:
+			return drained
+		}
+	}
+}
+
+//
+// checkEvents
+//
+// Fails the test if events differ in kind, task or record count from the expected ones, or
+// are not in time order, or name the wrong job or kind of task.
+//
+//      t        - the test
+//      w        - the Worker that sent the events
+//      events   - the events sent
+//      reduce   - whether the events are of a Reduce task
+//      task     - the number of the task
+//      expected - the expected kind and record count of each event
+//
+func checkEvents(t testing.TB, w *Worker, events []TaskEvent, reduce bool, task int, expected []TaskEvent) {
+	t.Helper()
+
+	if len(events) != len(expected) {
+		t.Fatalf("%d events %v, expected %d", len(events), events, len(expected))
+	}
+
+	for i, event := range events {
+		if event.Kind != expected[i].Kind || event.Records != expected[i].Records {
+			t.Errorf("event %d: %s after %d records, expected %s after %d", i, event.Kind, event.Records, expected[i].Kind, expected[i].Records)
+		}
+
+		if event.JobName != w.JobName || event.Reduce != reduce || event.Task != task {
+			t.Errorf("event %d: of job %s, reduce %v, task %d", i, event.JobName, event.Reduce, event.Task)
+		}
+
+		if i > 0 && event.Time.Before(events[i-1].Time) {
+			t.Errorf("event %d at %s, before event %d", i, event.Time, i-1)
+		}
+	}
+}
+
+//
+// TestTaskEvents
+//
+// Drains the events of a successful Map and Reduce task, and checks that each starts, that
+// the Reduce task reports progress every progressEventRecords records, and that each then
+// completes.
+//
+func TestTaskEvents(t *testing.T) {
+	events := make(chan TaskEvent, 100)
+
+	w := newTestWorker(t, "events")
+	w.Events = events
+
+	inFile := writeInput(t, w, "input", strings.Repeat("a b ", 12500))
+
+	if result := w.MapTask(3, inFile, 1, wordOnesMap); !result.Success {
+		t.Fatal(result.Err)
+	}
+
+	checkEvents(t, w, drainEvents(events), false, 3, []TaskEvent{
+		{Kind: TaskStarted},
+		{Kind: TaskCompleted, Records: 1},
+	})
+
+	if result := w.ReduceTask(0, 4, WordCountReduce); !result.Success {
+		t.Fatal(result.Err)
+	}
+
+	checkEvents(t, w, drainEvents(events), true, 0, []TaskEvent{
+		{Kind: TaskStarted},
+		{Kind: TaskProgress, Records: progressEventRecords},
+		{Kind: TaskProgress, Records: 2 * progressEventRecords},
+		{Kind: TaskCompleted, Records: 25000},
+	})
+}
+
+//
+// TestTaskEventsFailed
+//
+// Checks that a failed task ends with a TaskFailed event carrying its error.
+//
+func TestTaskEventsFailed(t *testing.T) {
+	events := make(chan TaskEvent, 100)
+
+	w := newTestWorker(t, "failed")
+	w.Events = events
+
+	result := w.MapTask(0, filepath.Join(w.OutDir, "missing"), 1, WordCountMap)
+	sent   := drainEvents(events)
+
+	checkEvents(t, w, sent, false, 0, []TaskEvent{{Kind: TaskStarted}, {Kind: TaskFailed}})
+
+	if !errors.Is(sent[1].Err, ErrReadInput) || sent[1].Err != result.Err {
+		t.Fatalf("failed event error %v, expected the task's %v", sent[1].Err, result.Err)
+	}
+}
+
+//
+// TestTaskEventsFull
+//
+// Checks that a task whose events channel is full drops its events rather than blocking.
+//
+func TestTaskEventsFull(t *testing.T) {
+	events := make(chan TaskEvent, 1)
+
+	w := newTestWorker(t, "full")
+	w.Events = events
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if result := w.MapTask(0, inFile, 1, WordCountMap); !result.Success {
+		t.Fatal(result.Err)
+	}
+
+	checkEvents(t, w, drainEvents(events), false, 0, []TaskEvent{{Kind: TaskStarted}})
+}
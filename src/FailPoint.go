@@ -0,0 +1,64 @@
+//go:build failpoint
+
+//
+// FailPoint.go
+//
+// This file contains the fail point consulted by 'map' and 'reduce' workers at key stages,
+// so that tests can make tasks fail at precise moments (see DoMap.go and DoReduce.go). It is
+// only built with the "failpoint" build tag (e.g. go test -tags failpoint); otherwise, see
+// FailPointOff.go.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// failPoint
+//
+// Called with the name of each stage a task reaches; returning an error fails the task at
+// that stage, as if the stage itself had failed (nil means no fail point). The stages are:
+//
+//      map:after-read      - Map has read and mapped its input
+//      map:before-write    - Map is about to create its intermediate files
+//      map:after-write     - Map has encoded its intermediate files, before closing them
+//      reduce:after-read   - Reduce has read and reduced its intermediate files
+//      reduce:before-write - Reduce is about to create its merge file
+//      reduce:after-write  - Reduce has written its merge file, before renaming it into place
+//
+// *NOTE* Not synchronized: set it before starting any task, and reset it after
+//
+var failPoint func(stage string) error = nil
+
+//
+// checkFailPoint
+//
+// Consults the fail point for a stage.
+//
+//      stage - the name of the stage reached
+//
+// Returns the error to fail the task with, or nil to continue.
+//
+func checkFailPoint(stage string) error {
+	if failPoint == nil {
+		return nil
+	}
+
+	return failPoint(stage)
+}
@@ -0,0 +1,122 @@
+//go:build failpoint
+
+//
+// FailPoint_test.go
+//
+// This file contains tests that fail tasks at each stage through the fail point (see
+// FailPoint.go). Run them with go test -tags failpoint.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+//
+// errTestFailPoint
+//
+// The error injected by the fail point tests.
+//
+var errTestFailPoint = errors.New("injected failure")
+
+//
+// failAt
+//
+// Sets the fail point to fail one stage, until the test ends.
+//
+//      t     - the test
+//      stage - the stage to fail
+//
+func failAt(t *testing.T, stage string) {
+	failPoint = func(reached string) error {
+		if reached == stage {
+			return errTestFailPoint
+		}
+
+		return nil
+	}
+
+	t.Cleanup(func() {
+		failPoint = nil
+	})
+}
+
+//
+// TestFailPointMap
+//
+// Fails a Map task at each of its stages, and checks that the task returns the injected
+// error and leaves no intermediate files behind.
+//
+func TestFailPointMap(t *testing.T) {
+	for _, stage := range []string{"map:after-read", "map:before-write", "map:after-write"} {
+		t.Run(stage, func(t *testing.T) {
+			w := newTestWorker(t, "failpoint")
+			inFile := writeInput(t, w, "input", testInput)
+
+			failAt(t, stage)
+
+			if _, err := w.Map(0, inFile, 2, WordCountMap); !errors.Is(err, errTestFailPoint) {
+				t.Fatalf("error %v, expected %v", err, errTestFailPoint)
+			}
+
+			if names := listDir(t, w.OutDir); !slices.Equal(names, []string{"input"}) {
+				t.Fatalf("left %v", names)
+			}
+		})
+	}
+}
+
+//
+// TestFailPointReduce
+//
+// Fails a Reduce task at each of its stages, and checks that the task returns the injected
+// error. A task failing at any stage leaves the merge file of an earlier run in place, and
+// no temporary files behind.
+//
+func TestFailPointReduce(t *testing.T) {
+	for _, stage := range []string{"reduce:after-read", "reduce:before-write", "reduce:after-write"} {
+		t.Run(stage, func(t *testing.T) {
+			w := newTestWorker(t, "failpoint")
+			runWordCount(t, w, []string{testInput}, 1)
+
+			output := readFile(t, w, w.mergeName(0))
+
+			failAt(t, stage)
+
+			if _, err := w.Reduce(0, 1, WordCountReduce); !errors.Is(err, errTestFailPoint) {
+				t.Fatalf("error %v, expected %v", err, errTestFailPoint)
+			}
+
+			expected := []string{"input-0", filepath.Base(w.reduceName(0, 0)), filepath.Base(w.mergeName(0))}
+
+			if names := listDir(t, w.OutDir); !slices.Equal(names, expected) {
+				t.Fatalf("left %v, expected %v", names, expected)
+			}
+
+			if readFile(t, w, w.mergeName(0)) != output {
+				t.Fatalf("merge file changed, expected %q", output)
+			}
+		})
+	}
+}
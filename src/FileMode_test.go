@@ -0,0 +1,99 @@
+//go:build unix
+
+//
+// FileMode_test.go
+//
+// This file contains tests for setting the permissions of created files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+//
+// TestFileMode
+//
+// Runs a word count job with each of several file modes, under a umask that would mask
+// them, over a merge file left with other permissions by an earlier run, and checks that
+// every file the job created has exactly the requested mode.
+//
+func TestFileMode(t *testing.T) {
+	umask := syscall.Umask(0077)
+	t.Cleanup(func() {
+		syscall.Umask(umask)
+	})
+
+	for _, mode := range []fs.FileMode{0640, 0644, 0600} {
+		w := newTestWorker(t, "filemode")
+		w.FileMode = mode
+
+		if err := os.WriteFile(w.mergeName(0), []byte("stale"), 0666); err != nil {
+			t.Fatal(err)
+		}
+
+		runWordCount(t, w, []string{testInput, "a fox and a dog"}, 2)
+
+		for _, name := range listDir(t, w.OutDir) {
+			info, err := os.Stat(filepath.Join(w.OutDir, name))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if info.Mode().Perm() != mode {
+				t.Fatalf("file %s created with mode %v, expected %v", name, info.Mode().Perm(), mode)
+			}
+		}
+	}
+}
+
+//
+// TestFileModeDefault
+//
+// Checks that without a FileMode, created files have os.Create's mode, masked by the umask.
+//
+func TestFileModeDefault(t *testing.T) {
+	umask := syscall.Umask(0027)
+	t.Cleanup(func() {
+		syscall.Umask(umask)
+	})
+
+	w := newTestWorker(t, "filemode")
+
+	runWordCount(t, w, []string{testInput}, 1)
+
+	for _, name := range listDir(t, w.OutDir) {
+		info, err := os.Stat(filepath.Join(w.OutDir, name))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if info.Mode().Perm() != 0640 {
+			t.Fatalf("file %s created with mode %v, expected %v", name, info.Mode().Perm(), fs.FileMode(0640))
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//
+// Finalize_test.go
+//
+// This file contains tests for finalizing Reduce results.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// sumCount
+//
+// A Reduce function that adds up "<sum>,<count>" values, accepting its own output.
+//
+//      key    - the key
+//      values - the partial sums and counts of the key
+//
+// Returns the total "<sum>,<count>", or "error" if a value is malformed.
+//
+func sumCount(key string, values []string) string {
+	var sum   float64 = 0
+	var count int     = 0
+
+	for _, value := range values {
+		sumText, countText, _ := strings.Cut(value, ",")
+
+		partialSum, sumErr     := strconv.ParseFloat(sumText, 64)
+		partialCount, countErr := strconv.Atoi(countText)
+
+		if sumErr != nil || countErr != nil {
+			return "error"
+		}
+
+		sum   += partialSum
+		count += partialCount
+	}
+
+	return strconv.FormatFloat(sum, 'g', -1, 64) + "," + strconv.Itoa(count)
+}
+
+//
+// mean
+//
+// A Finalize function that divides a "<sum>,<count>" value into its mean.
+//
+//      key      - the key
+//      combined - the total "<sum>,<count>" of the key
+//
+// Returns the mean, or "error" if the value is malformed.
+//
+func mean(key string, combined string) string {
+	sumText, countText, _ := strings.Cut(combined, ",")
+
+	sum, sumErr     := strconv.ParseFloat(sumText, 64)
+	count, countErr := strconv.Atoi(countText)
+
+	if sumErr != nil || countErr != nil || count == 0 {
+		return "error"
+	}
+
+	return strconv.FormatFloat(sum/float64(count), 'g', -1, 64)
+}
+
+//
+// TestFinalizeAverage
+//
+// Computes the average score of each student with a sum and count Reduce function and a
+// dividing Finalize function, and checks that Finalize runs once per key.
+//
+func TestFinalizeAverage(t *testing.T) {
+	w := newTestWorker(t, "average")
+
+	calls := 0
+
+	w.Finalize = func(key string, combined string) string {
+		calls++
+		return mean(key, combined)
+	}
+
+	mapFunc := emitMap(
+		KeyValue{"ann", "90,1"}, KeyValue{"bob", "70,1"}, KeyValue{"ann", "80,1"},
+		KeyValue{"cal", "65,1"}, KeyValue{"bob", "75,1"}, KeyValue{"ann", "100,1"},
+	)
+
+	runJob(t, w, 2, mapFunc, sumCount)
+
+	checkOutput(t, readOutput(t, w, 2), map[string]string{"ann": "90", "bob": "72.5", "cal": "65"})
+
+	if calls != 3 {
+		t.Fatalf("Finalize called %d times, expected once per key (3)", calls)
+	}
+}
+
+//
+// TestFinalizeIdentity
+//
+// Checks that without Finalize, the reduced value is written as is.
+//
+func TestFinalizeIdentity(t *testing.T) {
+	w := newTestWorker(t, "identity")
+
+	runJob(t, w, 1, emitMap(KeyValue{"ann", "90,1"}, KeyValue{"ann", "80,1"}), sumCount)
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"ann": "170,2"})
+}
+
+//
+// TestFinalizeError
+//
+// Checks that a Finalize function returning "error" fails the Reduce task.
+//
+func TestFinalizeError(t *testing.T) {
+	w := newTestWorker(t, "finalerr")
+	w.Finalize = mean
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, emitMap(KeyValue{"ann", "90,0"})); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Reduce(0, 1, sumCount); !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrReduceFunc)
+	}
+}
@@ -0,0 +1,433 @@
+//
+// Fixtures_test.go
+//
+// This file contains the fixtures shared by the package's tests: workers writing to a
+// temporary directory, input files, and the readers of their results.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// testInput
+//
+// A small word count input, whose words land in several partitions for any nReduce.
+//
+const testInput = "the quick brown fox jumps over the lazy dog the end"
+
+//
+// testStorage
+//
+// Builds the storage backend of the Workers of newTestWorker (nil means the default,
+// OSStorage). Set by useMemoryStorage, to run tests against a MemoryStorage.
+//
+var testStorage func() Storage = nil
+
+//
+// newTestWorker
+//
+// Builds a Worker with the default configuration, writing to a temporary directory that is
+// removed when the test ends, in the test storage backend (see testStorage).
+//
+//      t       - the test
+//      jobName - the name of the MapReduce job
+//
+// Returns the Worker.
+//
+func newTestWorker(t testing.TB, jobName string) *Worker {
+	w := &Worker{JobName: jobName, OutDir: t.TempDir()}
+
+	if testStorage != nil {
+		w.Storage = testStorage()
+	}
+
+	return w
+}
+
+//
+// writeInput
+//
+// Writes an input file to a Worker's output directory, through its storage.
+//
+//      t        - the test
+//      w        - the Worker
+//      name     - the base name of the input file
+//      contents - the contents of the input file
+//
+// Returns the path of the input file.
+//
+func writeInput(t testing.TB, w *Worker, name string, contents string) string {
+	t.Helper()
+
+	fileName := filepath.Join(w.OutDir, name)
+	file, err := w.storage().Create(fileName)
+
+	if err == nil {
+		_, err = file.Write([]byte(contents))
+
+		if tempErr := file.Close(); err == nil {
+			err = tempErr
+		}
+	}
+
+	if err != nil {
+		t.Fatalf("writing input %s: %v", fileName, err)
+	}
+
+	return fileName
+}
+
+//
+// readFile
+//
+// Reads the contents of a file through a Worker's storage.
+//
+//      t        - the test
+//      w        - the Worker
+//      fileName - the path of the file
+//
+// Returns the contents of the file.
+//
+func readFile(t testing.TB, w *Worker, fileName string) string {
+	t.Helper()
+
+	file, err := w.storage().Open(fileName)
+
+	if err != nil {
+		t.Fatalf("opening %s: %v", fileName, err)
+	}
+
+	defer file.Close()
+
+	var contents strings.Builder
+
+	if _, err = io.Copy(&contents, file); err != nil {
+		t.Fatalf("reading %s: %v", fileName, err)
+	}
+
+	return contents.String()
+}
+
+//
+// readKeyValueFile
+//
+// Decodes the KeyValues of an intermediate or merge file, in the order they were written.
+//
+//      t        - the test
+//      w        - the Worker whose storage holds the file
+//      fileName - the path of the file
+//
+// Returns the KeyValues of the file.
+//
+func readKeyValueFile(t testing.TB, w *Worker, fileName string) []KeyValue {
+	t.Helper()
+
+	keyValues, err := readKeyValues(w.storage(), fileName, nil, 0)
+
+	if err != nil {
+		t.Fatalf("decoding %s: %v", fileName, err)
+	}
+
+	return keyValues
+}
+
+//
+// readOutput
+//
+// Reads the merge files of every Reduce task of a job into one map, failing the test if a key
+// is written by more than one task, or more than once.
+//
+//      t       - the test
+//      w       - the Worker that ran the Reduce tasks
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns the job's output, by key.
+//
+func readOutput(t testing.TB, w *Worker, nReduce int) map[string]string {
+	t.Helper()
+
+	output := make(map[string]string)
+
+	for r := 0; r < nReduce; r++ {
+		for _, kv := range readKeyValueFile(t, w, w.mergeName(r)) {
+			if _, exists := output[kv.Key]; exists {
+				t.Fatalf("key %q written more than once", kv.Key)
+			}
+
+			output[kv.Key] = kv.Value
+		}
+	}
+
+	return output
+}
+
+//
+// countWords
+//
+// Counts the space-separated words of some inputs, as the word count job should.
+//
+//      inputs - the contents of the input files
+//
+// Returns the count of each word.
+//
+func countWords(inputs ...string) map[string]string {
+	counts := make(map[string]int)
+
+	for _, input := range inputs {
+		for _, word := range strings.Fields(input) {
+			counts[word]++
+		}
+	}
+
+	expected := make(map[string]string)
+
+	for word, count := range counts {
+		expected[word] = strconv.Itoa(count)
+	}
+
+	return expected
+}
+
+//
+// runWordCount
+//
+// Runs a word count job over some inputs with a Worker: one Map task per input, then every
+// Reduce task, failing the test on any error.
+//
+//      t       - the test
+//      w       - the Worker that runs the tasks
+//      inputs  - the contents of the input files
+//      nReduce - the number of Reduce tasks to run
+//
+// Returns the job's output, by key.
+//
+func runWordCount(t testing.TB, w *Worker, inputs []string, nReduce int) map[string]string {
+	t.Helper()
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := w.Map(m, inFile, nReduce, WordCountMap); err != nil {
+			t.Fatalf("map task %d: %v", m, err)
+		}
+	}
+
+	for r := 0; r < nReduce; r++ {
+		if _, err := w.Reduce(r, len(inputs), WordCountReduce); err != nil {
+			t.Fatalf("reduce task %d: %v", r, err)
+		}
+	}
+
+	return readOutput(t, w, nReduce)
+}
+
+//
+// checkOutput
+//
+// Fails the test if a job's output differs from the expected output.
+//
+//      t        - the test
+//      output   - the job's output, by key
+//      expected - the expected output, by key
+//
+func checkOutput(t testing.TB, output map[string]string, expected map[string]string) {
+	t.Helper()
+
+	if !maps.Equal(output, expected) {
+		t.Fatalf("output %v, expected %v", output, expected)
+	}
+}
+
+//
+// faultyStorage
+//
+// Wraps a Storage so that writes to the files it creates fail, after the files are created,
+// to test the cleanup of failed tasks. Not safe for concurrent use.
+//
+//      Storage     - the wrapped storage
+//      writeErr    - the error every write returns
+//      removeErr   - the error removals return once a write has failed, i.e. while a failed
+//                    task cleans up (nil means removals succeed)
+//      writeFailed - whether a write has failed
+//
+type faultyStorage struct {
+	Storage
+	writeErr    error
+	removeErr   error
+	writeFailed bool
+}
+
+//
+// faultyWriter
+//
+// A file created by a faultyStorage, whose writes fail.
+//
+type faultyWriter struct {
+	io.WriteCloser
+	storage *faultyStorage
+}
+
+func (s *faultyStorage) Create(name string) (io.WriteCloser, error) {
+	file, err := s.Storage.Create(name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &faultyWriter{WriteCloser: file, storage: s}, nil
+}
+
+func (s *faultyStorage) Remove(name string) error {
+	if s.writeFailed && s.removeErr != nil {
+		return s.removeErr
+	}
+
+	return s.Storage.Remove(name)
+}
+
+func (f *faultyWriter) Write(p []byte) (int, error) {
+	f.storage.writeFailed = true
+
+	return 0, f.storage.writeErr
+}
+
+//
+// chdirTemp
+//
+// Changes the working directory to a temporary directory until the test ends, for tests of
+// functions that write to the working directory.
+//
+//      t - the test
+//
+// Returns the temporary directory.
+//
+func chdirTemp(t testing.TB) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	previous, err := os.Getwd()
+
+	if err == nil {
+		err = os.Chdir(dir)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Chdir(previous)
+	})
+
+	return dir
+}
+
+//
+// wordOnesMap
+//
+// A word count Map function that emits a KeyValue of "1" for every occurrence of a word,
+// rather than one per distinct word, so that its output can be combined.
+//
+//      file     - the name of the input file
+//      contents - the contents of the input file
+//
+// Returns a KeyValue per word, in order.
+//
+func wordOnesMap(file string, contents string) []KeyValue {
+	var keyValues []KeyValue = nil
+
+	for _, word := range strings.Fields(contents) {
+		keyValues = append(keyValues, KeyValue{word, "1"})
+	}
+
+	return keyValues
+}
+
+//
+// emitMap
+//
+// Builds a Map function that emits fixed KeyValues, whatever its input.
+//
+//      keyValues - the KeyValues to emit
+//
+// Returns the Map function.
+//
+func emitMap(keyValues ...KeyValue) func(file string, contents string) []KeyValue {
+	return func(file string, contents string) []KeyValue {
+		return slices.Clone(keyValues)
+	}
+}
+
+//
+// runJob
+//
+// Runs a one-Map-task job with a Worker (see runWordCount), with custom functions.
+//
+//      t          - the test
+//      w          - the Worker that runs the tasks
+//      nReduce    - the number of Reduce tasks to run
+//      mapFunc    - the Map function
+//      reduceFunc - the Reduce function
+//
+func runJob(
+	t          testing.TB,
+	w          *Worker,
+	nReduce    int,
+	mapFunc    func(file string, contents string) []KeyValue,
+	reduceFunc func(key string, values []string) string,
+) {
+	t.Helper()
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, nReduce, mapFunc); err != nil {
+		t.Fatalf("map task: %v", err)
+	}
+
+	for r := 0; r < nReduce; r++ {
+		if _, err := w.Reduce(r, 1, reduceFunc); err != nil {
+			t.Fatalf("reduce task %d: %v", r, err)
+		}
+	}
+}
+
+//
+// firstValue
+//
+// A Reduce function that keeps the first value of each key.
+//
+//      key    - the key
+//      values - the values of the key
+//
+// Returns the first value.
+//
+func firstValue(key string, values []string) string {
+	return values[0]
+}
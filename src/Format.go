@@ -0,0 +1,326 @@
+//
+// Format.go
+//
+// This file contains the formats that 'reduce' workers can write merge files in (see
+// DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//
+// OutputFormat
+//
+// Identifies how a Reduce task encodes the KeyValue pairs of its merge file.
+//
+// FinalMerge, ConcatMerge, AppendOutput and WriteManifest read merge files back as JSON, so
+// they require OutputFormatJSON (or OutputFormatJSONArray), and fail before reading
+// anything otherwise (see checkJSONOutput). The other formats are meant for merge files
+// consumed directly, e.g. with grep, cut, a spreadsheet or a JSON parser. ConvertMerge
+// converts an existing JSON merge file to any of them.
+//
+// Every format ends its records with the record separator (see Worker.RecordSeparator),
+// "\n" by default. A JSON merge file is still read back with a separator of other whitespace
+// (e.g. "\r\n"), but not with any other separator (e.g. "\x00").
+//
+type OutputFormat int
+
+const (
+	OutputFormatJSON OutputFormat = iota // JSON-encoded KeyValues, one per record (the default)
+	OutputFormatTSV                      // "key\tvalue" records, escaped (see escapeTSV)
+	OutputFormatCSV                      // "key,value" records, quoted as needed (see encoding/csv)
+	OutputFormatJSONArray                // a single JSON array of KeyValues, one per record
+)
+
+//
+// String
+//
+// Returns the name of the output format.
+//
+func (f OutputFormat) String() string {
+	switch f {
+	case OutputFormatJSON:
+		return "json"
+	case OutputFormatTSV:
+		return "tsv"
+	case OutputFormatCSV:
+		return "csv"
+	case OutputFormatJSONArray:
+		return "json-array"
+	default:
+		return "unknown"
+	}
+}
+
+//
+// keyValueEncoder
+//
+// Builds the functions that encode KeyValue pairs to a writer in an output format. Some
+// formats buffer or bracket their output (CSV and JSON arrays), so the encoding is only
+// complete once the finishing function has been called.
+//
+//      writer     - the writer to receive the encoded KeyValue pairs
+//      format     - the format of the encoding
+//      escapeHTML - whether JSON encoding escapes HTML characters (see newJSONEncoder)
+//      separator  - ends each record (see Worker.RecordSeparator)
+//
+// Returns the encoding function, which returns any error writing a KeyValue pair, and the
+// finishing function, which returns any error completing the encoding.
+//
+func keyValueEncoder(
+	writer     io.Writer,
+	format     OutputFormat,
+	escapeHTML bool,
+	separator  string,
+) (func(kv KeyValue) error, func() error) {
+	switch format {
+	case OutputFormatTSV:
+		encode := func(kv KeyValue) error {
+			_, err := io.WriteString(writer, escapeTSV(kv.Key) + "\t" + escapeTSV(kv.Value) + separator)
+			return err
+		}
+
+		return encode, func() error { return nil }
+
+	case OutputFormatCSV:
+		if separator == "\n" || separator == "\r\n" {
+			csvWriter := csv.NewWriter(writer)
+
+			csvWriter.UseCRLF = separator == "\r\n"
+
+			encode := func(kv KeyValue) error {
+				return csvWriter.Write([]string{kv.Key, kv.Value})
+			}
+
+			finish := func() error {
+				csvWriter.Flush()
+				return csvWriter.Error()
+			}
+
+			return encode, finish
+		}
+
+		//
+		// Encode each record into a buffer, to swap its trailing newline for the separator:
+		// *NOTE* Newlines within quoted fields are kept, as they are part of the field, and a
+		//        field holding the separator is not quoted, as consumers splitting on it
+		//        (e.g. xargs -0) do not unquote
+		//
+		var buffer bytes.Buffer
+
+		csvWriter := csv.NewWriter(&buffer)
+
+		encode := func(kv KeyValue) error {
+			buffer.Reset()
+
+			err := csvWriter.Write([]string{kv.Key, kv.Value})
+
+			if err == nil {
+				csvWriter.Flush()
+				err = csvWriter.Error()
+			}
+
+			if err != nil {
+				return err
+			}
+
+			_, err = io.WriteString(writer, strings.TrimSuffix(buffer.String(), "\n") + separator)
+			return err
+		}
+
+		return encode, func() error { return nil }
+
+	case OutputFormatJSONArray:
+		var buffer bytes.Buffer
+		var count  int = 0
+
+		encoder := newJSONEncoder(&buffer, escapeHTML)
+
+		encode := func(kv KeyValue) error {
+			// *NOTE* Encoding into a buffer first, to swap the encoder's trailing newline for
+			//        the separator between elements
+			buffer.Reset()
+
+			if count == 0 {
+				buffer.WriteString("[" + separator)
+			} else {
+				buffer.WriteString("," + separator)
+			}
+
+			err := encoder.Encode(&kv)
+
+			if err != nil {
+				return err
+			}
+
+			count++
+
+			_, err = writer.Write(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+			return err
+		}
+
+		finish := func() error {
+			var err error = nil
+
+			if count == 0 {
+				_, err = io.WriteString(writer, "[]" + separator)
+			} else {
+				_, err = io.WriteString(writer, separator + "]" + separator)
+			}
+
+			return err
+		}
+
+		return encode, finish
+	}
+
+	if separator == "\n" {
+		encoder := newJSONEncoder(writer, escapeHTML)
+
+		encode := func(kv KeyValue) error {
+			return encoder.Encode(&kv)
+		}
+
+		return encode, func() error { return nil }
+	}
+
+	//
+	// Encode each KeyValue into a buffer, to swap the encoder's trailing newline for the
+	// separator:
+	//
+	var buffer bytes.Buffer
+
+	encoder := newJSONEncoder(&buffer, escapeHTML)
+
+	encode := func(kv KeyValue) error {
+		buffer.Reset()
+
+		err := encoder.Encode(&kv)
+
+		if err != nil {
+			return err
+		}
+
+		buffer.Truncate(buffer.Len() - 1)
+		buffer.WriteString(separator)
+
+		_, err = writer.Write(buffer.Bytes())
+		return err
+	}
+
+	return encode, func() error { return nil }
+}
+
+//
+// recordSeparator
+//
+// Returns the separator ending each record the Worker encodes (see Worker.RecordSeparator).
+//
+func (w *Worker) recordSeparator() string {
+	if w.RecordSeparator == "" {
+		return "\n"
+	}
+
+	return w.RecordSeparator
+}
+
+//
+// checkJSONOutput
+//
+// Checks that the Worker's merge files can be read back as JSON (see OutputFormat): they
+// are encoded as JSON, and their records are separated by JSON whitespace.
+//
+//      feature - the name of the feature reading them back, for the error
+//
+// Returns nil if they can. Otherwise, the error naming the unsupported output.
+//
+func (w *Worker) checkJSONOutput(feature string) error {
+	separator := w.recordSeparator()
+
+	switch w.OutputFormat {
+	case OutputFormatJSON, OutputFormatJSONArray:
+		if strings.Trim(separator, " \t\r\n") == "" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s cannot read back %s output separated by %q", feature, w.OutputFormat, separator)
+}
+
+//
+// newJSONEncoder
+//
+// Builds a JSON encoder for KeyValue pairs. By default, encoding/json escapes the HTML
+// characters '<', '>' and '&' in strings (as \u003c, \u003e and \u0026), which is safe for
+// embedding in HTML, but bloats the encoding and differs byte for byte from encoders that do
+// not escape them. Either way, the encoding decodes to the same KeyValue pairs.
+//
+//      writer     - the writer to receive the encoding
+//      escapeHTML - whether HTML characters are escaped (see Worker.DisableHTMLEscape)
+//
+// Returns the encoder.
+//
+func newJSONEncoder(writer io.Writer, escapeHTML bool) *json.Encoder {
+	encoder := json.NewEncoder(writer)
+
+	encoder.SetEscapeHTML(escapeHTML)
+
+	return encoder
+}
+
+//
+// tsvEscaper
+//
+// Replaces the characters that would break a TSV line with backslash escapes.
+//
+var tsvEscaper = strings.NewReplacer(
+	"\\", "\\\\",
+	"\t", "\\t",
+	"\n", "\\n",
+	"\r", "\\r",
+	"\x00", "\\0",
+)
+
+//
+// escapeTSV
+//
+// Escapes a key or value for a TSV line. Backslashes, tabs, newlines, carriage returns and
+// NULs are written as \\, \t, \n, \r and \0, so every KeyValue pair stays on one record with
+// exactly one tab (whichever of them separates records), and the original strings can be
+// recovered by reversing the escapes.
+//
+// *NOTE* Escaping rather than failing the task, as values such as lines of text commonly
+//        hold these characters
+//
+//      s - the key or value to be escaped
+//
+// Returns the escaped string.
+//
+func escapeTSV(s string) string {
+	return tsvEscaper.Replace(s)
+}
@@ -0,0 +1,175 @@
+//
+// Format_test.go
+//
+// This file contains tests for the output formats of merge files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+//
+// TestReduceTSVEscaping
+//
+// Checks that TSV merge files escape the tabs, newlines, backslashes and NULs of keys and
+// values, so each KeyValue stays on one line with one tab.
+//
+func TestReduceTSVEscaping(t *testing.T) {
+	w := newTestWorker(t, "tsv")
+	w.OutputFormat = OutputFormatTSV
+	w.SortOutput   = true
+
+	runJob(t, w, 1, emitMap(KeyValue{"a\tb", "line1\nline2\r"}, KeyValue{`back\slash`, "nul\x00"}), firstValue)
+
+	expected := "a\\tb\tline1\\nline2\\r\n" + "back\\\\slash\tnul\\0\n"
+
+	if output := readFile(t, w, w.mergeName(0)); output != expected {
+		t.Fatalf("merge file %q, expected %q", output, expected)
+	}
+}
+
+//
+// TestRecordSeparator
+//
+// Checks that each output format ends its records with a "\r\n" or "\x00" record separator,
+// both in the merge file and when converting it, that a CSV field holding a newline is still
+// quoted whole (with the newline written as "\r\n" by encoding/csv, when separating by it),
+// and that a JSON merge file separated by "\r\n" reads back.
+//
+func TestRecordSeparator(t *testing.T) {
+	tests := []struct {
+		format    OutputFormat
+		separator string
+		expected  string
+	}{
+		{OutputFormatJSON,      "\r\n", `{"Key":"a","Value":"1"}` + "\r\n" + `{"Key":"b","Value":"x\ny"}` + "\r\n"},
+		{OutputFormatJSON,      "\x00", `{"Key":"a","Value":"1"}` + "\x00" + `{"Key":"b","Value":"x\ny"}` + "\x00"},
+		{OutputFormatTSV,       "\r\n", "a\t1\r\n" + "b\tx\\ny\r\n"},
+		{OutputFormatTSV,       "\x00", "a\t1\x00" + "b\tx\\ny\x00"},
+		{OutputFormatCSV,       "\r\n", "a,1\r\n" + "b,\"x\r\ny\"\r\n"},
+		{OutputFormatCSV,       "\x00", "a,1\x00" + "b,\"x\ny\"\x00"},
+		{OutputFormatJSONArray, "\r\n", "[\r\n" + `{"Key":"a","Value":"1"}` + ",\r\n" + `{"Key":"b","Value":"x\ny"}` + "\r\n]\r\n"},
+		{OutputFormatJSONArray, "\x00", "[\x00" + `{"Key":"a","Value":"1"}` + ",\x00" + `{"Key":"b","Value":"x\ny"}` + "\x00]\x00"},
+	}
+
+	for _, test := range tests {
+		w := newTestWorker(t, "separator")
+		w.OutputFormat    = test.format
+		w.RecordSeparator = test.separator
+		w.SortOutput      = true
+
+		runJob(t, w, 1, emitMap(KeyValue{"b", "x\ny"}, KeyValue{"a", "1"}), firstValue)
+
+		if output := readFile(t, w, w.mergeName(0)); output != test.expected {
+			t.Fatalf("%s, %q: merge file %q, expected %q", test.format, test.separator, output, test.expected)
+		}
+
+		if test.format == OutputFormatJSON && test.separator == "\r\n" {
+			checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "1", "b": "x\ny"})
+		}
+
+		//
+		// Convert a JSON merge file to the format with the separator:
+		//
+		source := newTestWorker(t, "separator")
+		source.SortOutput = true
+
+		runJob(t, source, 1, emitMap(KeyValue{"b", "x\ny"}, KeyValue{"a", "1"}), firstValue)
+
+		var converted strings.Builder
+
+		source.RecordSeparator = test.separator
+
+		if err := source.ConvertMerge(0, test.format, &converted); err != nil {
+			t.Fatal(err)
+		}
+
+		if converted.String() != test.expected {
+			t.Fatalf("%s, %q: converted to %q, expected %q", test.format, test.separator, converted.String(), test.expected)
+		}
+	}
+}
+
+//
+// TestJSONReadBack
+//
+// Checks that AppendOutput, FinalMerge and ConcatMerge, which read merge files back as JSON,
+// reject output that is not JSON, or whose records are not separated by JSON whitespace,
+// before reading anything.
+//
+func TestJSONReadBack(t *testing.T) {
+	tests := []struct {
+		format    OutputFormat
+		separator string
+		readable  bool
+	}{
+		{OutputFormatJSON,      "",     true},
+		{OutputFormatJSON,      "\r\n", true},
+		{OutputFormatJSONArray, "",     true},
+		{OutputFormatJSON,      "\x00", false},
+		{OutputFormatJSONArray, "\x00", false},
+		{OutputFormatTSV,       "",     false},
+		{OutputFormatCSV,       "",     false},
+	}
+
+	for _, test := range tests {
+		w := newTestWorker(t, "readback")
+		w.OutputFormat    = test.format
+		w.RecordSeparator = test.separator
+		w.SortOutput      = true
+
+		runJob(t, w, 1, emitMap(KeyValue{"a", "1"}), firstValue)
+
+		merge := readFile(t, w, w.mergeName(0))
+
+		w.AppendOutput = true
+
+		_, err := w.Reduce(0, 1, firstValue)
+
+		if (err == nil) != test.readable {
+			t.Errorf("%s, %q: AppendOutput error %v", test.format, test.separator, err)
+		}
+
+		if !test.readable && readFile(t, w, w.mergeName(0)) != merge {
+			t.Errorf("%s, %q: rejected AppendOutput changed the merge file", test.format, test.separator)
+		}
+
+		for name, merge := range map[string]func(nReduce int, out io.Writer) error{
+			"FinalMerge":  w.FinalMerge,
+			"ConcatMerge": w.ConcatMerge,
+		} {
+			var output strings.Builder
+
+			err := merge(1, &output)
+
+			if (err == nil) != test.readable {
+				t.Errorf("%s, %q: %s error %v", test.format, test.separator, name, err)
+			}
+
+			if !test.readable && output.Len() != 0 {
+				t.Errorf("%s, %q: rejected %s wrote %q", test.format, test.separator, name, output.String())
+			}
+		}
+	}
+}
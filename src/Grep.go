@@ -0,0 +1,89 @@
+//
+// Grep.go
+//
+// This file contains a reusable distributed grep job: a Map function that emits the lines of
+// the input files matching a pattern (see RunJob).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//
+// GrepMap
+//
+// Builds a Map function that searches the lines of an input file for a pattern. The pattern
+// is compiled once, and the compiled regexp is shared by every call (which is safe, as a
+// regexp can be used concurrently). Lines are split on "\n", with any trailing "\r" removed;
+// a final "\n" ends the last line rather than starting an empty one. The KeyValues are
+// unique per line, so a Reduce function that returns its only value (e.g. GrepReduce, which
+// quotes it) passes them through.
+// *NOTE* Panics if the pattern does not compile, like regexp.MustCompile, as it is a fixed
+//        part of the job
+//
+//      pattern - the regular expression to search for (see regexp/syntax)
+//
+// Returns the Map function, which emits one KeyValue per matching line: the key is
+// "<file>:<line number>" (numbered from 1), and the value is the line.
+//
+func GrepMap(pattern string) func(file string, contents string) []KeyValue {
+	re := regexp.MustCompile(pattern)
+
+	return func(file string, contents string) []KeyValue {
+		var keyValues []KeyValue = nil
+
+		lines := strings.Split(contents, "\n")
+
+		if len(lines) > 1 && lines[len(lines)-1] == "" {
+			// Contents end with a line ending, not a line
+			lines = lines[:len(lines)-1]
+		}
+
+		for i, line := range lines {
+			line = strings.TrimSuffix(line, "\r")
+
+			if re.MatchString(line) {
+				keyValues = append(keyValues, KeyValue{file + ":" + strconv.Itoa(i+1), line})
+			}
+		}
+
+		return keyValues
+	}
+}
+
+//
+// GrepReduce
+//
+// Passes a matching line through to the merge file (see GrepMap), quoted as a Go string
+// literal (see strconv.Quote), which strconv.Unquote reverses.
+// *NOTE* Quoting so that a line reading "error" is not taken for a failed Reduce call
+//
+//      key    - the "<file>:<line number>" of the line
+//      values - the line
+//
+// Returns the quoted line.
+//
+func GrepReduce(key string, values []string) string {
+	return strconv.Quote(strings.Join(values, "\n"))
+}
@@ -0,0 +1,126 @@
+//
+// Hashing.go
+//
+// This file contains functionality for checking how uniformly a partitioner spreads keys
+// across Reduce partitions, and an alternative hash partitioner for key sets that the default
+// one spreads poorly (see partitionFor in DoMap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// BucketCounts
+//
+// Counts the keys a partitioner assigns to each Reduce partition, e.g. to check a sample of a
+// job's keys before choosing a Partitioner (see ChiSquared).
+//
+//      keys        - the keys to be partitioned
+//      nReduce     - the number of Reduce tasks that will be run
+//      partitioner - assigns each key to a partition (nil means partitionFor)
+//
+// Returns the number of keys assigned to each partition.
+//
+func BucketCounts(keys []string, nReduce int, partitioner func(key string, nReduce int) int) []int {
+	w      := &Worker{Partitioner: partitioner}
+	counts := make([]int, nReduce)
+
+	for _, key := range keys {
+		counts[w.partitionFor(key, nReduce)]++
+	}
+
+	return counts
+}
+
+//
+// ChiSquared
+//
+// Computes Pearson's chi-squared statistic of bucket counts against a uniform distribution.
+// For a uniform partitioner, it is close to the number of degrees of freedom, len(counts)-1;
+// values well above it (e.g. twice it, for a large sample) indicate keys are concentrated in
+// some partitions.
+//
+//      counts - the number of keys assigned to each partition (see BucketCounts)
+//
+// Returns the statistic, or zero if there are no keys.
+//
+func ChiSquared(counts []int) float64 {
+	var total int = 0
+
+	for _, count := range counts {
+		total += count
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	var expected   float64 = float64(total) / float64(len(counts))
+	var chiSquared float64 = 0
+
+	for _, count := range counts {
+		deviation  := float64(count) - expected
+		chiSquared  += deviation * deviation / expected
+	}
+
+	return chiSquared
+}
+
+//
+// MixedPartitionFor
+//
+// Determines the Reduce partition of a key like partitionFor, but mixes the bits of the hash
+// first, for use as a Worker.Partitioner.
+//
+// FNV-1a spreads the low bits of its hash well, but keys that differ only in their last few
+// characters (e.g. "user:000001", "user:000002", ...) are spread unevenly over larger
+// numbers of partitions, such as 256. Mixing with the MurmurHash3 finalizer makes every bit
+// of the hash depend on every bit of the key.
+//
+// *NOTE* Assigns keys differently from partitionFor, so every Map task of a job must use the
+//        same one
+//
+//      key     - the key to be partitioned
+//      nReduce - the number of Reduce tasks that will be run
+//
+// Returns the partition number, in the range [0, nReduce).
+//
+func MixedPartitionFor(key string, nReduce int) int {
+	return int(mixHash(ihash(key)) % uint32(nReduce))
+}
+
+//
+// mixHash
+//
+// Mixes the bits of a 32-bit hash value with the MurmurHash3 finalizer.
+//
+//      h - the hash value to be mixed
+//
+// Returns the mixed hash value.
+//
+func mixHash(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+
+	return h
+}
@@ -0,0 +1,113 @@
+//
+// Hashing_test.go
+//
+// This file contains tests for the uniformity of partitioning.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"fmt"
+	"testing"
+)
+
+//
+// realisticKeys
+//
+// Builds sets of keys shaped like those of real jobs: sequential identifiers, URLs, e-mail
+// addresses and words.
+//
+// Returns the key sets, by name.
+//
+func realisticKeys() map[string][]string {
+	keySets := make(map[string][]string)
+
+	syllables := []string{"ka", "lo", "mi", "ne", "ru", "sa", "ti", "vo", "ze", "qu"}
+
+	for i := 0; i < 50000; i++ {
+		keySets["ids"]    = append(keySets["ids"], fmt.Sprintf("user:%06d", i))
+		keySets["urls"]   = append(keySets["urls"], fmt.Sprintf("https://example.com/page/%d?ref=%d", i, i%7))
+		keySets["emails"] = append(keySets["emails"], fmt.Sprintf("person%d@mail%d.example.org", i, i%13))
+		keySets["words"]  = append(keySets["words"], syllables[i%10]+syllables[i/10%10]+syllables[i/100%10]+syllables[i/1000%10]+syllables[i/10000%10])
+	}
+
+	return keySets
+}
+
+//
+// checkUniform
+//
+// Fails the test if a partitioner spreads a key set unevenly over nReduce partitions: if
+// the chi-squared statistic of its bucket counts is well above the degrees of freedom.
+//
+//      t           - the test
+//      name        - the name of the key set
+//      keys        - the keys to be partitioned
+//      nReduce     - the number of Reduce tasks
+//      partitioner - the partitioner (nil means partitionFor)
+//
+func checkUniform(t *testing.T, name string, keys []string, nReduce int, partitioner func(key string, nReduce int) int) {
+	t.Helper()
+
+	// Degrees of freedom, plus a margin far beyond chance for these sample sizes
+	tolerance  := 2*float64(nReduce-1) + 30
+	chiSquared := ChiSquared(BucketCounts(keys, nReduce, partitioner))
+
+	if chiSquared > tolerance {
+		t.Errorf("%s over %d partitions: chi-squared %.1f, tolerance %.1f", name, nReduce, chiSquared, tolerance)
+	}
+}
+
+//
+// TestPartitionUniformity
+//
+// Checks that the default partitioner spreads realistic key sets evenly over typical numbers
+// of partitions, and that MixedPartitionFor also does over many partitions.
+//
+func TestPartitionUniformity(t *testing.T) {
+	for name, keys := range realisticKeys() {
+		for _, nReduce := range []int{2, 7, 8, 10, 16} {
+			checkUniform(t, name, keys, nReduce, nil)
+		}
+
+		for _, nReduce := range []int{2, 7, 8, 10, 16, 64, 256} {
+			checkUniform(t, name, keys, nReduce, MixedPartitionFor)
+		}
+	}
+}
+
+//
+// TestChiSquared
+//
+// Checks the chi-squared statistic of fixed bucket counts.
+//
+func TestChiSquared(t *testing.T) {
+	if chiSquared := ChiSquared([]int{10, 10, 10, 10}); chiSquared != 0 {
+		t.Fatalf("uniform counts: chi-squared %v, expected 0", chiSquared)
+	}
+
+	if chiSquared := ChiSquared([]int{40, 0, 0, 0}); chiSquared != 120 {
+		t.Fatalf("concentrated counts: chi-squared %v, expected 120", chiSquared)
+	}
+
+	if chiSquared := ChiSquared(make([]int, 4)); chiSquared != 0 {
+		t.Fatalf("no keys: chi-squared %v, expected 0", chiSquared)
+	}
+}
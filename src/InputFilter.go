@@ -0,0 +1,82 @@
+//
+// InputFilter.go
+//
+// This file contains functionality for restricting a job to the input files it can map (see
+// Worker.InputFilter), e.g. to skip the .DS_Store or .crc files of an input directory.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"path/filepath"
+	"strings"
+)
+
+//
+// Extensions
+//
+// Builds an input filter (see Worker.InputFilter) that accepts the files with any of a list
+// of extensions, compared without regard to case. An extension may be given with or without
+// its leading dot, so ".txt" and "txt" are the same.
+//
+//      extensions - the extensions to be accepted
+//
+// Returns the input filter.
+//
+func Extensions(extensions ...string) func(name string) bool {
+	set := make(map[string]struct{}, len(extensions))
+
+	for _, extension := range extensions {
+		set["." + strings.ToLower(strings.TrimPrefix(extension, "."))] = struct{}{}
+	}
+
+	return func(name string) bool {
+		_, exists := set[strings.ToLower(filepath.Ext(name))]
+		return exists
+	}
+}
+
+//
+// filterInputs
+//
+// Selects the input files a job maps (see Worker.InputFilter), keeping their order. The Map
+// tasks are numbered over the selected files, so nMap is the number selected.
+//
+//      inFiles - the names of the input files
+//
+// Returns the names of the selected input files.
+//
+func (w *Worker) filterInputs(inFiles []string) []string {
+	if w.InputFilter == nil {
+		return inFiles
+	}
+
+	var selected []string = nil
+
+	for _, inFile := range inFiles {
+		if w.InputFilter(inFile) {
+			selected = append(selected, inFile)
+		} else {
+			w.logf("Function warning [InputFilter.filterInputs]: skipping input file %s\n", inFile)
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,157 @@
+//
+// InputFilter_test.go
+//
+// This file contains tests for skipping unwanted input files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//
+// TestExtensions
+//
+// Checks which file names an extension filter accepts.
+//
+func TestExtensions(t *testing.T) {
+	filter := Extensions("txt", ".log")
+
+	tests := []struct {
+		name     string
+		accepted bool
+	}{
+		{"data.txt",       true},
+		{"DATA.TXT",       true},
+		{"dir/app.log",    true},
+		{"data.txt.crc",   false},
+		{".DS_Store",      false},
+		{"txt",            false},
+		{"notes.md",       false},
+		{"archive.log.gz", false},
+	}
+
+	for _, test := range tests {
+		if accepted := filter(test.name); accepted != test.accepted {
+			t.Fatalf("%s accepted %v, expected %v", test.name, accepted, test.accepted)
+		}
+	}
+}
+
+//
+// TestInputFilter
+//
+// Runs a word count job over every file of a directory of mixed files, with RunJob and with
+// MapGroup, filtering them by extension and by a predicate, and checks that only the
+// matching files are mapped, as the only Map tasks, and counted.
+//
+func TestInputFilter(t *testing.T) {
+	files := map[string]string{
+		"a.txt":     "the quick brown fox",
+		"b.TXT":     "the lazy dog",
+		"b.TXT.crc": "checksum bytes",
+		".DS_Store": "Bud1 \x00 junk",
+		"notes.md":  "draft notes",
+		"_SUCCESS":  "",
+	}
+
+	tests := []struct {
+		name   string
+		filter func(name string) bool
+		mapped []string
+	}{
+		{"extensions", Extensions("txt"), []string{"a.txt", "b.TXT"}},
+		{"predicate", func(name string) bool {
+			return !strings.HasPrefix(filepath.Base(name), ".") && !strings.HasPrefix(filepath.Base(name), "_")
+		}, []string{"a.txt", "b.TXT", "b.TXT.crc", "notes.md"}},
+	}
+
+	for _, test := range tests {
+		for _, group := range []bool{false, true} {
+			w := newTestWorker(t, "inputfilter")
+			w.InputFilter = test.filter
+			w.Logger      = log.New(io.Discard, "", 0)
+
+			for name, contents := range files {
+				writeInput(t, w, name, contents)
+			}
+
+			var inFiles []string = nil
+
+			for _, name := range listDir(t, w.OutDir) {
+				inFiles = append(inFiles, filepath.Join(w.OutDir, name))
+			}
+
+			var mutex  sync.Mutex
+			var mapped []string = nil
+
+			mapFunc := func(file string, contents string) []KeyValue {
+				mutex.Lock()
+				mapped = append(mapped, filepath.Base(file))
+				mutex.Unlock()
+
+				return WordCountMap(file, contents)
+			}
+
+			var err error = nil
+
+			if group {
+				_, err = w.MapGroup(context.Background(), inFiles, 1, mapFunc)
+
+				if err == nil {
+					_, err = w.Reduce(0, len(test.mapped), WordCountReduce)
+				}
+			} else {
+				err = w.RunJob(inFiles, 1, mapFunc, WordCountReduce)
+			}
+
+			if err != nil {
+				t.Fatalf("%s (MapGroup %v): %v", test.name, group, err)
+			}
+
+			slices.Sort(mapped)
+
+			if !slices.Equal(mapped, test.mapped) {
+				t.Fatalf("%s (MapGroup %v): mapped %v, expected %v", test.name, group, mapped, test.mapped)
+			}
+
+			var contents []string = nil
+
+			for _, name := range test.mapped {
+				contents = append(contents, files[name])
+			}
+
+			checkOutput(t, readOutput(t, w, 1), countWords(contents...))
+
+			if _, err := os.Stat(w.reduceName(len(test.mapped), 0)); err == nil {
+				t.Fatalf("%s (MapGroup %v): Map task %d run for a skipped file", test.name, group, len(test.mapped))
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+//
+// InsertionOrder_test.go
+//
+// This file contains tests for writing Reduce output in insertion order.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// TestInsertionOrder
+//
+// Runs the same word count job several times with InsertionOrder, and checks that every run
+// writes identical merge files, with the keys in the order first seen across the Map tasks.
+//
+func TestInsertionOrder(t *testing.T) {
+	inputs := []string{testInput, "a fox and a hen", "zebra the yak"}
+
+	var expected []string = nil
+
+	for _, input := range inputs {
+		for _, word := range strings.Fields(input) {
+			if !slices.Contains(expected, word) {
+				expected = append(expected, word)
+			}
+		}
+	}
+
+	var first string
+
+	for run := 0; run < 5; run++ {
+		w := newTestWorker(t, "ordered")
+		w.InsertionOrder = true
+
+		checkOutput(t, runWordCount(t, w, inputs, 1), countWords(inputs...))
+
+		contents := readFile(t, w, w.mergeName(0))
+
+		if run == 0 {
+			first = contents
+		} else if contents != first {
+			t.Fatalf("run %d wrote %q, expected %q as in run 0", run, contents, first)
+		}
+
+		var keys []string = nil
+
+		for _, kv := range readKeyValueFile(t, w, w.mergeName(0)) {
+			keys = append(keys, kv.Key)
+		}
+
+		if !slices.Equal(keys, expected) {
+			t.Fatalf("keys %q, expected %q", keys, expected)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+//
+// KeyEquality.go
+//
+// This file contains functionality for grouping the keys of a 'reduce' worker by a custom
+// equality, rather than by string equality (see Worker.KeyEquality), e.g. to reduce numeric
+// keys written differently as one key.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+)
+
+//
+// KeyEquality
+//
+// Decides which keys Reduce groups together, and the key each group is reduced under. Equal
+// must be an equivalence relation (reflexive, symmetric and transitive), and is consulted
+// after normalizing (see Worker.Normalize).
+//
+// Unlike Normalize, no string identifies a group until its keys have been compared, so keys
+// cannot simply be looked up in a map: each distinct key string is compared with the first
+// key of every group found so far, which costs O(distinct keys x groups) calls to Equal per
+// task (see keyGrouper). Repeats of a key string are looked up in a map, so the cost grows
+// with the number of distinct spellings, not the number of records.
+//
+//      Equal     - reports whether two keys belong to the same group
+//      Canonical - builds the key a group is reduced under from the first key seen in it
+//                  (nil means the first key seen, unchanged)
+//
+type KeyEquality struct {
+	Equal     func(a, b string) bool
+	Canonical func(key string) string
+}
+
+//
+// NumericKeyEquality
+//
+// Builds a KeyEquality under which keys that parse as numbers (see strconv.ParseFloat) are
+// equal when their values are, e.g. "1", "1.0" and "1e0", and are reduced under their
+// shortest form ("1"). Other keys are only equal to themselves.
+//
+// Returns the KeyEquality.
+//
+func NumericKeyEquality() *KeyEquality {
+	equal := func(a, b string) bool {
+		x, errA := strconv.ParseFloat(a, 64)
+		y, errB := strconv.ParseFloat(b, 64)
+
+		if errA != nil || errB != nil {
+			return a == b
+		}
+
+		return x == y
+	}
+
+	canonical := func(key string) string {
+		x, err := strconv.ParseFloat(key, 64)
+
+		if err != nil {
+			return key
+		}
+
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+
+	return &KeyEquality{Equal: equal, Canonical: canonical}
+}
+
+//
+// keyGrouper
+//
+// Assigns the keys of one Reduce task to groups under a KeyEquality, remembering the group
+// key of every distinct key string it has seen. It is not safe for concurrent use.
+//
+type keyGrouper struct {
+	equality *KeyEquality
+	firsts   []string
+	groups   []string
+	known    map[string]string
+}
+
+//
+// newKeyGrouper
+//
+// Creates a grouper for a Reduce task.
+//
+//      equality - the equality to group keys by (nil means string equality)
+//
+// Returns the grouper, or nil (which groups by string equality) if equality is nil.
+//
+func newKeyGrouper(equality *KeyEquality) *keyGrouper {
+	if equality == nil {
+		return nil
+	}
+
+	return &keyGrouper{equality: equality, known: make(map[string]string)}
+}
+
+//
+// group
+//
+// Finds the group of a key, starting a new group if it is equal to no earlier key.
+//
+//      key - the (normalized) key to be grouped
+//
+// Returns the key the group is reduced under.
+//
+func (g *keyGrouper) group(key string) string {
+	if g == nil {
+		return key
+	}
+
+	if groupKey, ok := g.known[key]; ok {
+		return groupKey
+	}
+
+	for i, first := range g.firsts {
+		if g.equality.Equal(first, key) {
+			g.known[key] = g.groups[i]
+			return g.groups[i]
+		}
+	}
+
+	groupKey := key
+
+	if g.equality.Canonical != nil {
+		groupKey = g.equality.Canonical(key)
+	}
+
+	g.firsts     = append(g.firsts, key)
+	g.groups     = append(g.groups, groupKey)
+	g.known[key] = groupKey
+
+	return groupKey
+}
@@ -0,0 +1,99 @@
+//
+// KeyEquality_test.go
+//
+// This file contains tests for grouping Reduce keys by a custom equality.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestNumericKeyEquality
+//
+// Runs a count over numeric keys written in several forms, split between two Map tasks, and
+// checks that keys with equal values, such as "1" and "1.0", are counted as one key under
+// their shortest form, while other keys are only grouped with themselves.
+//
+func TestNumericKeyEquality(t *testing.T) {
+	inputs := []string{"1 1.0 2.50 abc 10", "1e0 2.5 2 ABC 1.00 01"}
+
+	w := newTestWorker(t, "equality")
+	w.KeyEquality = NumericKeyEquality()
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		_, err := w.Map(m, inFile, 1, func(file string, contents string) []KeyValue {
+			var keyValues []KeyValue = nil
+
+			for _, field := range strings.Fields(contents) {
+				keyValues = append(keyValues, KeyValue{field, "1"})
+			}
+
+			return keyValues
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := w.Reduce(0, len(inputs), WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"1": "5", "2": "1", "2.5": "2", "10": "1", "abc": "1", "ABC": "1"})
+}
+
+//
+// TestKeyEqualityCanonical
+//
+// Runs a word count with a case-insensitive key equality, and checks that each word is
+// counted once under its canonical lower-case form, whatever case it was first seen in.
+//
+func TestKeyEqualityCanonical(t *testing.T) {
+	w := newTestWorker(t, "equality")
+	w.KeyEquality = &KeyEquality{Equal: strings.EqualFold, Canonical: strings.ToLower}
+
+	output := runWordCount(t, w, []string{"The Fox the fox", "THE dog Dog"}, 1)
+
+	checkOutput(t, output, map[string]string{"the": "3", "fox": "2", "dog": "2"})
+}
+
+//
+// TestKeyEqualitySorted
+//
+// Checks that a key equality is rejected with a sorted merge, in which equal keys need not
+// be adjacent.
+//
+func TestKeyEqualitySorted(t *testing.T) {
+	w := newTestWorker(t, "equality")
+	w.KeyEquality      = NumericKeyEquality()
+	w.PartitionsSorted = true
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); err == nil {
+		t.Fatal("KeyEquality accepted with a sorted merge")
+	}
+}
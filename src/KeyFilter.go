@@ -0,0 +1,155 @@
+//
+// KeyFilter.go
+//
+// This file contains functionality for restricting a 'reduce' worker to a known subset of
+// keys (see Worker.KeyFilter), e.g. for a targeted lookup.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"hash/fnv"
+	"math"
+)
+
+//
+// KeySet
+//
+// Builds a key filter (see Worker.KeyFilter) that accepts exactly the keys of a whitelist.
+// The whitelist is copied, so it may be changed afterwards.
+//
+//      keys - the keys to be accepted
+//
+// Returns the key filter.
+//
+func KeySet(keys []string) func(key string) bool {
+	set := make(map[string]struct{}, len(keys))
+
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+
+	return func(key string) bool {
+		_, exists := set[key]
+		return exists
+	}
+}
+
+//
+// BloomFilter
+//
+// A probabilistic set of keys, for whitelists too large to hold as a KeySet. Contains never
+// misses a key that was added, but may accept a key that was not (a false positive), at
+// about the rate the filter was sized for. Used as a key filter (see Worker.KeyFilter), a
+// false positive key is reduced and written like any other, so it only costs the work
+// filtering would have saved.
+//
+// A BloomFilter is not safe for concurrent use while keys are added, but Contains may be
+// called concurrently once they have been.
+//
+type BloomFilter struct {
+	bits   []uint64
+	hashes int
+}
+
+//
+// NewBloomFilter
+//
+// Creates an empty Bloom filter sized for a number of keys and a false positive rate, with
+// the optimal number of bits, m = -n*ln(p)/ln(2)^2, and hash functions, k = m/n*ln(2).
+//
+//      expectedKeys      - the number of keys that will be added
+//      falsePositiveRate - the rate at which Contains may accept a key not added (e.g. 0.01)
+//
+// Returns the Bloom filter.
+//
+func NewBloomFilter(expectedKeys int, falsePositiveRate float64) *BloomFilter {
+	n := math.Max(float64(expectedKeys), 1)
+	p := math.Min(math.Max(falsePositiveRate, 1e-9), 0.5)
+
+	nBits   := math.Ceil(-n * math.Log(p) / (math.Ln2 * math.Ln2))
+	nHashes := int(math.Round(nBits / n * math.Ln2))
+
+	return &BloomFilter{
+		bits:   make([]uint64, (int(nBits)+63)/64),
+		hashes: max(nHashes, 1),
+	}
+}
+
+//
+// Add
+//
+// Adds a key to the filter.
+//
+//      key - the key to be added
+//
+func (b *BloomFilter) Add(key string) {
+	for _, bit := range b.positions(key) {
+		b.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+//
+// Contains
+//
+// Reports whether a key may have been added to the filter. Pass b.Contains as a key filter
+// (see Worker.KeyFilter).
+//
+//      key - the key to be checked
+//
+// Returns false if the key was certainly not added, and true if it probably was.
+//
+func (b *BloomFilter) Contains(key string) bool {
+	for _, bit := range b.positions(key) {
+		if b.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// positions
+//
+// Computes the bits a key sets in the filter, by double hashing: the i-th position is
+// h1 + i*h2, with h1 and h2 the halves of the key's 64-bit FNV-1a hash.
+//
+//      key - the key to be hashed
+//
+// Returns the bit positions.
+//
+func (b *BloomFilter) positions(key string) []uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+
+	h1    := sum & math.MaxUint32
+	h2    := sum>>32 | 1
+	nBits := uint64(len(b.bits)) * 64
+
+	positions := make([]uint64, b.hashes)
+
+	for i := range positions {
+		positions[i] = (h1 + uint64(i)*h2) % nBits
+	}
+
+	return positions
+}
@@ -0,0 +1,132 @@
+//
+// KeyFilter_test.go
+//
+// This file contains tests for restricting Reduce to a whitelist of keys.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"maps"
+	"strconv"
+	"testing"
+)
+
+//
+// TestKeyFilter
+//
+// Runs a word count job with a key whitelist, as a KeySet and as a BloomFilter, over sorted
+// and unsorted partitions, and checks that every whitelisted key is counted in full and, for
+// a KeySet, that no other key is written to the merge files.
+//
+func TestKeyFilter(t *testing.T) {
+	inputs    := []string{"the quick brown fox jumps", "over the lazy dog and the fox"}
+	whitelist := []string{"fox", "the", "cat"}
+
+	expected := countWords(inputs...)
+	filtered := make(map[string]string)
+
+	for _, key := range whitelist {
+		if count, exists := expected[key]; exists {
+			filtered[key] = count
+		}
+	}
+
+	bloom := NewBloomFilter(len(whitelist), 0.01)
+
+	for _, key := range whitelist {
+		bloom.Add(key)
+	}
+
+	tests := []struct {
+		name   string
+		filter func(key string) bool
+		exact  bool
+	}{
+		{"KeySet",      KeySet(whitelist), true},
+		{"BloomFilter", bloom.Contains,    false},
+	}
+
+	for _, test := range tests {
+		for _, sorted := range []bool{false, true} {
+			w := newTestWorker(t, "keyfilter")
+			w.KeyFilter        = test.filter
+			w.SortPartitions   = sorted
+			w.PartitionsSorted = sorted
+
+			output := runWordCount(t, w, inputs, 3)
+
+			if test.exact {
+				if !maps.Equal(output, filtered) {
+					t.Fatalf("%s (sorted %v): output %v, expected %v", test.name, sorted, output, filtered)
+				}
+
+				continue
+			}
+
+			for key, count := range output {
+				if !test.filter(key) || count != expected[key] {
+					t.Fatalf("%s (sorted %v): wrote %s=%s, expected %s=%s if accepted", test.name, sorted, key, count, key, expected[key])
+				}
+			}
+
+			for key, count := range filtered {
+				if output[key] != count {
+					t.Fatalf("%s (sorted %v): %s=%s, expected %s", test.name, sorted, key, output[key], count)
+				}
+			}
+		}
+	}
+}
+
+//
+// TestBloomFilter
+//
+// Checks that a BloomFilter accepts every key added to it, and rejects keys that were not
+// at about the false positive rate it was sized for.
+//
+func TestBloomFilter(t *testing.T) {
+	const nKeys = 10000
+	const rate  = 0.01
+
+	bloom := NewBloomFilter(nKeys, rate)
+
+	for i := 0; i < nKeys; i++ {
+		bloom.Add("key-" + strconv.Itoa(i))
+	}
+
+	for i := 0; i < nKeys; i++ {
+		if !bloom.Contains("key-" + strconv.Itoa(i)) {
+			t.Fatalf("key-%d added but not contained", i)
+		}
+	}
+
+	var falsePositives int = 0
+
+	for i := 0; i < nKeys; i++ {
+		if bloom.Contains("other-" + strconv.Itoa(i)) {
+			falsePositives++
+		}
+	}
+
+	if falsePositives > 3*nKeys*rate {
+		t.Fatalf("%d false positives in %d, expected about %v", falsePositives, nKeys, nKeys*rate)
+	}
+}
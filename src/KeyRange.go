@@ -0,0 +1,93 @@
+//
+// KeyRange.go
+//
+// This file contains functionality for bounding a 'reduce' worker to a range of keys, so that
+// one large partition can be split across several Reduce invocations (see Worker.KeyRange).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"net/url"
+	"strings"
+)
+
+//
+// KeyRange
+//
+// A half-open range of keys, [Min, Max), in string order. The empty Max means the range has no
+// upper bound (and the empty Min, the lowest key, means it has no lower bound), so ranges like
+// {"", "m"} and {"m", ""} cover every key of a partition between them.
+//
+//      Min - the lowest key in the range
+//      Max - the first key after the range ("" means none)
+//
+type KeyRange struct {
+	Min string
+	Max string
+}
+
+//
+// contains
+//
+// Reports whether a key is in the range. The nil range contains every key.
+//
+//      key - the key to be checked
+//
+// Returns true if the key is in the range.
+//
+func (r *KeyRange) contains(key string) bool {
+	return r == nil || (key >= r.Min && (r.Max == "" || key < r.Max))
+}
+
+//
+// beyond
+//
+// Reports whether a key is past the end of the range, so that no key following it in sorted
+// order is in the range either.
+//
+//      key - the key to be checked
+//
+// Returns true if the key is past the end of the range.
+//
+func (r *KeyRange) beyond(key string) bool {
+	return r != nil && r.Max != "" && key >= r.Max
+}
+
+//
+// suffix
+//
+// Builds the suffix that qualifies a merge file name with the range: ".range-<Min>-<Max>",
+// with the bounds escaped so that any keys make a valid, unambiguous file name. The nil
+// range has no suffix.
+//
+// Returns the suffix.
+//
+func (r *KeyRange) suffix() string {
+	if r == nil {
+		return ""
+	}
+
+	escape := func(key string) string {
+		return strings.ReplaceAll(url.PathEscape(key), "-", "%2D")
+	}
+
+	return ".range-" + escape(r.Min) + "-" + escape(r.Max)
+}
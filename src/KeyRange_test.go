@@ -0,0 +1,97 @@
+//
+// KeyRange_test.go
+//
+// This file contains tests for Reduce tasks bounded by a key range.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"maps"
+	"testing"
+)
+
+//
+// TestKeyRangeUnion
+//
+// Reduces a partition in full, then in two key ranges split at "m", and checks that each
+// range's merge file holds only its own keys, and that together they hold the full output,
+// with the partition sorted or not.
+//
+func TestKeyRangeUnion(t *testing.T) {
+	inputs := []string{testInput, "a man a plan a canal panama", "zebra mango yak"}
+
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "range")
+		w.SortPartitions   = sorted
+		w.PartitionsSorted = sorted
+
+		full := runWordCount(t, w, inputs, 1)
+
+		checkOutput(t, full, countWords(inputs...))
+
+		union := make(map[string]string)
+
+		for _, keyRange := range []*KeyRange{{"", "m"}, {"m", ""}} {
+			w.KeyRange = keyRange
+
+			if _, err := w.Reduce(0, len(inputs), WordCountReduce); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, kv := range readKeyValueFile(t, w, w.mergeName(0)) {
+				if !keyRange.contains(kv.Key) {
+					t.Errorf("sorted %v: key %q written outside range %v", sorted, kv.Key, *keyRange)
+				}
+
+				if _, exists := union[kv.Key]; exists {
+					t.Errorf("sorted %v: key %q written by both ranges", sorted, kv.Key)
+				}
+
+				union[kv.Key] = kv.Value
+			}
+		}
+
+		if !maps.Equal(union, full) {
+			t.Errorf("sorted %v: ranges hold %v, expected %v", sorted, union, full)
+		}
+	}
+}
+
+//
+// TestKeyRangeMergeName
+//
+// Checks that each key range writes its own merge file, apart from the full partition's.
+//
+func TestKeyRangeMergeName(t *testing.T) {
+	w := newTestWorker(t, "names")
+
+	names := make(map[string]bool)
+
+	for i, keyRange := range []*KeyRange{nil, {"", "m"}, {"m", ""}, {"a-b", "c"}, {"a", "b-c"}} {
+		w.KeyRange = keyRange
+
+		if names[w.mergeName(0)] {
+			t.Errorf("range %d shares merge file %s", i, w.mergeName(0))
+		}
+
+		names[w.mergeName(0)] = true
+	}
+}
@@ -0,0 +1,104 @@
+//
+// Limiter.go
+//
+// This file contains functionality for limiting the rate at which 'map' workers create files,
+// so that bursts of creations from many concurrent tasks do not overwhelm the metadata
+// servers of shared storage (see Worker.CreateLimiter).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"sync"
+	"time"
+)
+
+//
+// Limiter
+//
+// Limits the rate of an operation. Wait blocks until the operation may run once. A Limiter
+// must be safe for concurrent use, so that one can be shared by every task of a cluster.
+//
+type Limiter interface {
+	Wait()
+}
+
+//
+// TokenBucket
+//
+// A Limiter that allows a steady rate of operations, with bursts of up to a fixed size: a
+// bucket holds up to burst tokens, refilled at rate tokens per second, and each operation
+// takes one token, waiting for it if the bucket is empty. Waiting operations reserve their
+// tokens in order, so none of them starves.
+//
+type TokenBucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+//
+// NewTokenBucket
+//
+// Builds a full TokenBucket.
+//
+//      rate  - the number of operations allowed per second, on average
+//      burst - the number of operations allowed at once (less than one means one)
+//
+// Returns the TokenBucket.
+//
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	burst = max(burst, 1)
+
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+//
+// Wait
+//
+// Takes a token from the bucket, waiting until one is available (see Limiter).
+//
+func (b *TokenBucket) Wait() {
+	b.mutex.Lock()
+
+	//
+	// Refill the bucket for the time since the last operation, and take a token:
+	// *NOTE* A negative balance reserves tokens that have not been refilled yet, which this
+	//        operation waits for outside the lock
+	//
+	now := time.Now()
+
+	b.tokens = min(b.burst, b.tokens + now.Sub(b.last).Seconds() * b.rate)
+	b.last   = now
+
+	b.tokens--
+
+	var wait time.Duration = 0
+
+	if b.tokens < 0 {
+		wait = time.Duration(-b.tokens / b.rate * float64(time.Second))
+	}
+
+	b.mutex.Unlock()
+
+	time.Sleep(wait)
+}
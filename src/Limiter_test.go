@@ -0,0 +1,127 @@
+//
+// Limiter_test.go
+//
+// This file contains tests for limiting the rate of file creation.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+//
+// traceStorage
+//
+// Wraps a Storage to trace each file created, after the limiter waits it follows, as
+// "create". Not safe for concurrent use.
+//
+//      Storage - the wrapped storage
+//      trace   - the waits and creates so far, in order
+//
+type traceStorage struct {
+	Storage
+	trace []string
+}
+
+//
+// traceLimiter
+//
+// A Limiter that traces each wait, as "wait", to a traceStorage, without limiting.
+//
+type traceLimiter struct {
+	storage *traceStorage
+}
+
+func (s *traceStorage) Create(name string) (io.WriteCloser, error) {
+	s.trace = append(s.trace, "create")
+
+	return s.Storage.Create(name)
+}
+
+func (l *traceLimiter) Wait() {
+	l.storage.trace = append(l.storage.trace, "wait")
+}
+
+//
+// TestCreateLimiter
+//
+// Checks that Map consults its CreateLimiter before creating each file, in the per-file and
+// combined layouts.
+//
+func TestCreateLimiter(t *testing.T) {
+	for _, test := range []struct {
+		combined bool
+		creates  int
+	}{
+		{false, 4},
+		{true, 2},
+	} {
+		w := newTestWorker(t, "limit")
+
+		inFile  := writeInput(t, w, "input", testInput)
+		storage := &traceStorage{Storage: w.storage()}
+
+		w.Storage        = storage
+		w.CreateLimiter  = &traceLimiter{storage: storage}
+		w.CombinedLayout = test.combined
+
+		if _, err := w.Map(0, inFile, 4, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := slices.Repeat([]string{"wait", "create"}, test.creates)
+
+		if !slices.Equal(storage.trace, expected) {
+			t.Errorf("combined %v: traced %s, expected %s", test.combined, strings.Join(storage.trace, " "), strings.Join(expected, " "))
+		}
+	}
+}
+
+//
+// TestTokenBucket
+//
+// Checks that a TokenBucket lets a burst through at once, then paces the waits at its rate.
+//
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(100, 3)
+	start  := time.Now()
+
+	for i := 0; i < 3; i++ {
+		bucket.Wait()
+	}
+
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Fatalf("burst of 3 took %s, expected no wait", elapsed)
+	}
+
+	for i := 0; i < 5; i++ {
+		bucket.Wait()
+	}
+
+	// 5 more at 100 per second take at least 50ms, less timer slack
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("8 waits took %s, expected at least 40ms", elapsed)
+	}
+}
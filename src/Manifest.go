@@ -0,0 +1,331 @@
+//
+// Manifest.go
+//
+// This file contains functionality for writing the manifest of a completed MapReduce job: a
+// machine-readable index of its outputs and statistics (see RunJob.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+//
+// Manifest
+//
+// The summary of a completed job, written as JSON to <jobName>.manifest.json, or to
+// <jobName>.<runID>.manifest.json for a run with an identifier (see Worker.RunID), so that
+// overlapping runs each write their own. Since it is written (atomically) only after every
+// Reduce task has completed, its presence marks the job as complete.
+//
+//      JobName           - the name of the MapReduce job
+//      RunID             - the run of the job (see Worker.RunID)
+//      NMap              - the number of Map tasks that were run
+//      NReduce           - the number of Reduce tasks that were run
+//      Compression       - the compression of the intermediate files (see Compression.go)
+//      OutputFormat      - the encoding of the merge files (see OutputFormat)
+//      OutputCompression - the compression of the merge files (see Worker.OutputCompression)
+//      RecordSeparator   - the separator ending each record of the merge files (see
+//                          Worker.RecordSeparator)
+//      Outputs           - the merge file of each Reduce task, in partition order
+//      TotalRecords      - the number of KeyValue pairs in all merge files
+//      TotalBytes        - the size of all merge files
+//      MapStats          - the statistics of all Map tasks added together (see MapStats)
+//
+type Manifest struct {
+	JobName           string
+	RunID             string `json:",omitempty"`
+	NMap              int
+	NReduce           int
+	Compression       string
+	OutputFormat      string
+	OutputCompression string
+	RecordSeparator   string
+	Outputs           []ManifestOutput
+	TotalRecords      int
+	TotalBytes        int64
+	MapStats          MapStats
+}
+
+//
+// ManifestOutput
+//
+// The merge file of one Reduce task in a Manifest.
+//
+//      Path    - the path of the merge file
+//      Records - the number of KeyValue pairs in the merge file
+//      Bytes   - the size of the merge file
+//
+type ManifestOutput struct {
+	Path    string
+	Records int
+	Bytes   int64
+}
+
+//
+// manifestName
+//
+// Builds the path of a job's manifest from the Worker's run identifier and output directory.
+//
+// Returns the manifest path.
+//
+func (w *Worker) manifestName() string {
+	jobName := w.JobName
+
+	if w.RunID != "" {
+		jobName += "." + w.RunID
+	}
+
+	return filepath.Join(w.OutDir, jobName+".manifest.json")
+}
+
+//
+// WriteManifest
+//
+// Writes the manifest of a completed job (see Manifest), counting the records and bytes of
+// each merge file in the Worker's output format (see countMergeRecords). The manifest is
+// written to a temporary file that is then renamed, so a reader never sees a partial
+// manifest.
+//
+//      nMap     - the number of Map tasks that were run
+//      nReduce  - the number of Reduce tasks that were run
+//      mapStats - the statistics of all Map tasks added together
+//
+// Returns the path of the manifest, and nil on success. Otherwise, the error that failed
+// the write.
+//
+func (w *Worker) WriteManifest(nMap int, nReduce int, mapStats MapStats) (string, error) {
+	var status int   = 0
+	var err    error = nil
+
+	manifest := Manifest{
+		JobName:           w.JobName,
+		RunID:             w.RunID,
+		NMap:              nMap,
+		NReduce:           nReduce,
+		Compression:       w.Compression.String(),
+		OutputFormat:      w.OutputFormat.String(),
+		OutputCompression: w.OutputCompression.String(),
+		RecordSeparator:   w.recordSeparator(),
+		Outputs:           make([]ManifestOutput, nReduce),
+		MapStats:          mapStats,
+	}
+
+	//
+	// Count the records and bytes of each merge file:
+	//
+	for i := 0; i < nReduce && status == 0; i++ {
+		output := &manifest.Outputs[i]
+
+		output.Path = w.mergeName(i)
+
+		fileInfo, tempErr := w.storage().Stat(output.Path)
+
+		if tempErr == nil {
+			output.Bytes = fileInfo.Size()
+
+			output.Records, tempErr = w.countMergeRecords(output.Path)
+		}
+
+		if tempErr != nil {
+			// Error reading merge file
+			status = -1
+			err    = tempErr
+		}
+
+		manifest.TotalRecords += output.Records
+		manifest.TotalBytes   += output.Bytes
+	}
+
+	//
+	// Encode the manifest, and write it through a temporary file:
+	//
+	fileName := w.manifestName()
+
+	if status == 0 {
+		encoding, tempErr := json.MarshalIndent(&manifest, "", "\t")
+
+		if tempErr == nil {
+			tempErr = writeFileDurable(w.storage(), fileName+".tmp", encoding, w.Durable)
+		}
+
+		if tempErr == nil {
+			tempErr = w.storage().Rename(fileName+".tmp", fileName)
+		}
+
+		if tempErr == nil && w.Durable {
+			tempErr = syncDir(w.storage(), fileName)
+		}
+
+		if tempErr != nil {
+			// Error writing manifest
+			w.storage().Remove(fileName + ".tmp")
+			status = -1
+			err    = tempErr
+		}
+	}
+
+	if status != 0 {
+		w.logf("Function error [Manifest.WriteManifest]: %s\n", err.Error())
+	}
+
+	return fileName, err
+}
+
+//
+// checkManifest
+//
+// Checks that the records of the Worker's merge files can be counted for a manifest (see
+// countMergeRecords), so that a job that cannot write its manifest fails before any task
+// runs, rather than after its outputs are written.
+//
+// Returns nil if they can. Otherwise, the error naming the unsupported output.
+//
+func (w *Worker) checkManifest() error {
+	separator := w.recordSeparator()
+
+	switch w.OutputFormat {
+	case OutputFormatJSON, OutputFormatJSONArray:
+		if w.checkJSONOutput("EmitManifest") == nil {
+			return nil
+		}
+
+	case OutputFormatTSV:
+		if strings.Trim(separator, "\r\n\x00") == "" {
+			return nil
+		}
+
+	case OutputFormatCSV:
+		if separator == "\n" || separator == "\r\n" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("EmitManifest cannot count the records of %s output separated by %q", w.OutputFormat, separator)
+}
+
+//
+// countMergeRecords
+//
+// Counts the records of a merge file in the Worker's output format (see OutputFormat). JSON
+// records are decoded, which requires a separator of JSON whitespace; TSV records end with a
+// separator that escaped keys and values never hold (see escapeTSV); and CSV records are
+// parsed, which requires a newline separator (see checkManifest).
+// *NOTE* A file that does not exist is not treated as an error, and has no records
+//
+//      fileName - the name of the merge file
+//
+// Returns the number of records, and any error reading the file.
+//
+func (w *Worker) countMergeRecords(fileName string) (int, error) {
+	var count int = 0
+
+	err := w.checkManifest()
+
+	if err != nil {
+		return 0, err
+	}
+
+	file, reader, err := openKeyValueFile(w.storage(), fileName, w.ReadBufferSize)
+
+	if file == nil {
+		// Missing file, or error opening it
+		return 0, err
+	}
+
+	defer file.Close()
+
+	switch w.OutputFormat {
+	case OutputFormatTSV:
+		count, err = countSeparators(reader, []byte(w.recordSeparator()))
+
+	case OutputFormatCSV:
+		csvReader := csv.NewReader(reader)
+
+		csvReader.FieldsPerRecord = 2
+
+		for err == nil {
+			_, err = csvReader.Read()
+
+			if err == nil {
+				count++
+			}
+		}
+
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+	default:
+		err = decodeKeyValues(reader, func(kv KeyValue) error {
+			count++
+			return nil
+		})
+	}
+
+	return count, err
+}
+
+//
+// countSeparators
+//
+// Counts the occurrences of a separator in a stream, including those that span two reads.
+//
+//      reader    - the stream to be counted
+//      separator - the separator to be counted
+//
+// Returns the number of occurrences, and any error reading the stream.
+//
+func countSeparators(reader io.Reader, separator []byte) (int, error) {
+	var count int    = 0
+	var data  []byte = nil
+
+	buffer := make([]byte, defaultReadBufferSize)
+
+	for {
+		n, err := reader.Read(buffer)
+
+		data   = append(data, buffer[:n]...)
+		count += bytes.Count(data, separator)
+
+		//
+		// Keep the bytes that may start an occurrence completed by the next read:
+		//
+		if len(data) >= len(separator) {
+			data = append(data[:0], data[len(data)-len(separator)+1:]...)
+		}
+
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+
+		if err != nil {
+			return count, err
+		}
+	}
+}
@@ -0,0 +1,148 @@
+//
+// Manifest_test.go
+//
+// This file contains tests for job manifests.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+)
+
+//
+// readManifest
+//
+// Decodes the manifest a Worker wrote for its job.
+//
+//      t - the test
+//      w - the Worker that ran the job
+//
+// Returns the manifest.
+//
+func readManifest(t testing.TB, w *Worker) Manifest {
+	t.Helper()
+
+	var manifest Manifest
+
+	if err := json.Unmarshal([]byte(readFile(t, w, w.manifestName())), &manifest); err != nil {
+		t.Fatalf("decoding manifest: %v", err)
+	}
+
+	return manifest
+}
+
+//
+// TestManifestFormats
+//
+// Checks that the manifest counts the records of merge files in each output format and
+// record separator it supports.
+//
+func TestManifestFormats(t *testing.T) {
+	expected := len(countWords(testInputs...))
+
+	for _, test := range []struct {
+		format    OutputFormat
+		separator string
+	}{
+		{OutputFormatJSON, ""},
+		{OutputFormatJSON, "\r\n"},
+		{OutputFormatJSONArray, ""},
+		{OutputFormatTSV, ""},
+		{OutputFormatTSV, "\x00"},
+		{OutputFormatCSV, ""},
+		{OutputFormatCSV, "\r\n"},
+	} {
+		w := newTestWorker(t, "manifest")
+		w.EmitManifest    = true
+		w.OutputFormat    = test.format
+		w.RecordSeparator = test.separator
+
+		if err := w.RunJob(writeInputs(t, w, testInputs), 3, WordCountMap, WordCountReduce); err != nil {
+			t.Fatalf("%s separated by %q: %v", test.format, test.separator, err)
+		}
+
+		manifest := readManifest(t, w)
+
+		if manifest.TotalRecords != expected || manifest.OutputFormat != test.format.String() {
+			t.Fatalf("%s separated by %q: manifest %+v, expected %d records", test.format, test.separator, manifest, expected)
+		}
+	}
+}
+
+//
+// TestManifestUnsupportedFormat
+//
+// Checks that a job whose records the manifest cannot count fails before any task runs, and
+// leaves the outputs of an earlier run in place.
+//
+func TestManifestUnsupportedFormat(t *testing.T) {
+	w := newTestWorker(t, "manifest")
+	inFiles := writeInputs(t, w, testInputs)
+
+	if err := w.RunJob(inFiles, 3, WordCountMap, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	w.EmitManifest    = true
+	w.OutputFormat    = OutputFormatCSV
+	w.RecordSeparator = "\x00"
+
+	if err := w.RunJob(inFiles, 3, WordCountMap, WordCountReduce); err == nil {
+		t.Fatal("CSV separated by NULs accepted")
+	}
+
+	checkOutput(t, readOutput(t, w, 3), countWords(testInputs...))
+}
+
+//
+// TestManifestRunID
+//
+// Checks that two runs of a job with different run identifiers each write their own
+// manifest, named after the run, rather than one overwriting the other's.
+//
+func TestManifestRunID(t *testing.T) {
+	w := newTestWorker(t, "manifest")
+	w.EmitManifest = true
+
+	inFiles := writeInputs(t, w, testInputs)
+
+	for _, runID := range []string{"run1", "run2"} {
+		w.RunID = runID
+
+		if err := w.RunJob(inFiles, 3, WordCountMap, WordCountReduce); err != nil {
+			t.Fatalf("%s: %v", runID, err)
+		}
+
+		if expected := filepath.Join(w.OutDir, "manifest."+runID+".manifest.json"); w.manifestName() != expected {
+			t.Fatalf("manifest %s, expected %s", w.manifestName(), expected)
+		}
+	}
+
+	for _, runID := range []string{"run1", "run2"} {
+		w.RunID = runID
+
+		if manifest := readManifest(t, w); manifest.RunID != runID {
+			t.Fatalf("manifest of %s has RunID %q", runID, manifest.RunID)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+//
+// MapContext.go
+//
+// This file contains functionality for map functions that need to know which task they run
+// in, e.g. to seed a deterministic sampler per task (see Worker.MapWithContext).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"sync"
+)
+
+//
+// MapContext
+//
+// Describes the Map task a map function is called by (see Worker.MapWithContext).
+//
+//      JobName       - the name of the MapReduce job
+//      MapTaskNumber - the unique number assigned to the Map task
+//      NReduce       - the number of Reduce tasks that will be run
+//      Counters      - the call's counters, which the map function may increment; they are
+//                      added to the task's MapStats when the call returns (see
+//                      Worker.mapWithContext)
+//      Config        - the job's user configuration (see Worker.Config), e.g. a threshold
+//                      or a field index. It is shared by every task, so it is read-only
+//
+type MapContext struct {
+	JobName       string
+	MapTaskNumber int
+	NReduce       int
+	Counters      Counters
+	Config        map[string]string
+}
+
+//
+// doMapWithContext
+//
+// Does the job of a map worker with a context-aware map function, with the default
+// configuration (see Worker.MapWithContext).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapWithContext(
+	jobName       string,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(ctx MapContext, file string, contents string) []KeyValue,
+) (MapStats, error) {
+	return (&Worker{JobName: jobName}).MapWithContext(mapTaskNumber, inFile, nReduce, mapFunc)
+}
+
+//
+// MapWithContext
+//
+// This function does the job of a map worker like Map, but also passes the map function a
+// MapContext describing its task, so that it can depend on its task without global state.
+// The counters the map function increments are returned in MapStats.Counters (an empty map
+// if it increments none).
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapWithContext(
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(ctx MapContext, file string, contents string) []KeyValue,
+) (MapStats, error) {
+	return w.mapWithContext(mapTaskNumber, nReduce, mapFunc, func(taskMapFunc func(file string, contents string) []KeyValue) (MapStats, error) {
+		return w.Map(mapTaskNumber, inFile, nReduce, taskMapFunc)
+	})
+}
+
+//
+// mapWithContext
+//
+// Runs a Map task with a context-aware map function, by passing a plain map function that
+// calls it with the task's MapContext to the function running the task (e.g. Map or
+// MapSplit), and collects the counters it increments into the task's MapStats.
+//
+// Each call is given counters of its own, which are merged into the task's when it returns.
+// A call that times out is abandoned while it may still be running (see callWithTimeout), so
+// calls merge under a lock, and the counters of a call still running when the task returns
+// are dropped: the task's counters are never written once they have been reported.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//      run           - runs the Map task with the plain map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) mapWithContext(
+	mapTaskNumber int,
+	nReduce       int,
+	mapFunc       func(ctx MapContext, file string, contents string) []KeyValue,
+	run           func(mapFunc func(file string, contents string) []KeyValue) (MapStats, error),
+) (MapStats, error) {
+	var counters Counters   = Counters{}
+	var mutex    sync.Mutex
+	var returned bool       = false
+
+	stats, err := run(func(file string, contents string) []KeyValue {
+		ctx := MapContext{
+			JobName:       w.JobName,
+			MapTaskNumber: mapTaskNumber,
+			NReduce:       nReduce,
+			Counters:      Counters{},
+			Config:        w.Config,
+		}
+
+		keyValues := mapFunc(ctx, file, contents)
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if !returned {
+			counters.Merge(ctx.Counters)
+		}
+
+		return keyValues
+	})
+
+	mutex.Lock()
+	returned = true
+	mutex.Unlock()
+
+	stats.Counters = counters
+
+	return stats, err
+}
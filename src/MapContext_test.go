@@ -0,0 +1,78 @@
+//
+// MapContext_test.go
+//
+// This file contains tests for map functions that read their task's context.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestMapContextTask
+//
+// Runs three Map tasks whose map function tags each word with its task number from the
+// MapContext, and checks the context of every call, and that each word's tags name the
+// tasks whose inputs hold it.
+//
+func TestMapContextTask(t *testing.T) {
+	w := newTestWorker(t, "context")
+
+	inputs := []string{"a b", "b c", "c a d"}
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		_, err := w.MapWithContext(m, inFile, 2, func(ctx MapContext, file string, contents string) []KeyValue {
+			if ctx.JobName != w.JobName || ctx.MapTaskNumber != m || ctx.NReduce != 2 {
+				t.Errorf("map task %d: context %+v", m, ctx)
+			}
+
+			var keyValues []KeyValue = nil
+
+			for _, kv := range wordOnesMap(file, contents) {
+				keyValues = append(keyValues, KeyValue{kv.Key, strconv.Itoa(ctx.MapTaskNumber)})
+			}
+
+			return keyValues
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Map tasks are read in order, so each word's tags are too
+	concat := func(key string, values []string) string {
+		return strings.Join(values, "")
+	}
+
+	for r := 0; r < 2; r++ {
+		if _, err := w.Reduce(r, len(inputs), concat); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkOutput(t, readOutput(t, w, 2), map[string]string{"a": "02", "b": "01", "c": "12", "d": "2"})
+}
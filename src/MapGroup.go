@@ -0,0 +1,192 @@
+//
+// MapGroup.go
+//
+// This file contains functionality for running the Map tasks of a job concurrently with
+// fail-fast semantics: the first failed task cancels the others (see Worker.MapGroup).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"context"
+	"fmt"
+	"iter"
+
+	"golang.org/x/sync/errgroup"
+)
+
+//
+// mapGroup
+//
+// Runs the Map tasks of a job concurrently with the default configuration (see
+// Worker.MapGroup).
+//
+//      ctx     - the context of the run; canceling it cancels every task
+//      jobName - the name of the MapReduce job
+//      inFiles - the names of the input files, one per Map task
+//      nReduce - the number of Reduce tasks that will be run
+//      mapFunc - the user-defined Map function
+//
+// Returns the statistics of every Map task, and nil on success. Otherwise, the error of the
+// first failed task.
+//
+func mapGroup(
+	ctx     context.Context,
+	jobName string,
+	inFiles []string,
+	nReduce int,
+	mapFunc func(file string, contents string) []KeyValue,
+) ([]MapStats, error) {
+	return (&Worker{JobName: jobName}).MapGroup(ctx, inFiles, nReduce, mapFunc)
+}
+
+//
+// MapGroup
+//
+// Runs a Map task for each input file accepted by the input filter (see Worker.InputFilter)
+// concurrently (see MapCancellable), in an errgroup that shares one context between the
+// tasks: the first task to fail cancels it, so the remaining tasks stop early rather than
+// run to completion, and its error is returned. This gives an interactive run fail-fast
+// semantics, where RunJob would let the running tasks finish and report every error.
+//
+// Up to Parallelism tasks are run at once (zero means one, as in RunJob), so the Worker's
+// functions and mapFunc must be safe for concurrent use. A canceled task removes the
+// intermediate files it had created, and once every task has stopped, the intermediate
+// files of the tasks that completed are removed too, so a failed (or canceled) run leaves
+// nothing behind.
+//
+//      ctx     - the context of the run; canceling it cancels every task
+//      inFiles - the names of the input files, one per Map task
+//      nReduce - the number of Reduce tasks that will be run
+//      mapFunc - the user-defined Map function
+//
+// Returns the statistics of every Map task, and nil on success. Otherwise, the error of the
+// first failed task (or the context's error, if it was canceled from outside the run).
+//
+func (w *Worker) MapGroup(
+	ctx     context.Context,
+	inFiles []string,
+	nReduce int,
+	mapFunc func(file string, contents string) []KeyValue,
+) ([]MapStats, error) {
+	inFiles = w.filterInputs(inFiles)
+
+	mapStats := make([]MapStats, len(inFiles))
+
+	group, groupCtx := errgroup.WithContext(ctx)
+
+	group.SetLimit(max(w.Parallelism, 1))
+
+	for task, inFile := range inFiles {
+		group.Go(func() error {
+			var taskErr error
+
+			mapStats[task], taskErr = w.MapCancellable(groupCtx, task, inFile, nReduce, mapFunc)
+
+			if taskErr != nil {
+				taskErr = fmt.Errorf("Map task %d (%s): %w", task, inFile, taskErr)
+			}
+
+			return taskErr
+		})
+	}
+
+	err := group.Wait()
+
+	//
+	// Handle any error by removing the files of the tasks that completed:
+	//
+	if err != nil {
+		for task := range inFiles {
+			tempErr := w.removeMapOutput(task, nReduce)
+
+			if tempErr != nil {
+				w.logf("Function warning [MapGroup.MapGroup]: %s\n", tempErr.Error())
+			}
+		}
+	}
+
+	return mapStats, err
+}
+
+//
+// MapCancellable
+//
+// This function does the job of a map worker like Map, but stops early once a context is
+// canceled: the context is checked before the input is read and before the intermediate
+// files are written, and a canceled task fails with an error wrapping the context's error
+// (e.g. context.Canceled), removing any intermediate file it had created. A Map function
+// that is already running is not interrupted; it is left to return first.
+//
+//      ctx           - the context of the task
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc       - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapCancellable(
+	ctx           context.Context,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) []KeyValue,
+) (MapStats, error) {
+	records := cancellableRecords(ctx, fileContents(w.storage(), inFile))
+
+	return mapRecords(w, mapTaskNumber, inFile, 0, nReduce, records, func(file string, contents string) ([]KeyValue, error) {
+		return mapFunc(file, contents), nil
+	})
+}
+
+//
+// cancellableRecords
+//
+// Wraps a sequence of input records (see mapRecords) so that it ends with the context's
+// error once the context is canceled: before each record is read, and after the last.
+//
+//      ctx     - the context that cancels the sequence
+//      records - the input records, or the error that ended reading them
+//
+// Returns the sequence of the records, or of the error that ended them.
+//
+func cancellableRecords[R any](ctx context.Context, records iter.Seq2[R, error]) iter.Seq2[R, error] {
+	return func(yield func(R, error) bool) {
+		var zero R
+
+		if ctx.Err() != nil {
+			yield(zero, ctx.Err())
+			return
+		}
+
+		for record, err := range records {
+			if !yield(record, err) {
+				return
+			}
+
+			if ctx.Err() != nil {
+				yield(zero, ctx.Err())
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,221 @@
+//
+// MapGroup_test.go
+//
+// This file contains tests for running Map tasks with fail-fast semantics.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//
+// checkNoMapOutput
+//
+// Fails a test if any Map task of a job left an intermediate file behind.
+//
+//      t       - the test
+//      w       - the Worker that ran the Map tasks
+//      nMap    - the number of Map tasks
+//      nReduce - the number of Reduce tasks
+//
+func checkNoMapOutput(t testing.TB, w *Worker, nMap int, nReduce int) {
+	t.Helper()
+
+	for m := 0; m < nMap; m++ {
+		for r := 0; r < nReduce; r++ {
+			if files, err := w.intermediateFiles(w.reduceName(m, r)); files != nil || err != nil {
+				t.Fatalf("map task %d left %v: %v", m, files, err)
+			}
+		}
+	}
+}
+
+//
+// TestMapGroupFirstError
+//
+// Runs several Map tasks one at a time in a MapGroup, the first of which fails to read its
+// input, and checks that its error is returned, that the tasks after it are canceled before
+// their Map function is called, and that no intermediate file is left behind.
+//
+func TestMapGroupFirstError(t *testing.T) {
+	w := newTestWorker(t, "mapgroup")
+	w.Parallelism = 1
+
+	inFiles := []string{filepath.Join(w.OutDir, "missing")}
+
+	for m := 1; m < 4; m++ {
+		inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(m), "a b c"))
+	}
+
+	var calls atomic.Int32
+
+	_, err := w.MapGroup(context.Background(), inFiles, 3, func(file string, contents string) []KeyValue {
+		calls.Add(1)
+		return WordCountMap(file, contents)
+	})
+
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("MapGroup returned %v, expected %v", err, fs.ErrNotExist)
+	}
+
+	if calls.Load() != 0 {
+		t.Fatalf("Map function called %d times after the first error, expected none", calls.Load())
+	}
+
+	checkNoMapOutput(t, w, len(inFiles), 3)
+}
+
+//
+// TestMapGroupCanceled
+//
+// Runs several Map tasks concurrently in a MapGroup, cancels the run while every Map function
+// is running, and checks that each task is canceled once its Map function returns, and that
+// no intermediate file is left behind.
+//
+func TestMapGroupCanceled(t *testing.T) {
+	const nMap = 4
+
+	w := newTestWorker(t, "mapgroup")
+	w.Parallelism = nMap
+
+	var inFiles []string = nil
+
+	for m := 0; m < nMap; m++ {
+		inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(m), "a b c"))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	release     := make(chan struct{})
+
+	var running sync.WaitGroup
+	running.Add(nMap)
+
+	go func() {
+		running.Wait()
+		cancel()
+		close(release)
+	}()
+
+	_, err := w.MapGroup(ctx, inFiles, 3, func(file string, contents string) []KeyValue {
+		running.Done()
+		<-release
+		return WordCountMap(file, contents)
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("MapGroup returned %v, expected %v", err, context.Canceled)
+	}
+
+	checkNoMapOutput(t, w, nMap, 3)
+}
+
+//
+// TestMapGroup
+//
+// Runs a word count job with MapGroup, and checks its output.
+//
+func TestMapGroup(t *testing.T) {
+	inputs := []string{"a b c", "b c d", "c d e"}
+
+	w := newTestWorker(t, "mapgroup")
+
+	var inFiles []string = nil
+
+	for m, input := range inputs {
+		inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(m), input))
+	}
+
+	mapStats, err := w.MapGroup(context.Background(), inFiles, 2, WordCountMap)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mapStats) != len(inputs) {
+		t.Fatalf("%d MapStats, expected %d", len(mapStats), len(inputs))
+	}
+
+	for r := 0; r < 2; r++ {
+		if _, err := w.Reduce(r, len(inputs), WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkOutput(t, readOutput(t, w, 2), countWords(inputs...))
+}
+
+//
+// TestMapGroupParallelism
+//
+// Checks that MapGroup runs no more than Parallelism Map tasks at once, and only one when
+// Parallelism is zero, as RunJob does.
+//
+func TestMapGroupParallelism(t *testing.T) {
+	const nMap = 6
+
+	for _, parallelism := range []int{0, 1, 3} {
+		w := newTestWorker(t, "mapgroup")
+		w.Parallelism = parallelism
+
+		var inFiles []string = nil
+
+		for m := 0; m < nMap; m++ {
+			inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(m), "a b c"))
+		}
+
+		var running atomic.Int32
+		var peak    atomic.Int32
+
+		_, err := w.MapGroup(context.Background(), inFiles, 1, func(file string, contents string) []KeyValue {
+			current := running.Add(1)
+
+			for {
+				previous := peak.Load()
+
+				if current <= previous || peak.CompareAndSwap(previous, current) {
+					break
+				}
+			}
+
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+
+			return WordCountMap(file, contents)
+		})
+
+		if err != nil {
+			t.Fatalf("parallelism %d: %v", parallelism, err)
+		}
+
+		if limit := int32(max(parallelism, 1)); peak.Load() > limit {
+			t.Errorf("parallelism %d: %d tasks ran at once, expected at most %d", parallelism, peak.Load(), limit)
+		}
+	}
+}
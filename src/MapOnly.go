@@ -0,0 +1,187 @@
+//
+// MapOnly.go
+//
+// This file contains functionality for map-only jobs (e.g. format conversion or filtering),
+// whose map output is their final output, so they need no partitioning, shuffle or reduce.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+)
+
+//
+// doMapOnly
+//
+// Does the job of a map-only worker with the default configuration (see Worker.MapOnlyTo).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      mapFunc		  - the user-defined Map function
+//      out           - the writer to write the map output to
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapOnly(
+	jobName       string,
+	mapTaskNumber int,
+	inFile        string,
+	mapFunc       func(file string, contents string) []KeyValue,
+	out           io.Writer,
+) (MapStats, error) {
+	return (&Worker{JobName: jobName}).MapOnlyTo(mapTaskNumber, inFile, mapFunc, out)
+}
+
+//
+// MapOnlyTo
+//
+// This function does the job of a map-only worker: it reads its input file, calls the
+// user-defined map function for the file's contents, and writes every KeyValue emitted, in
+// the order emitted (or sorted by key; see Worker.SortOutput), to a writer. The output is
+// encoded as a merge file would be (see Worker.OutputFormat and Worker.OutputCompression).
+// Several Map tasks may write to the same writer, one after another, to gather a job's
+// output in one place.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      mapFunc		  - the user-defined Map function
+//      out           - the writer to write the map output to
+//
+// Returns the task's statistics (without partitions), and nil on success. Otherwise, the
+// error that failed the task.
+//
+func (w *Worker) MapOnlyTo(
+	mapTaskNumber int,
+	inFile        string,
+	mapFunc       func(file string, contents string) []KeyValue,
+	out           io.Writer,
+) (MapStats, error) {
+	keyValues, stats, err := w.mapOnly(inFile, mapFunc)
+
+	if err == nil {
+		err = w.encodeKeyValues(out, keyValues)
+
+		if err != nil {
+			// Error encoding or writing
+			err = stageError(ErrWriteMerge, err)
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [MapOnly.MapOnlyTo]: %s\n", err.Error())
+	}
+
+	return stats, err
+}
+
+//
+// MapOnly
+//
+// This function does the job of a map-only worker like MapOnlyTo, but writes the map output
+// to its own file: the merge file numbered mapTaskNumber, written atomically as a Reduce
+// task writes its merge file (see writeOutputFile). A map-only job of nMap tasks can then
+// be read like a job of nMap Reduce tasks (e.g. with ConcatMerge or JobChecksum).
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the path of the output file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func (w *Worker) MapOnly(
+	mapTaskNumber int,
+	inFile        string,
+	mapFunc       func(file string, contents string) []KeyValue,
+) (string, error) {
+	var outputPath string = ""
+
+	keyValues, _, err := w.mapOnly(inFile, mapFunc)
+
+	if err == nil {
+		outputPath = w.mergeName(mapTaskNumber)
+
+		err = w.writeOutputFile(outputPath, keyValues)
+
+		if err != nil {
+			// Error writing file
+			outputPath = ""
+			err        = stageError(ErrWriteMerge, err)
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [MapOnly.MapOnly]: %s\n", err.Error())
+	}
+
+	return outputPath, err
+}
+
+//
+// mapOnly
+//
+// Reads the input file of a map-only task, and calls the map function for its contents (see
+// MapOnlyTo).
+//
+//      inFile  - the name of the input file
+//      mapFunc - the user-defined Map function
+//
+// Returns the KeyValue pairs emitted (sorted if requested), the task's statistics, and nil
+// on success. Otherwise, the error reading the input or calling the map function.
+//
+func (w *Worker) mapOnly(
+	inFile  string,
+	mapFunc func(file string, contents string) []KeyValue,
+) ([]KeyValue, MapStats, error) {
+	var keyValues []KeyValue = nil
+	var stats     MapStats   = MapStats{}
+	var err       error      = nil
+
+	for contents, tempErr := range fileContents(w.storage(), inFile) {
+		if tempErr != nil {
+			// Error reading input
+			err = stageError(ErrReadInput, tempErr)
+			break
+		}
+
+		keyValues, tempErr = callMapFunc(func(file string, contents string) ([]KeyValue, error) {
+			return mapFunc(file, contents), nil
+		}, inFile, contents, w.Timeout)
+
+		stats.InputRecords++
+
+		if tempErr != nil {
+			// Map function panicked, or timed out
+			err = stageError(ErrMapFunc, tempErr)
+			break
+		}
+	}
+
+	if err == nil && w.SortOutput {
+		sortKeyValues(keyValues)
+	}
+
+	stats.OutputRecords = len(keyValues)
+
+	return keyValues, stats, err
+}
@@ -0,0 +1,122 @@
+//
+// MapOnly_test.go
+//
+// This file contains tests for map-only jobs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// logInputs
+//
+// The inputs of the map-only tests: logs whose error lines are kept by the filter.
+//
+var logInputs = []string{
+	"ok start\nerror disk full\nok retry\n",
+	"ok\nok\n",
+	"error timeout\nerror refused",
+}
+
+//
+// TestMapOnlyFilter
+//
+// Runs a filtering map-only job (see GrepMap), one output file per Map task, and checks that
+// each file holds exactly its input's error lines, in order, and that no intermediate files
+// are written.
+//
+func TestMapOnlyFilter(t *testing.T) {
+	w := newTestWorker(t, "filter")
+
+	filter := GrepMap("^error")
+
+	expected := [][]KeyValue{
+		{{"input-0:2", "error disk full"}},
+		{},
+		{{"input-2:1", "error timeout"}, {"input-2:2", "error refused"}},
+	}
+
+	for m, input := range logInputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		outputPath, err := w.MapOnly(m, inFile, func(file string, contents string) []KeyValue {
+			// Key lines by the input's base name, to compare them independently of OutDir
+			return filter("input-"+strconv.Itoa(m), contents)
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if outputPath != w.mergeName(m) {
+			t.Fatalf("output %s, expected %s", outputPath, w.mergeName(m))
+		}
+
+		if keyValues := readKeyValueFile(t, w, outputPath); !slices.Equal(keyValues, expected[m]) {
+			t.Fatalf("map task %d: output %v, expected %v", m, keyValues, expected[m])
+		}
+
+		if _, err := w.storage().Stat(w.reduceName(m, 0)); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("map task %d wrote an intermediate file: %v", m, err)
+		}
+	}
+}
+
+//
+// TestMapOnlyTo
+//
+// Runs a filtering map-only job whose tasks all write to one buffer, and checks that it
+// holds every task's output, in task order, encoded as a merge file would be.
+//
+func TestMapOnlyTo(t *testing.T) {
+	w := newTestWorker(t, "filterto")
+
+	var buffer bytes.Buffer
+
+	for m, input := range logInputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := w.MapOnlyTo(m, inFile, GrepMap("^error"), &buffer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []string{"error disk full", "error timeout", "error refused"}
+
+	fileName := writeInput(t, w, "combined", buffer.String())
+
+	var lines []string = nil
+
+	for _, kv := range readKeyValueFile(t, w, fileName) {
+		lines = append(lines, kv.Value)
+	}
+
+	if !slices.Equal(lines, expected) {
+		t.Fatalf("lines %q, expected %q", lines, expected)
+	}
+}
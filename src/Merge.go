@@ -0,0 +1,533 @@
+//
+// Merge.go
+//
+// This file contains functionality for merging the sorted merge files written by 'reduce'
+// workers (see DoReduce.go) into a single output.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+)
+
+//
+// finalMerge
+//
+// Merges the merge files of a job with the default configuration (see Worker.FinalMerge).
+//
+//      jobName - the name of the MapReduce job
+//      nReduce - the number of Reduce tasks that were run
+//      out     - the writer to write the merged KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error that failed the merge.
+//
+func finalMerge(jobName string, nReduce int, out io.Writer) error {
+	return (&Worker{JobName: jobName}).FinalMerge(nReduce, out)
+}
+
+//
+// concatMerge
+//
+// Concatenates the merge files of a job with the default configuration (see
+// Worker.ConcatMerge).
+//
+//      jobName - the name of the MapReduce job
+//      nReduce - the number of Reduce tasks that were run
+//      out     - the writer to write the concatenated KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error that failed the merge.
+//
+func concatMerge(jobName string, nReduce int, out io.Writer) error {
+	return (&Worker{JobName: jobName}).ConcatMerge(nReduce, out)
+}
+
+//
+// FinalMerge
+//
+// Merges the merge files of a job (compressed or not; see Worker.OutputCompression) into a
+// single, globally sorted stream of JSON-encoded KeyValue pairs. Each merge file must be
+// sorted by key (see Worker.SortOutput); they are merged with a heap holding the next
+// KeyValue of every file.
+//
+// This is the merge to use with hash partitioning (the default Partitioner), where every
+// partition holds keys from the whole key space. With range partitioning, ConcatMerge gives
+// the same result more cheaply.
+//
+// When deduplicating (see Worker.DedupFinalMerge), a KeyValue identical to an earlier one is
+// dropped, and the number dropped is logged as a warning. With correct partitioning no key
+// is in two merge files, so a drop points to a faulty Partitioner. As the merged stream is
+// sorted by key, duplicates fall in the same run of equal keys, and only that run is held
+// (see recordDeduper); the extra memory is constant only because the merge files are sorted.
+//
+//      nReduce - the number of Reduce tasks that were run
+//      out     - the writer to write the merged KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error that failed the merge, e.g. because the merge
+// files are not JSON (see OutputFormat).
+//
+func (w *Worker) FinalMerge(nReduce int, out io.Writer) error {
+	err := w.checkJSONOutput("FinalMerge")
+
+	if err != nil {
+		return err
+	}
+
+	files, readers, names, err := w.openMergeFiles(nReduce)
+
+	//
+	// Merge the files' KeyValue pairs in key order, and encode them to the writer:
+	//
+	var deduper recordDeduper
+
+	if err == nil {
+		writer  := bufio.NewWriter(out)
+		encoder := json.NewEncoder(writer)
+
+		err = mergeSorted(readers, names, func(kv KeyValue) error {
+			if w.DedupFinalMerge && deduper.duplicate(kv) {
+				// Exact duplicate of an earlier KeyValue
+				return nil
+			}
+
+			return encoder.Encode(&kv)
+		})
+
+		if err == nil {
+			err = writer.Flush()
+		}
+	}
+
+	if deduper.dropped > 0 {
+		w.logf("Function warning [Merge.FinalMerge]: dropped %d duplicate KeyValues\n", deduper.dropped)
+	}
+
+	for _, file := range files {
+		file.Close()
+	}
+
+	return err
+}
+
+//
+// openMergeFiles
+//
+// Opens the merge files of a job for decoding, detecting each file's compression.
+//
+//      nReduce - the number of Reduce tasks that were run
+//
+// Returns the opened files (which the caller must close, even on error), the readers to
+// decode them from, their names (for errors), and any error opening them.
+//
+func (w *Worker) openMergeFiles(nReduce int) ([]io.ReadCloser, []io.Reader, []string, error) {
+	var err error = nil
+
+	files   := make([]io.ReadCloser, 0, nReduce)
+	readers := make([]io.Reader, 0, nReduce)
+	names   := make([]string, 0, nReduce)
+
+	for i := 0; i < nReduce; i++ {
+		file, tempErr := w.storage().Open(w.mergeName(i))
+
+		if tempErr != nil {
+			// Error opening file
+			err = tempErr
+			break
+		}
+
+		files = append(files, file)
+
+		reader, tempErr := decompressReader(bufio.NewReader(file))
+
+		if tempErr != nil {
+			// Error reading compression header
+			err = tempErr
+			break
+		}
+
+		readers = append(readers, reader)
+		names   = append(names, w.mergeName(i))
+	}
+
+	return files, readers, names, err
+}
+
+//
+// ConcatMerge
+//
+// Concatenates the merge files of a job, in partition order, into a single stream of
+// JSON-encoded KeyValue pairs (decompressing any compressed ones). Nothing is re-sorted, so
+// the result is only globally sorted when each merge file is sorted by key (see
+// Worker.SortOutput) and partition i holds only keys smaller than those of partition i+1.
+//
+// This is the merge to use with range partitioning (see RangePartitioner), which assigns
+// increasing key ranges to increasing partitions. With hash partitioning (the default
+// Partitioner), use FinalMerge.
+//
+//      nReduce - the number of Reduce tasks that were run
+//      out     - the writer to write the concatenated KeyValue pairs to
+//
+// Returns nil on success. Otherwise, the error that failed the merge, e.g. because the merge
+// files are not JSON (see OutputFormat).
+//
+func (w *Worker) ConcatMerge(nReduce int, out io.Writer) error {
+	err := w.checkJSONOutput("ConcatMerge")
+
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < nReduce; i++ {
+		file, tempErr := w.storage().Open(w.mergeName(i))
+
+		if tempErr != nil {
+			// Error opening file
+			err = tempErr
+			break
+		}
+
+		reader, tempErr := decompressReader(bufio.NewReader(file))
+
+		if tempErr == nil {
+			_, tempErr = io.Copy(out, reader)
+		}
+
+		file.Close()
+
+		if tempErr != nil {
+			// Error copying file
+			err = tempErr
+			break
+		}
+	}
+
+	return err
+}
+
+//
+// MergeSortedKeyValues
+//
+// Merges streams of JSON-encoded KeyValue pairs, each sorted by key, into a single sequence
+// sorted by key, e.g. to combine the sorted outputs of several jobs in a custom pipeline.
+// This is the k-way merge behind FinalMerge and the streaming Reduce (see
+// Worker.PartitionsSorted): only the next KeyValue of each stream is held in memory, and
+// KeyValues with equal keys are produced in the order of their streams.
+// *NOTE* The sequence ends early, without reporting why, if a stream fails to decode or is
+//        not sorted; use MergeSortedKeyValuesErr to receive the error
+//
+//      readers - the sorted streams to be merged, which are consumed as the sequence is
+//                iterated, so it can be iterated once
+//      less    - the order the streams are sorted in (nil means byte order)
+//
+// Returns the sequence of merged keys and values.
+//
+func MergeSortedKeyValues(readers []io.Reader, less func(a, b string) bool) iter.Seq2[string, string] {
+	return func(yield func(string, string) bool) {
+		for kv, err := range MergeSortedKeyValuesErr(readers, less) {
+			if err != nil || !yield(kv.Key, kv.Value) {
+				return
+			}
+		}
+	}
+}
+
+//
+// MergeSortedKeyValuesErr
+//
+// Merges streams of JSON-encoded KeyValue pairs, each sorted by key, into a single sequence
+// sorted by key (see MergeSortedKeyValues).
+//
+//      readers - the sorted streams to be merged
+//      less    - the order the streams are sorted in (nil means byte order)
+//
+// Returns the sequence of merged KeyValue pairs, ending with the error that ended the
+// merge, if any: the error decoding a stream, or a stream not sorted by less.
+//
+func MergeSortedKeyValuesErr(readers []io.Reader, less func(a, b string) bool) iter.Seq2[KeyValue, error] {
+	return func(yield func(KeyValue, error) bool) {
+		merger, err := newSortedMerger(readers, nil, less, decodeOptions{})
+
+		for err == nil {
+			kv, more := merger.peek()
+
+			if !more {
+				// Every stream exhausted
+				return
+			}
+
+			if !yield(kv, nil) {
+				return
+			}
+
+			err = merger.advance()
+		}
+
+		yield(KeyValue{}, err)
+	}
+}
+
+//
+// keyLess
+//
+// Compares two keys in an order.
+//
+//      less - the order of the keys (nil means byte order)
+//      a    - the first key
+//      b    - the second key
+//
+// Returns true if a comes before b.
+//
+func keyLess(less func(a, b string) bool, a string, b string) bool {
+	if less == nil {
+		return a < b
+	}
+
+	return less(a, b)
+}
+
+//
+// mergeSorted
+//
+// Merges streams of JSON-encoded KeyValue pairs, each sorted by key, into a single stream
+// sorted by key (see sortedMerger).
+//
+//      readers - the sorted streams to be merged
+//      names   - the names of the streams, used in errors (may be nil)
+//      emit    - called with each KeyValue in merged order; an error stops the merge
+//
+// Returns nil on success. Otherwise, the error decoding a stream or returned by emit.
+//
+func mergeSorted(readers []io.Reader, names []string, emit func(kv KeyValue) error) error {
+	merger, err := newSortedMerger(readers, names, nil, decodeOptions{})
+
+	for err == nil {
+		kv, more := merger.peek()
+
+		if !more {
+			break
+		}
+
+		err = emit(kv)
+
+		if err == nil {
+			err = merger.advance()
+		}
+	}
+
+	return err
+}
+
+//
+// sortedMerger
+//
+// Merges streams of JSON-encoded KeyValue pairs, each sorted by key, into a single stream
+// sorted by key, one KeyValue at a time. KeyValues with equal keys are produced in the order
+// of their streams. A heap holds the next KeyValue of every stream, so only one KeyValue per
+// stream is in memory at once.
+//
+type sortedMerger struct {
+	streams   mergeHeap
+	truncated []string
+}
+
+//
+// newSortedMerger
+//
+// Creates a merger over sorted streams, decoding the first KeyValue of each.
+//
+//      readers - the sorted streams to be merged
+//      names   - the names of the streams, used in errors (may be nil)
+//      less    - the order of the keys (nil means byte order)
+//      options - how malformed streams are treated (see keyValueDecoder); the names of
+//                streams cut short are collected in truncated
+//
+// Returns the merger, and any error decoding a stream.
+//
+func newSortedMerger(
+	readers []io.Reader,
+	names   []string,
+	less    func(a, b string) bool,
+	options decodeOptions,
+) (*sortedMerger, error) {
+	var err error = nil
+
+	merger := &sortedMerger{streams: make(mergeHeap, 0, len(readers))}
+
+	for i, reader := range readers {
+		name := fmt.Sprintf("stream %d", i)
+
+		if names != nil {
+			name = names[i]
+		}
+
+		decoder, tempErr := newKeyValueDecoder(reader, name, options)
+
+		if tempErr != nil {
+			err = tempErr
+			break
+		}
+
+		stream := &mergeStream{decoder: decoder, index: i, name: name, less: less}
+
+		more, tempErr := stream.next()
+
+		if tempErr != nil {
+			err = tempErr
+			break
+		}
+
+		if more {
+			merger.streams = append(merger.streams, stream)
+		} else if decoder.truncated {
+			merger.truncated = append(merger.truncated, stream.name)
+		}
+	}
+
+	heap.Init(&merger.streams)
+
+	return merger, err
+}
+
+//
+// peek
+//
+// Returns the smallest KeyValue not yet consumed, and false if every stream is exhausted.
+//
+func (m *sortedMerger) peek() (KeyValue, bool) {
+	if len(m.streams) == 0 {
+		return KeyValue{}, false
+	}
+
+	return m.streams[0].current, true
+}
+
+//
+// advance
+//
+// Consumes the KeyValue returned by peek, decoding the next KeyValue of its stream.
+//
+// Returns any error decoding the stream.
+//
+func (m *sortedMerger) advance() error {
+	stream := m.streams[0]
+
+	more, err := stream.next()
+
+	if err == nil && more {
+		heap.Fix(&m.streams, 0)
+	} else {
+		heap.Pop(&m.streams)
+
+		if stream.decoder.truncated {
+			m.truncated = append(m.truncated, stream.name)
+		}
+	}
+
+	return err
+}
+
+//
+// mergeStream
+//
+// A sorted stream of KeyValue pairs being merged, holding its next KeyValue. Every stream of
+// a merge shares the same order of keys (nil means byte order).
+//
+type mergeStream struct {
+	decoder *keyValueDecoder
+	current KeyValue
+	index   int
+	name    string
+	started bool
+	less    func(a, b string) bool
+}
+
+//
+// next
+//
+// Decodes the stream's next KeyValue into current, checking that the stream is sorted.
+//
+// Returns true if a KeyValue was decoded, false at the end of the stream, and any error
+// decoding.
+//
+func (s *mergeStream) next() (bool, error) {
+	var tempKV KeyValue
+
+	more, err := s.decoder.next(&tempKV)
+
+	if err == nil && !more {
+		return false, nil
+	}
+
+	if err == nil && s.started && keyLess(s.less, tempKV.Key, s.current.Key) {
+		err = fmt.Errorf("%s is not sorted by key (%q after %q)", s.name, tempKV.Key, s.current.Key)
+	}
+
+	if err == nil {
+		s.current = tempKV
+		s.started = true
+	}
+
+	return err == nil, err
+}
+
+//
+// mergeHeap
+//
+// A min-heap of merge streams ordered by their next key, then by stream index (see
+// container/heap).
+//
+type mergeHeap []*mergeStream
+
+func (h mergeHeap) Len() int {
+	return len(h)
+}
+
+func (h mergeHeap) Less(i, j int) bool {
+	if keyLess(h[i].less, h[i].current.Key, h[j].current.Key) {
+		return true
+	}
+
+	if keyLess(h[i].less, h[j].current.Key, h[i].current.Key) {
+		return false
+	}
+
+	return h[i].index < h[j].index
+}
+
+func (h mergeHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *mergeHeap) Push(x any) {
+	*h = append(*h, x.(*mergeStream))
+}
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n   := len(old)
+	x   := old[n-1]
+	*h   = old[:n-1]
+	return x
+}
@@ -0,0 +1,229 @@
+//
+// Merge_test.go
+//
+// This file contains tests for merging sorted KeyValue streams.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// sortedStreams
+//
+// Builds readers over streams of JSON-encoded KeyValue pairs.
+//
+//      streams - the contents of the streams
+//
+// Returns the readers.
+//
+func sortedStreams(streams ...string) []io.Reader {
+	var readers []io.Reader = nil
+
+	for _, stream := range streams {
+		readers = append(readers, strings.NewReader(stream))
+	}
+
+	return readers
+}
+
+//
+// TestMergeSortedKeyValues
+//
+// Merges several sorted streams, in the stream and array formats and with an empty stream,
+// whose keys are duplicated within and across streams, in byte order and in a custom order,
+// and checks that the merge is sorted, and that KeyValues with equal keys keep the order of
+// their streams.
+//
+func TestMergeSortedKeyValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		streams  []string
+		less     func(a, b string) bool
+		expected []KeyValue
+	}{
+		{
+			"byte order",
+			[]string{
+				`{"Key":"a","Value":"0"}{"Key":"b","Value":"0"}{"Key":"b","Value":"1"}{"Key":"d","Value":"0"}`,
+				`[{"Key":"b","Value":"2"},{"Key":"c","Value":"0"},{"Key":"d","Value":"1"}]`,
+				``,
+				`{"Key":"a","Value":"1"}` + "\n" + `{"Key":"d","Value":"2"}` + "\n",
+			},
+			nil,
+			[]KeyValue{{"a", "0"}, {"a", "1"}, {"b", "0"}, {"b", "1"}, {"b", "2"}, {"c", "0"}, {"d", "0"}, {"d", "1"}, {"d", "2"}},
+		},
+		{
+			"descending",
+			[]string{
+				`{"Key":"c","Value":"0"}{"Key":"a","Value":"0"}`,
+				`{"Key":"c","Value":"1"}{"Key":"b","Value":"0"}{"Key":"a","Value":"1"}`,
+			},
+			func(a, b string) bool { return a > b },
+			[]KeyValue{{"c", "0"}, {"c", "1"}, {"b", "0"}, {"a", "0"}, {"a", "1"}},
+		},
+	}
+
+	for _, test := range tests {
+		var merged []KeyValue = nil
+
+		for key, value := range MergeSortedKeyValues(sortedStreams(test.streams...), test.less) {
+			merged = append(merged, KeyValue{key, value})
+		}
+
+		if !slices.Equal(merged, test.expected) {
+			t.Fatalf("%s: merged %v, expected %v", test.name, merged, test.expected)
+		}
+	}
+}
+
+//
+// TestMergeSortedKeyValuesBreak
+//
+// Checks that iterating a merge can stop early.
+//
+func TestMergeSortedKeyValuesBreak(t *testing.T) {
+	readers := sortedStreams(`{"Key":"a","Value":"0"}{"Key":"c","Value":"0"}`, `{"Key":"b","Value":"0"}`)
+
+	var keys []string = nil
+
+	for key := range MergeSortedKeyValues(readers, nil) {
+		keys = append(keys, key)
+
+		if key == "b" {
+			break
+		}
+	}
+
+	if expected := []string{"a", "b"}; !slices.Equal(keys, expected) {
+		t.Fatalf("merged keys %v, expected %v", keys, expected)
+	}
+}
+
+//
+// TestMergeSortedKeyValuesErr
+//
+// Checks that a merge ends with an error, after the KeyValues before it, if a stream is not
+// sorted or fails to decode, and that MergeSortedKeyValues ends at the same point.
+//
+func TestMergeSortedKeyValuesErr(t *testing.T) {
+	for _, streams := range [][]string{
+		{`{"Key":"a","Value":"0"}{"Key":"c","Value":"0"}{"Key":"b","Value":"0"}`, `{"Key":"b","Value":"1"}`},
+		{`{"Key":"a","Value":"0"}{"Key":"c",`, `{"Key":"b","Value":"1"}`},
+	} {
+		var keys []string = nil
+		var err  error    = nil
+
+		for kv, tempErr := range MergeSortedKeyValuesErr(sortedStreams(streams...), nil) {
+			if tempErr != nil {
+				err = tempErr
+				break
+			}
+
+			keys = append(keys, kv.Key)
+		}
+
+		if err == nil {
+			t.Fatalf("streams %q merged without error", streams)
+		}
+
+		var quiet []string = nil
+
+		for key := range MergeSortedKeyValues(sortedStreams(streams...), nil) {
+			quiet = append(quiet, key)
+		}
+
+		if !slices.Equal(quiet, keys) {
+			t.Fatalf("streams %q: MergeSortedKeyValues merged %v, expected %v", streams, quiet, keys)
+		}
+	}
+}
+
+//
+// TestDedupFinalMerge
+//
+// Merges sorted merge files holding KeyValues duplicated across partitions, as written under
+// a faulty Partitioner, with and without DedupFinalMerge, and checks that deduplicating
+// drops exactly the repeated KeyValues, keeps KeyValues that only share a key, and logs the
+// number dropped.
+//
+func TestDedupFinalMerge(t *testing.T) {
+	files := [][]KeyValue{
+		{{"a", "1"}, {"b", "1"}, {"c", "1"}},
+		{{"a", "1"}, {"b", "2"}, {"d", "1"}},
+		{{"a", "1"}, {"b", "1"}, {"c", "1"}, {"e", "1"}},
+	}
+
+	all := slices.Concat(files...)
+	sortKeyValuesCanonical(all)
+
+	tests := []struct {
+		dedup    bool
+		expected []KeyValue
+		logged   string
+	}{
+		{false, all, ""},
+		{true, []KeyValue{{"a", "1"}, {"b", "1"}, {"b", "2"}, {"c", "1"}, {"d", "1"}, {"e", "1"}}, "dropped 4 duplicate KeyValues"},
+	}
+
+	for _, test := range tests {
+		var logs bytes.Buffer
+
+		w := newTestWorker(t, "dedup")
+		w.DedupFinalMerge = test.dedup
+		w.Logger          = log.New(&logs, "", 0)
+
+		writeMergeFiles(t, w, files)
+
+		var merged bytes.Buffer
+
+		if err := w.FinalMerge(len(files), &merged); err != nil {
+			t.Fatal(err)
+		}
+
+		var output []KeyValue = nil
+
+		err := decodeKeyValues(&merged, func(kv KeyValue) error {
+			output = append(output, kv)
+			return nil
+		})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		sortKeyValuesCanonical(output)
+
+		if !slices.Equal(output, test.expected) {
+			t.Fatalf("dedup %v: merged %v, expected %v", test.dedup, output, test.expected)
+		}
+
+		if !strings.Contains(logs.String(), test.logged) || (test.logged == "" && logs.Len() > 0) {
+			t.Fatalf("dedup %v: logged %q, expected %q", test.dedup, logs.String(), test.logged)
+		}
+	}
+}
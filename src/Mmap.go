@@ -0,0 +1,59 @@
+//go:build unix
+
+//
+// Mmap.go
+//
+// This file contains the memory mapping of intermediate files read by 'reduce' workers on
+// platforms that support mmap (see Worker.MmapIntermediate); otherwise, see MmapOff.go.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"os"
+	"syscall"
+)
+
+//
+// mmapFile
+//
+// Maps the contents of a file into memory, read-only.
+//
+//      file - the file to be mapped
+//      size - the size of the file
+//
+// Returns the mapped contents, and any error mapping them.
+//
+func mmapFile(file *os.File, size int64) ([]byte, error) {
+	return syscall.Mmap(int(file.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+//
+// munmapFile
+//
+// Unmaps the contents of a file mapped by mmapFile.
+//
+//      data - the mapped contents
+//
+// Returns any error unmapping them.
+//
+func munmapFile(data []byte) error {
+	return syscall.Munmap(data)
+}
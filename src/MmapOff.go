@@ -0,0 +1,59 @@
+//go:build !unix
+
+//
+// MmapOff.go
+//
+// This file contains the memory mapping of intermediate files on platforms that do not
+// support mmap, which always fails so that files are read normally (see Mmap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"os"
+)
+
+//
+// mmapFile
+//
+// Maps the contents of a file into memory. Without mmap support, there is no mapping.
+//
+//      file - the file to be mapped
+//      size - the size of the file
+//
+// Returns an error.
+//
+func mmapFile(file *os.File, size int64) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+//
+// munmapFile
+//
+// Unmaps the contents of a file. Without mmap support, there is nothing to unmap.
+//
+//      data - the mapped contents
+//
+// Returns nil.
+//
+func munmapFile(data []byte) error {
+	return nil
+}
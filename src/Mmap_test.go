@@ -0,0 +1,145 @@
+//go:build unix
+
+//
+// Mmap_test.go
+//
+// This file contains tests for memory mapping intermediate files, on platforms that support
+// mmap.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+//
+// largeInput
+//
+// Builds an input of distinct words whose intermediate files are large enough to be memory
+// mapped, for any small nReduce. The words are letters only (each is its number in base 26,
+// with a-z as digits), so that WordCountMap and countWords split them alike.
+//
+// Returns the input.
+//
+func largeInput() string {
+	var input strings.Builder
+
+	for i := 0; i < 50000; i++ {
+		input.WriteString("word")
+
+		for n := i; ; n /= 26 {
+			input.WriteByte(byte('a' + n%26))
+
+			if n < 26 {
+				break
+			}
+		}
+
+		input.WriteByte(' ')
+	}
+
+	return input.String()
+}
+
+//
+// TestOpenMappedFile
+//
+// Checks that a large intermediate file is mapped, with the same contents as when read,
+// while a small or missing one, or one in a storage backend other than the filesystem, is
+// left to be read normally.
+//
+func TestOpenMappedFile(t *testing.T) {
+	w := newTestWorker(t, "mmap")
+
+	for _, test := range []struct {
+		name    string
+		input   string
+		storage Storage
+		mapped  bool
+	}{
+		{"large", largeInput(), nil, true},
+		{"small", testInput, nil, false},
+		{"memory", largeInput(), NewMemoryStorage(), false},
+	} {
+		w.Storage = test.storage
+
+		inFile := writeInput(t, w, "input-"+test.name, test.input)
+
+		if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		fileName := w.reduceName(0, 0)
+
+		mapped, reader, err := openMappedFile(w.storage(), fileName)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if (mapped != nil) != test.mapped {
+			t.Fatalf("%s: mapped %v, expected %v", test.name, mapped != nil, test.mapped)
+		}
+
+		if mapped != nil {
+			contents, err := io.ReadAll(reader)
+
+			if err == nil {
+				err = mapped.Close()
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(contents) != readFile(t, w, fileName) {
+				t.Fatalf("%s: mapped contents differ from the file's", test.name)
+			}
+		}
+	}
+
+	if mapped, _, err := openMappedFile(w.storage(), w.reduceName(5, 0)); mapped != nil || err != nil {
+		t.Fatalf("missing file: mapped %v, error %v", mapped != nil, err)
+	}
+}
+
+//
+// TestMmapIntermediate
+//
+// Runs a word count job over large and small inputs, plain and gzipped, with and without
+// MmapIntermediate, and checks that every run gives the same output.
+//
+func TestMmapIntermediate(t *testing.T) {
+	inputs   := []string{largeInput(), testInput}
+	expected := countWords(inputs...)
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		for _, mmap := range []bool{false, true} {
+			w := newTestWorker(t, "mmap")
+			w.Compression      = compression
+			w.MmapIntermediate = mmap
+
+			checkOutput(t, runWordCount(t, w, inputs, 2), expected)
+		}
+	}
+}
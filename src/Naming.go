@@ -0,0 +1,165 @@
+//
+// Naming.go
+//
+// This file contains functionality for naming the intermediate and merge files written by
+// 'map' and 'reduce' workers (see DoMap.go and DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+//
+// NamingScheme
+//
+// Describes how the names of intermediate (map output) and merge (reduce output) files are
+// built. The Map and Reduce tasks of a job must use the same scheme (see Worker.Naming) so
+// that the names written by a Map task match the names read by a Reduce task.
+//
+// The zero value is the default scheme, which uses reduceName and mergeName unchanged. Any
+// other value builds names of the form:
+//
+//      intermediate: <Prefix><jobName><Separator><mapTask><Separator><reduceTask><Extension>
+//      combined:     <Prefix><jobName><Separator><mapTask><Extension>
+//      shuffled:     <Prefix><jobName><Separator>shuffle<Separator><reduceTask><Extension>
+//      merge:        <Prefix><jobName><Separator>res<Separator><reduceTask><Extension>
+//
+// An empty Separator in a non-default scheme is treated as "-".
+//
+type NamingScheme struct {
+	Prefix    string
+	Separator string
+	Extension string
+}
+
+//
+// ReduceName
+//
+// Builds the name of the intermediate file written by a Map task for a Reduce task.
+//
+//      jobName          - the name of the MapReduce job
+//      mapTaskNumber    - the number of the Map task that writes the file
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//
+// Returns the intermediate file name.
+//
+func (n NamingScheme) ReduceName(jobName string, mapTaskNumber int, reduceTaskNumber int) string {
+	if n == (NamingScheme{}) {
+		return reduceName(jobName, mapTaskNumber, reduceTaskNumber)
+	}
+
+	sep := n.separator()
+
+	return n.Prefix + jobName + sep + strconv.Itoa(mapTaskNumber) + sep + strconv.Itoa(reduceTaskNumber) + n.Extension
+}
+
+//
+// MergeName
+//
+// Builds the name of the merge file written by a Reduce task.
+//
+//      jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the number of the Reduce task that writes the file
+//
+// Returns the merge file name.
+//
+func (n NamingScheme) MergeName(jobName string, reduceTaskNumber int) string {
+	if n == (NamingScheme{}) {
+		return mergeName(jobName, reduceTaskNumber)
+	}
+
+	sep := n.separator()
+
+	return n.Prefix + jobName + sep + "res" + sep + strconv.Itoa(reduceTaskNumber) + n.Extension
+}
+
+//
+// CombinedName
+//
+// Builds the name of the combined intermediate file written by a Map task (see
+// Worker.CombinedLayout). Its index is named the same, followed by ".index".
+//
+//      jobName       - the name of the MapReduce job
+//      mapTaskNumber - the number of the Map task that writes the file
+//
+// Returns the combined file name.
+//
+func (n NamingScheme) CombinedName(jobName string, mapTaskNumber int) string {
+	if n == (NamingScheme{}) {
+		return "mrtmp." + jobName + "-" + strconv.Itoa(mapTaskNumber)
+	}
+
+	return n.Prefix + jobName + n.separator() + strconv.Itoa(mapTaskNumber) + n.Extension
+}
+
+//
+// ShuffleName
+//
+// Builds the name of the pre-shuffled file holding every KeyValue of a Reduce task's
+// partition, written outside the job (see Worker.PreShuffled).
+//
+//      jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//
+// Returns the pre-shuffled file name.
+//
+func (n NamingScheme) ShuffleName(jobName string, reduceTaskNumber int) string {
+	if n == (NamingScheme{}) {
+		return "mrtmp." + jobName + "-shuffle-" + strconv.Itoa(reduceTaskNumber)
+	}
+
+	sep := n.separator()
+
+	return n.Prefix + jobName + sep + "shuffle" + sep + strconv.Itoa(reduceTaskNumber) + n.Extension
+}
+
+//
+// separator
+//
+// Returns the separator of a non-default scheme, falling back to "-" when it is empty.
+//
+func (n NamingScheme) separator() string {
+	if n.Separator == "" {
+		return "-"
+	}
+
+	return n.Separator
+}
+
+//
+// NewRunID
+//
+// Generates an identifier for one run of a job (see Worker.RunID), unique across runs: the
+// start time, followed by random bytes in case two runs start at the same time.
+//
+// Returns the run identifier.
+//
+func NewRunID() string {
+	random := make([]byte, 4)
+
+	rand.Read(random)
+
+	return strconv.FormatInt(time.Now().UnixNano(), 36) + hex.EncodeToString(random)
+}
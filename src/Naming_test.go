@@ -0,0 +1,87 @@
+//
+// Naming_test.go
+//
+// This file contains tests for the naming of intermediate and merge files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"path/filepath"
+	"testing"
+)
+
+//
+// TestRunIDIsolatesRuns
+//
+// Checks that two runs of a job with different run identifiers, sharing an output directory,
+// each reduce their own intermediate files, and that without a run identifier the names
+// stay deterministic.
+//
+func TestRunIDIsolatesRuns(t *testing.T) {
+	w := newTestWorker(t, "runid")
+
+	if name := filepath.Base(w.reduceName(0, 1)); name != "mrtmp.runid-0-1" {
+		t.Fatalf("intermediate file %s without a run ID, expected mrtmp.runid-0-1", name)
+	}
+
+	first  := *w
+	second := *w
+
+	first.RunID  = NewRunID()
+	second.RunID = NewRunID()
+
+	if first.reduceName(0, 0) == second.reduceName(0, 0) {
+		t.Fatalf("runs %s and %s share intermediate file %s", first.RunID, second.RunID, first.reduceName(0, 0))
+	}
+
+	// Both runs map before either reduces, as overlapping runs would
+	firstInput  := writeInput(t, w, "first", "a a b")
+	secondInput := writeInput(t, w, "second", "c")
+
+	if _, err := first.Map(0, firstInput, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := second.Map(0, secondInput, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, run := range []struct {
+		worker   *Worker
+		expected map[string]string
+	}{
+		{&first, countWords("a a b")},
+		{&second, countWords("c")},
+	} {
+		if _, err := run.worker.Reduce(0, 1, WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+
+		keyValues := readKeyValueFile(t, run.worker, run.worker.mergeName(0))
+		output    := make(map[string]string)
+
+		for _, kv := range keyValues {
+			output[kv.Key] = kv.Value
+		}
+
+		checkOutput(t, output, run.expected)
+	}
+}
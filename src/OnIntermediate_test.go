@@ -0,0 +1,85 @@
+//
+// OnIntermediate_test.go
+//
+// This file contains tests for the hook observing Reduce's intermediate KeyValues.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestOnIntermediate
+//
+// Checks that OnIntermediate observes every KeyValue of a Reduce task's intermediate files
+// exactly once, whether the partitions are grouped in a map or merged in sorted order, and
+// when grouping, including those its key range then skips.
+//
+func TestOnIntermediate(t *testing.T) {
+	inputs := []string{testInput, "the fox and the hen", "end"}
+
+	compare := func(a, b KeyValue) int {
+		return strings.Compare(a.Key+"\x00"+a.Value, b.Key+"\x00"+b.Value)
+	}
+
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "observe")
+		w.SortPartitions   = sorted
+		w.PartitionsSorted = sorted
+
+		var expected []KeyValue = nil
+		var observed []KeyValue = nil
+
+		for m, input := range inputs {
+			inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+			if _, err := w.Map(m, inFile, 2, wordOnesMap); err != nil {
+				t.Fatal(err)
+			}
+
+			expected = append(expected, readKeyValueFile(t, w, w.reduceName(m, 1))...)
+		}
+
+		w.OnIntermediate = func(kv KeyValue) {
+			observed = append(observed, kv)
+		}
+
+		if !sorted {
+			// A sorted merge stops reading past the end of the range
+			w.KeyRange = &KeyRange{"", "f"}
+		}
+
+		if _, err := w.Reduce(1, len(inputs), WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+
+		slices.SortFunc(expected, compare)
+		slices.SortFunc(observed, compare)
+
+		if !slices.Equal(observed, expected) {
+			t.Errorf("sorted %v: observed %v, expected %v", sorted, observed, expected)
+		}
+	}
+}
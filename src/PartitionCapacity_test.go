@@ -0,0 +1,120 @@
+//
+// PartitionCapacity_test.go
+//
+// This file contains tests and benchmarks for preallocating Map partitions.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+	"testing"
+)
+
+//
+// capacityInput
+//
+// Builds the input of the preallocation tests: words numbered in letters (as WordCountMap
+// splits words at digits), so that every word is distinct.
+//
+//      n - the number of words
+//
+// Returns the input.
+//
+func capacityInput(n int) string {
+	var builder strings.Builder
+
+	for i := 0; i < n; i++ {
+		builder.WriteString("word")
+
+		for n := i; ; n /= 26 {
+			builder.WriteByte(byte('a' + n%26))
+
+			if n < 26 {
+				break
+			}
+		}
+
+		builder.WriteByte(' ')
+	}
+
+	return builder.String()
+}
+
+//
+// TestPartitionCapacity
+//
+// Runs a word count job with partitions preallocated below, at and above their estimate,
+// and checks that the output is the same as without preallocation.
+//
+func TestPartitionCapacity(t *testing.T) {
+	input    := capacityInput(1000)
+	expected := countWords(input)
+
+	for _, capacity := range []int{0, 1, EstimatePartitionCapacity(int64(len(input)), 4, 8), 10000} {
+		w := newTestWorker(t, "capacity")
+		w.PartitionCapacity = capacity
+
+		output := runWordCount(t, w, []string{input}, 4)
+
+		if len(output) != len(expected) {
+			t.Fatalf("capacity %d: %d words counted, expected %d", capacity, len(output), len(expected))
+		}
+
+		checkOutput(t, output, expected)
+	}
+}
+
+//
+// BenchmarkPartitionCapacity
+//
+// Compares a Map task whose partitions grow as needed with one whose partitions are
+// preallocated from an estimate of its output (see EstimatePartitionCapacity). Run with
+// -benchmem: preallocating saves the reallocations, and the copying, of growing each
+// partition.
+//
+func BenchmarkPartitionCapacity(b *testing.B) {
+	const nReduce = 8
+
+	input := capacityInput(200000)
+
+	for _, bench := range []struct {
+		name     string
+		capacity int
+	}{
+		{"grow",     0},
+		{"prealloc", EstimatePartitionCapacity(int64(len(input)), nReduce, 8)},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			w := &Worker{JobName: "capacity", Storage: NewMemoryStorage(), PartitionCapacity: bench.capacity}
+
+			inFile := writeInput(b, w, "input", input)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if _, err := w.Map(0, inFile, nReduce, WordCountMap); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
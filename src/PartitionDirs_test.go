@@ -0,0 +1,90 @@
+//
+// PartitionDirs_test.go
+//
+// This file contains tests for keeping intermediate files in a directory per partition.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// TestPartitionDirs
+//
+// Runs a word count job with PartitionDirs, and checks that each Reduce task's intermediate
+// files land in its own subdirectory, r<reduceTaskNumber>, that Reduce reads them from there,
+// and that the job's cleanup removes the subdirectories.
+//
+func TestPartitionDirs(t *testing.T) {
+	w := newTestWorker(t, "dirs")
+	w.PartitionDirs = true
+
+	inputs := []string{testInput, "a fox and a dog", "the end"}
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := w.Map(m, inFile, 3, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for r := 0; r < 3; r++ {
+		dir := filepath.Join(w.OutDir, "r"+strconv.Itoa(r))
+
+		var expected []string = nil
+
+		for m := range inputs {
+			if filepath.Dir(w.reduceName(m, r)) != dir {
+				t.Fatalf("intermediate file %s is not in %s", w.reduceName(m, r), dir)
+			}
+
+			expected = append(expected, filepath.Base(w.reduceName(m, r)))
+		}
+
+		slices.Sort(expected)
+
+		if names := listDir(t, dir); !slices.Equal(names, expected) {
+			t.Fatalf("%s holds %v, expected %v", dir, names, expected)
+		}
+
+		if _, err := w.Reduce(r, len(inputs), WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkOutput(t, readOutput(t, w, 3), countWords(inputs...))
+
+	if err := w.CleanupJob(len(inputs), 3); err != nil {
+		t.Fatal(err)
+	}
+
+	for r := 0; r < 3; r++ {
+		if _, err := os.Stat(filepath.Join(w.OutDir, "r"+strconv.Itoa(r))); !os.IsNotExist(err) {
+			t.Errorf("partition directory %d not removed: %v", r, err)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+//
+// PartitionKey_test.go
+//
+// This file contains tests for partitioning by a key derived from each KeyValue.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+	"testing"
+)
+
+//
+// TestPartitionKey
+//
+// Partitions "<user>|<date>" keys by user, and checks that every key of a user lands in the
+// same partition, written in full, and that Reduce still groups by the full key.
+//
+func TestPartitionKey(t *testing.T) {
+	w := newTestWorker(t, "partkey")
+	w.PartitionKey = func(kv KeyValue) string {
+		user, _, _ := strings.Cut(kv.Key, "|")
+		return user
+	}
+
+	var keyValues []KeyValue = nil
+
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		for _, date := range []string{"2023-01-01", "2023-01-02", "2023-02-14", "2023-03-30"} {
+			keyValues = append(keyValues, KeyValue{user + "|" + date, "1"})
+		}
+	}
+
+	runJob(t, w, 8, emitMap(keyValues...), WordCountReduce)
+
+	partitions := make(map[string]int)
+	written    := make(map[string]bool)
+
+	for r := 0; r < 8; r++ {
+		for _, kv := range readKeyValueFile(t, w, w.reduceName(0, r)) {
+			user, _, _ := strings.Cut(kv.Key, "|")
+
+			if p, exists := partitions[user]; exists && p != r {
+				t.Fatalf("keys of %s in partitions %d and %d", user, p, r)
+			}
+
+			partitions[user] = r
+			written[kv.Key]  = true
+		}
+	}
+
+	if len(written) != len(keyValues) {
+		t.Fatalf("wrote %d distinct keys, expected %d", len(written), len(keyValues))
+	}
+
+	expected := make(map[string]string)
+
+	for _, kv := range keyValues {
+		expected[kv.Key] = "1"
+	}
+
+	checkOutput(t, readOutput(t, w, 8), expected)
+}
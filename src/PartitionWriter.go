@@ -0,0 +1,354 @@
+//
+// PartitionWriter.go
+//
+// This file contains functionality for writing the intermediate files of a 'map' worker,
+// one per Reduce partition (see DoMap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"path/filepath"
+)
+
+//
+// intermediateWriter
+//
+// Writes the partitions of a Map task in one of the intermediate layouts: a file per
+// partition (see partitionWriter), or one combined file (see combinedWriter).
+//
+//      write  - encodes KeyValue pairs to a partition
+//      close  - completes the files, once every partition has been written
+//      remove - removes everything created, after a failure
+//
+type intermediateWriter interface {
+	write(partition int, keyValues []KeyValue) error
+	close() error
+	remove() error
+}
+
+//
+// partitionWriter
+//
+// Holds the open intermediate files of a Map task, each written through a buffered,
+// optionally compressing, JSON encoder. Files are left open until every partition has been
+// written, so that a failed task can remove them. If durable, they are synced to disk before
+// they are closed (see Worker.Durable).
+//
+// When rolling over (see Worker.SegmentSize), each partition is written to numbered segment
+// files instead (see segmentName): once a segment has grown past maxBytes, it is completed
+// and closed, and the partition's next KeyValue starts the next segment.
+//
+type partitionWriter struct {
+	storage     Storage
+	files       []io.WriteCloser
+	fileNames   []string
+	writers     []*bufio.Writer
+	counters    []*countingWriter
+	compressors []io.WriteCloser
+	encoders    []*json.Encoder
+	segments    []int
+	created     []string
+	durable     bool
+	maxBytes    int64
+	bufferSize  int
+	compression Compression
+	escapeHTML  bool
+	waitCreate  func()
+}
+
+//
+// createPartitionFiles
+//
+// Creates the intermediate files of a Map task (or their first segments), replacing any that
+// already exist.
+//
+//      mapTaskNumber - the unique number assigned to this Map task
+//      nReduce       - the number of Reduce tasks that will be run
+//
+// Returns the writer of the files, and nil on success. Otherwise, the error that failed;
+// the writer is still returned, holding the files created so far, so that the caller can
+// remove them.
+//
+func (w *Worker) createPartitionFiles(mapTaskNumber int, nReduce int) (*partitionWriter, error) {
+	var err error = nil
+
+	out := &partitionWriter{
+		storage:     w.storage(),
+		files:       make([]io.WriteCloser, nReduce),
+		fileNames:   make([]string, nReduce),
+		writers:     make([]*bufio.Writer, nReduce),
+		counters:    make([]*countingWriter, nReduce),
+		compressors: make([]io.WriteCloser, nReduce),
+		encoders:    make([]*json.Encoder, nReduce),
+		segments:    make([]int, nReduce),
+		durable:     w.Durable,
+		maxBytes:    w.SegmentSize,
+		bufferSize:  w.WriteBufferSize,
+		compression: w.Compression,
+		escapeHTML:  !w.DisableHTMLEscape,
+		waitCreate:  w.waitCreate,
+	}
+
+	if out.bufferSize <= 0 {
+		out.bufferSize = defaultWriteBufferSize
+	}
+
+	for i := 0; i < nReduce; i++ {
+		fileName := w.reduceName(mapTaskNumber, i)
+
+		//
+		// Remove file (and any segments) if it already exists:
+		// *NOTE* Currently not treating this as an error
+		//
+		tempErr := w.removeIntermediateFile(fileName)
+
+		if tempErr != nil {
+			// Error removing file
+			err = stageError(ErrWriteIntermediate, tempErr)
+			break
+		}
+
+		//
+		// Create new file (and its partition directory, if needed), to be written through a
+		// buffered JSON encoder:
+		//
+		if w.PartitionDirs {
+			tempErr = makeDir(out.storage, filepath.Dir(fileName))
+
+			if tempErr != nil {
+				// Error creating directory
+				err = stageError(ErrWriteIntermediate, tempErr)
+				break
+			}
+		}
+
+		out.fileNames[i] = fileName
+
+		if out.maxBytes > 0 {
+			fileName = segmentName(fileName, 0)
+		}
+
+		tempErr = out.open(i, fileName)
+
+		if tempErr != nil {
+			// Error creating file
+			err = stageError(ErrWriteIntermediate, tempErr)
+			break
+		}
+	}
+
+	return out, err
+}
+
+//
+// open
+//
+// Creates a file for a partition, makes it the file the partition is written to, and writes
+// its version header (see writeFormatHeader); a later segment continues the partition's
+// stream, so has no header of its own.
+//
+//      partition - the number of the partition
+//      fileName  - the name of the file (the partition's file, or one of its segments)
+//
+// Returns nil on success. Otherwise, the error creating the file, or writing its header.
+//
+func (out *partitionWriter) open(partition int, fileName string) error {
+	out.waitCreate()
+
+	file, err := out.storage.Create(fileName)
+
+	if err != nil {
+		return err
+	}
+
+	out.created = append(out.created, fileName)
+
+	writer     := bufio.NewWriterSize(file, out.bufferSize)
+	compressor := compressWriter(writer, out.compression)
+	counter    := &countingWriter{writer: compressor}
+
+	out.files[partition]       = file
+	out.writers[partition]     = writer
+	out.compressors[partition] = compressor
+	out.counters[partition]    = counter
+	out.encoders[partition]    = newJSONEncoder(counter, out.escapeHTML)
+
+	if out.segments[partition] > 0 {
+		// Continuing the partition's stream, whose header is in its first segment
+		return nil
+	}
+
+	return writeFormatHeader(counter)
+}
+
+//
+// write
+//
+// Encodes KeyValue pairs to the intermediate file of a partition, rolling over to the next
+// segment whenever the current one has grown past maxBytes.
+//
+//      partition - the number of the partition
+//      keyValues - the KeyValue pairs to be encoded
+//
+// Returns nil on success. Otherwise, the error encoding a KeyValue, or rolling over.
+//
+func (out *partitionWriter) write(partition int, keyValues []KeyValue) error {
+	for _, kv := range keyValues {
+		if out.maxBytes > 0 && out.counters[partition].count >= out.maxBytes {
+			tempErr := out.rollOver(partition)
+
+			if tempErr != nil {
+				// Error completing segment, or creating the next
+				return stageError(ErrWriteIntermediate, tempErr)
+			}
+		}
+
+		tempErr := out.encoders[partition].Encode(&kv)
+
+		if tempErr != nil {
+			// Error encoding KeyValue
+			return encodeError(tempErr)
+		}
+	}
+
+	return nil
+}
+
+//
+// rollOver
+//
+// Completes the current segment of a partition, and starts its next segment.
+// *NOTE* Segments are measured by the bytes encoded into them, before compression, as a
+//        compressor buffers its output; a compressed segment is smaller than maxBytes
+//
+//      partition - the number of the partition
+//
+// Returns nil on success. Otherwise, the error completing or creating a segment.
+//
+func (out *partitionWriter) rollOver(partition int) error {
+	err := out.closeFile(partition)
+
+	if err == nil {
+		out.segments[partition]++
+
+		err = out.open(partition, segmentName(out.fileNames[partition], out.segments[partition]))
+	}
+
+	return err
+}
+
+//
+// closeFile
+//
+// Completes the current file of a partition: closes its compressor, flushes its buffer,
+// syncs it to disk if durable, and closes it. The file is closed even if an earlier step
+// fails.
+//
+//      partition - the number of the partition
+//
+// Returns nil on success. Otherwise, the first error completing the file.
+//
+func (out *partitionWriter) closeFile(partition int) error {
+	err := out.compressors[partition].Close()
+
+	if err == nil {
+		err = out.writers[partition].Flush()
+	}
+
+	if err == nil && out.durable {
+		err = syncFile(out.files[partition])
+	}
+
+	// The file is closed even if completing it failed, so its handle is not leaked
+	tempErr := out.files[partition].Close()
+
+	if tempErr != nil && err == nil {
+		err = tempErr
+	}
+
+	out.files[partition] = nil
+
+	return err
+}
+
+//
+// close
+//
+// Flushes and closes every intermediate file, once every partition has been written, syncing
+// each to disk first if durable.
+//
+// Returns nil on success. Otherwise, the first error flushing or closing a file.
+//
+func (out *partitionWriter) close() error {
+	var err error = nil
+
+	for i := 0; i < len(out.files); i++ {
+		if out.files[i] == nil {
+			// Never created
+			continue
+		}
+
+		tempErr := out.closeFile(i)
+
+		if tempErr != nil && err == nil {
+			// Error writing or closing file
+			err = stageError(ErrWriteIntermediate, tempErr)
+		}
+	}
+
+	return err
+}
+
+//
+// remove
+//
+// Closes and removes every intermediate file (and segment) created, after a failure. Every
+// file is tried, even if removing an earlier one fails.
+// *NOTE* Removing by the name the file was created with, as the name from its FileInfo has
+//        no directory
+//
+// Returns nil on success. Otherwise, the errors removing files, joined.
+//
+func (out *partitionWriter) remove() error {
+	var errs []error = nil
+
+	for _, file := range out.files {
+		if file != nil {
+			file.Close()
+		}
+	}
+
+	for _, fileName := range out.created {
+		tempErr := out.storage.Remove(fileName)
+
+		if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+			// Error removing file
+			errs = append(errs, tempErr)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,199 @@
+//
+// Pipeline.go
+//
+// This file contains functionality for a 'reduce' worker that decodes its sorted
+// intermediate files on a goroutine of their own, overlapping reading with reducing (see
+// Worker.PipelineReads).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// Pipeline sizing for pipelinedMerger
+//
+const (
+	pipelineBatchSize = 256 // the KeyValues decoded into each batch
+	pipelineDepth     = 4   // the batches decoded ahead of the Reduce function
+)
+
+//
+// mergeSource
+//
+// A stream of KeyValue pairs in key order, consumed one at a time by the streaming Reduce
+// (see mergeAndReduce): a sortedMerger, or a pipelinedMerger decoding ahead of it.
+//
+//      peek    - returns the next KeyValue, and false once the stream is exhausted
+//      advance - consumes the KeyValue returned by peek, returning any error decoding
+//
+type mergeSource interface {
+	peek() (KeyValue, bool)
+	advance() error
+}
+
+//
+// pipelineBatch
+//
+// A batch of KeyValues decoded by a pipelinedMerger, with the error that ended decoding
+// after its last KeyValue, if any.
+//
+type pipelineBatch struct {
+	keyValues []KeyValue
+	err       error
+}
+
+//
+// pipelinedMerger
+//
+// Decodes a sortedMerger on a goroutine of its own, in batches of pipelineBatchSize
+// KeyValues, up to pipelineDepth batches ahead of the Reduce function consuming them. While
+// the Reduce function works on one batch, the next are read and decoded, so a CPU-bound
+// Reduce over slow storage takes about the longer of the two, rather than their sum. The
+// order of the KeyValues, and so the grouping of their keys, is that of the merger.
+//
+// The merger must not be used by anything else until the pipeline is stopped (see stop).
+//
+type pipelinedMerger struct {
+	batches  chan pipelineBatch
+	done     chan struct{}
+	finished chan struct{}
+	batch    pipelineBatch
+	position int
+}
+
+//
+// newPipelinedMerger
+//
+// Starts decoding a merger ahead of its consumer, and waits for the first batch.
+//
+//      merger - the merger to be decoded
+//
+// Returns the pipeline, which the caller must stop.
+//
+func newPipelinedMerger(merger *sortedMerger) *pipelinedMerger {
+	p := &pipelinedMerger{
+		batches:  make(chan pipelineBatch, pipelineDepth),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(p.finished)
+		defer close(p.batches)
+
+		for {
+			var batch pipelineBatch = pipelineBatch{keyValues: make([]KeyValue, 0, pipelineBatchSize)}
+			var more  bool          = true
+
+			for len(batch.keyValues) < pipelineBatchSize && batch.err == nil {
+				var kv KeyValue
+
+				kv, more = merger.peek()
+
+				if !more {
+					break
+				}
+
+				batch.keyValues = append(batch.keyValues, kv)
+				batch.err       = merger.advance()
+			}
+
+			if len(batch.keyValues) > 0 {
+				select {
+				case p.batches <- batch:
+				case <-p.done:
+					// Stopped by the consumer
+					return
+				}
+			}
+
+			if !more || batch.err != nil {
+				// Merger exhausted, or failed
+				return
+			}
+		}
+	}()
+
+	p.receive()
+
+	return p
+}
+
+//
+// receive
+//
+// Waits for the next batch, which is empty once the merger is exhausted.
+//
+func (p *pipelinedMerger) receive() {
+	p.batch    = <-p.batches
+	p.position = 0
+}
+
+//
+// peek
+//
+// Returns the next KeyValue, and false once the merger is exhausted.
+//
+func (p *pipelinedMerger) peek() (KeyValue, bool) {
+	if p.position >= len(p.batch.keyValues) {
+		return KeyValue{}, false
+	}
+
+	return p.batch.keyValues[p.position], true
+}
+
+//
+// advance
+//
+// Consumes the KeyValue returned by peek, waiting for the next batch at the end of this one.
+//
+// Returns the error that ended decoding after the consumed KeyValue, if any.
+//
+func (p *pipelinedMerger) advance() error {
+	p.position++
+
+	if p.position < len(p.batch.keyValues) {
+		return nil
+	}
+
+	if p.batch.err != nil {
+		// Error decoding the KeyValue after this one
+		err := p.batch.err
+
+		p.batch = pipelineBatch{}
+		return err
+	}
+
+	p.receive()
+
+	return nil
+}
+
+//
+// stop
+//
+// Stops decoding, and waits for the decoding goroutine to exit, so that the merger and its
+// files may be used (or closed) again.
+//
+func (p *pipelinedMerger) stop() {
+	close(p.done)
+
+	<-p.finished
+}
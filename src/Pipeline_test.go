@@ -0,0 +1,122 @@
+//
+// Pipeline_test.go
+//
+// This file contains tests for decoding sorted intermediate files ahead of reducing.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestPipelineReads
+//
+// Runs a word count job over sorted partitions, spanning many pipeline batches, with and
+// without pipelined reads, and checks that both write byte-identical merge files.
+//
+func TestPipelineReads(t *testing.T) {
+	inputs := []string{capacityInput(3000), capacityInput(5000), capacityInput(100)}
+
+	var merges [2][]string
+
+	for i, pipeline := range []bool{false, true} {
+		w := newTestWorker(t, "pipeline")
+		w.SortPartitions   = true
+		w.PartitionsSorted = true
+		w.PipelineReads    = pipeline
+
+		checkOutput(t, runWordCount(t, w, inputs, 3), countWords(inputs...))
+
+		for r := 0; r < 3; r++ {
+			merges[i] = append(merges[i], readFile(t, w, w.mergeName(r)))
+		}
+	}
+
+	for r := 0; r < 3; r++ {
+		if merges[0][r] != merges[1][r] {
+			t.Fatalf("merge file %d differs with pipelined reads", r)
+		}
+	}
+}
+
+//
+// TestPipelineReadsErrors
+//
+// Checks that with pipelined reads, a Reduce function failing part way through the merge,
+// and an intermediate file failing to decode part way through, each fail the task, and
+// that pipelined reads over unsorted partitions are rejected.
+//
+func TestPipelineReadsErrors(t *testing.T) {
+	w := newTestWorker(t, "pipeline")
+	w.SortPartitions   = true
+	w.PartitionsSorted = true
+	w.PipelineReads    = true
+
+	inFile := writeInput(t, w, "input", capacityInput(2000))
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	var calls int = 0
+
+	_, err := w.Reduce(0, 1, func(key string, values []string) string {
+		calls++
+
+		if calls == 300 {
+			return "error"
+		}
+
+		return WordCountReduce(key, values)
+	})
+
+	if !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("failing Reduce function returned %v, expected %v", err, ErrReduceFunc)
+	}
+
+	//
+	// Corrupt a file after many batches' worth of KeyValues:
+	//
+	var contents strings.Builder
+
+	for i := 0; i < 2000; i++ {
+		contents.WriteString(`{"Key":"k` + strconv.Itoa(100000+i) + `","Value":"1"}` + "\n")
+	}
+
+	contents.WriteString(`{"Key":` + "\n" + `{"Key":"z","Value":"1"}`)
+
+	writeIntermediate(t, w, 1, 0, contents.String())
+
+	if _, err := w.Reduce(0, 2, WordCountReduce); err == nil {
+		t.Fatal("corrupt intermediate file decoded")
+	}
+
+	unsorted := newTestWorker(t, "pipeline")
+	unsorted.PipelineReads = true
+
+	if _, err := unsorted.Reduce(0, 1, WordCountReduce); err == nil {
+		t.Fatal("pipelined reads over unsorted partitions accepted")
+	}
+}
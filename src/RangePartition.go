@@ -0,0 +1,127 @@
+//
+// RangePartition.go
+//
+// This file contains functionality for partitioning keys by range rather than by hash, so
+// that the merge files of a job, concatenated in order, are globally sorted (see
+// Worker.ConcatMerge).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"iter"
+	"math/rand/v2"
+	"slices"
+	"sort"
+)
+
+//
+// RangePartitioner
+//
+// Builds a partitioner (see Worker.Partitioner) that assigns keys to partitions by range.
+// With split points s[0] < s[1] < ... < s[n-1], partition 0 holds the keys below s[0],
+// partition i holds the keys from s[i-1] (inclusive) up to s[i] (exclusive), and partition
+// n holds the keys from s[n-1] up. A key equal to a split point therefore starts the next
+// partition. A job using it must be run with nReduce = len(splitPoints)+1; with fewer
+// partitions, the keys of the missing ones are assigned to the last.
+//
+// Each partition's keys are all below the next partition's, so if every merge file is sorted
+// (see Worker.SortOutput), concatenating them in order gives a globally sorted output.
+//
+//      splitPoints - the sorted keys at which each partition after the first starts
+//
+// Returns the partitioner.
+//
+func RangePartitioner(splitPoints []string) func(key string, nReduce int) int {
+	splitPoints = slices.Clone(splitPoints)
+
+	return func(key string, nReduce int) int {
+		partition := sort.Search(len(splitPoints), func(i int) bool {
+			return key < splitPoints[i]
+		})
+
+		return min(partition, nReduce-1)
+	}
+}
+
+//
+// SplitPoints
+//
+// Computes split points for RangePartitioner that balance a sample of a job's keys across
+// partitions: the sample is sorted, and a split point is taken at every 1/nReduce of it. The
+// more representative the sample, the more balanced the partitions.
+//
+//      sample  - a sample of the job's keys (see SampleKeys)
+//      nReduce - the number of Reduce tasks that will be run
+//
+// Returns the nReduce-1 split points, or fewer if the sample has fewer distinct keys (in which
+// case nReduce should be reduced to len(splitPoints)+1, to avoid empty partitions).
+//
+func SplitPoints(sample []string, nReduce int) []string {
+	sorted := slices.Clone(sample)
+
+	slices.Sort(sorted)
+
+	var splitPoints []string = nil
+
+	for i := 1; i < nReduce && len(sorted) > 0; i++ {
+		splitPoint := sorted[i * len(sorted) / nReduce]
+
+		//
+		// Skip duplicate split points, and any at the smallest key, as both would give an
+		// empty partition:
+		//
+		if splitPoint == sorted[0] || (len(splitPoints) > 0 && splitPoint == splitPoints[len(splitPoints)-1]) {
+			continue
+		}
+
+		splitPoints = append(splitPoints, splitPoint)
+	}
+
+	return splitPoints
+}
+
+//
+// SampleKeys
+//
+// Draws a uniform random sample of keys, in one pass, by reservoir sampling, e.g. from the
+// keys a job's Map function emits for part of its input.
+//
+//      keys       - the keys to be sampled
+//      sampleSize - the maximum number of keys in the sample
+//
+// Returns the sample, which holds every key if there are at most sampleSize.
+//
+func SampleKeys(keys iter.Seq[string], sampleSize int) []string {
+	sample := make([]string, 0, sampleSize)
+	seen   := 0
+
+	for key := range keys {
+		seen++
+
+		if len(sample) < sampleSize {
+			sample = append(sample, key)
+		} else if i := rand.IntN(seen); i < sampleSize {
+			sample[i] = key
+		}
+	}
+
+	return sample
+}
@@ -0,0 +1,117 @@
+//
+// RangePartition_test.go
+//
+// This file contains tests for range partitioning.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// TestRangePartitionerBoundaries
+//
+// Checks that keys below, at, between and above the split points land in the right
+// partitions: a key equal to a split point starts the next partition.
+//
+func TestRangePartitionerBoundaries(t *testing.T) {
+	partitioner := RangePartitioner([]string{"g", "n", "t"})
+
+	for key, expected := range map[string]int{
+		"":    0,
+		"a":   0,
+		"fzz": 0,
+		"g":   1,
+		"g0":  1,
+		"mzz": 1,
+		"n":   2,
+		"szz": 2,
+		"t":   3,
+		"z":   3,
+		"zzz": 3,
+	} {
+		if partition := partitioner(key, 4); partition != expected {
+			t.Errorf("key %q in partition %d, expected %d", key, partition, expected)
+		}
+	}
+
+	// With fewer partitions than ranges, the later ranges go to the last partition
+	if partition := partitioner("z", 2); partition != 1 {
+		t.Errorf("key %q in partition %d of 2, expected 1", "z", partition)
+	}
+}
+
+//
+// TestSplitPoints
+//
+// Checks that split points computed from a sample balance it across partitions, and skip
+// split points that would leave a partition empty.
+//
+func TestSplitPoints(t *testing.T) {
+	var sample []string = nil
+
+	for i := 99; i >= 0; i-- {
+		sample = append(sample, strconv.Itoa(1000+i))
+	}
+
+	splitPoints := SplitPoints(sample, 4)
+
+	if expected := []string{"1025", "1050", "1075"}; !slices.Equal(splitPoints, expected) {
+		t.Fatalf("split points %v, expected %v", splitPoints, expected)
+	}
+
+	if counts := BucketCounts(sample, 4, RangePartitioner(splitPoints)); !slices.Equal(counts, []int{25, 25, 25, 25}) {
+		t.Fatalf("partition counts %v, expected 25 each", counts)
+	}
+
+	if splitPoints := SplitPoints([]string{"a", "a", "a", "b"}, 4); !slices.Equal(splitPoints, []string{"b"}) {
+		t.Fatalf("split points %v of a sample with 2 distinct keys, expected [b]", splitPoints)
+	}
+}
+
+//
+// TestRangePartitionedJobSorted
+//
+// Runs a range-partitioned job with sorted merge files, and checks that concatenating them in
+// partition order gives the keys in sorted order.
+//
+func TestRangePartitionedJobSorted(t *testing.T) {
+	w := newTestWorker(t, "range")
+	w.Partitioner = RangePartitioner([]string{"f", "p"})
+	w.SortOutput  = true
+
+	checkOutput(t, runWordCount(t, w, testInputs, 3), countWords(testInputs...))
+
+	var keys []string = nil
+
+	for r := 0; r < 3; r++ {
+		for _, kv := range readKeyValueFile(t, w, w.mergeName(r)) {
+			keys = append(keys, kv.Key)
+		}
+	}
+
+	if !slices.IsSorted(keys) {
+		t.Fatalf("concatenated keys %v are not sorted", keys)
+	}
+}
@@ -0,0 +1,89 @@
+//
+// Readiness.go
+//
+// This file contains functionality for checking whether a Reduce task's input is ready, i.e.
+// whether every Map task has written its intermediate file.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// MissingPartitions
+//
+// Finds the Map tasks whose intermediate file for a Reduce task is missing, for a job with
+// the default configuration (see Worker.MissingPartitions).
+//
+// 		jobName          - the name of the MapReduce job
+//      nMap             - the number of Map tasks that were run
+//      reduceTaskNumber - the unique number assigned to the Reduce task
+//
+// Returns the numbers of the Map tasks whose files are missing, in order.
+//
+func MissingPartitions(jobName string, nMap int, reduceTaskNumber int) []int {
+	return (&Worker{JobName: jobName}).MissingPartitions(nMap, reduceTaskNumber)
+}
+
+//
+// MissingPartitions
+//
+// Finds the Map tasks whose intermediate file for a Reduce task is missing, so a scheduler
+// can tell whether the Reduce task can run, or must wait for those Map tasks to be re-run.
+// The files are found by the same names Reduce reads (see reduceName), and only the Map
+// tasks Reduce reads are checked (see Worker.MapTasks). In the combined layout (see
+// Worker.CombinedLayout), a Map task's partition is present once its combined file's index
+// is, since the index is written last. A pre-shuffled file (see Worker.PreShuffled) is
+// checked in place of the intermediate files, and reported as Map task 0 if missing (see
+// intermediateTasks). A file that cannot be stat'ed is reported missing.
+//
+//      nMap             - the number of Map tasks that were run
+//      reduceTaskNumber - the unique number assigned to the Reduce task
+//
+// Returns the numbers of the Map tasks whose files are missing, in order (nil if none are).
+//
+func (w *Worker) MissingPartitions(nMap int, reduceTaskNumber int) []int {
+	var missing []int = nil
+
+	for _, i := range w.intermediateTasks(nMap) {
+		fileName := w.reduceName(i, reduceTaskNumber)
+
+		if w.PreShuffled {
+			fileName = w.shuffleName(reduceTaskNumber)
+		} else if w.CombinedLayout {
+			fileName = w.combinedName(i) + ".index"
+		} else {
+			// A rolled over file is present once its first segment is
+			fileNames, tempErr := w.intermediateFiles(fileName)
+
+			if tempErr == nil && len(fileNames) > 0 {
+				fileName = fileNames[0]
+			}
+		}
+
+		_, tempErr := w.storage().Stat(fileName)
+
+		if tempErr != nil {
+			// File missing, or unreadable
+			missing = append(missing, i)
+		}
+	}
+
+	return missing
+}
@@ -0,0 +1,103 @@
+//
+// Readiness_test.go
+//
+// This file contains tests for checking that a Reduce task's input is ready.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// TestMissingPartitions
+//
+// Runs four Map tasks, removes some of their intermediate files, and checks that exactly the
+// Map tasks whose files are gone are reported missing, for each Reduce task.
+//
+func TestMissingPartitions(t *testing.T) {
+	dir := chdirTemp(t)
+	w   := &Worker{JobName: "ready"}
+
+	for m := 0; m < 4; m++ {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), testInput)
+
+		if _, err := w.Map(m, inFile, 2, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, fileName := range []string{w.reduceName(1, 0), w.reduceName(3, 0), w.reduceName(2, 1)} {
+		if err := w.storage().Remove(fileName); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		reduceTaskNumber int
+		expected         []int
+	}{
+		{0, []int{1, 3}},
+		{1, []int{2}},
+	} {
+		// The package function finds the files of the default configuration in dir
+		missing := MissingPartitions("ready", 4, test.reduceTaskNumber)
+
+		if !slices.Equal(missing, test.expected) {
+			t.Errorf("reduce task %d in %s: missing %v, expected %v", test.reduceTaskNumber, dir, missing, test.expected)
+		}
+	}
+
+	if missing := w.MissingPartitions(2, 0); !slices.Equal(missing, []int{1}) {
+		t.Errorf("missing %v of the first two Map tasks, expected only task 1", missing)
+	}
+}
+
+//
+// TestMissingPartitionsPreShuffled
+//
+// Checks that a pre-shuffled Reduce task's file is checked in place of the intermediate
+// files, and reported as Map task 0 when missing.
+//
+func TestMissingPartitionsPreShuffled(t *testing.T) {
+	w := newTestWorker(t, "shuffled")
+	w.PreShuffled = true
+
+	file, err := w.storage().Create(w.shuffleName(0))
+
+	if err == nil {
+		err = file.Close()
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if missing := w.MissingPartitions(4, 0); missing != nil {
+		t.Errorf("reduce task 0: missing %v, expected none", missing)
+	}
+
+	if missing := w.MissingPartitions(4, 1); !slices.Equal(missing, []int{0}) {
+		t.Errorf("reduce task 1: missing %v, expected [0]", missing)
+	}
+}
@@ -0,0 +1,246 @@
+//
+// Rebalance.go
+//
+// This file contains functionality for rewriting the merge files of a job into a number of
+// equally sized merge files, so that skewed Reduce outputs can be consumed in parallel
+// evenly downstream.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+)
+
+//
+// rebalance
+//
+// Rebalances the merge files of a job with the default configuration (see
+// Worker.Rebalance).
+//
+//      jobName  - the name of the MapReduce job
+//      nReduce  - the number of Reduce tasks that were run
+//      nOutputs - the number of merge files to rewrite them into
+//
+// Returns nil on success. Otherwise, the error that failed the rebalancing.
+//
+func rebalance(jobName string, nReduce int, nOutputs int) error {
+	return (&Worker{JobName: jobName}).Rebalance(nReduce, nOutputs)
+}
+
+//
+// Rebalance
+//
+// Rewrites the nReduce merge files of a job into nOutputs merge files holding equal numbers
+// of KeyValue pairs (differing by at most one, if they cannot be equal), so that a skewed
+// job's output can be split evenly between downstream consumers. The new files take
+// the place of the old ones, so the job's output is then read as if nOutputs Reduce tasks
+// had written it, e.g. by FinalMerge or ConcatMerge.
+//
+// When the merge files are sorted (see Worker.SortOutput), their KeyValue pairs are merged in
+// key order (see mergeSorted), so each new file holds a contiguous run of keys, and the new
+// files concatenated in order are globally sorted. Otherwise, the pairs are taken from the
+// old files in partition order.
+//
+// The merge files are read twice, first to count their KeyValue pairs, and then to write
+// them, holding one new file's pairs in memory at a time. The new files are written beside
+// the old ones, <mergeName>.rebalance, and only replace them once every new file is
+// written, so a failure before then leaves the old files in place. The merge files must be
+// JSON-encoded (see OutputFormat), and the new files are written in the Worker's output
+// format and compression; completion markers of the old files are removed (see
+// Worker.SkipCompleted).
+//
+//      nReduce  - the number of Reduce tasks that were run
+//      nOutputs - the number of merge files to rewrite them into
+//
+// Returns nil on success. Otherwise, the error that failed the rebalancing.
+//
+func (w *Worker) Rebalance(nReduce int, nOutputs int) error {
+	var status int   = 0
+	var err    error = nil
+
+	if nOutputs < 1 {
+		status = -1
+		err    = fmt.Errorf("invalid number of outputs %d", nOutputs)
+	}
+
+	//
+	// Count the KeyValue pairs of the merge files:
+	//
+	var total int = 0
+
+	if status == 0 {
+		tempErr := w.forEachMergeKeyValue(nReduce, func(kv KeyValue) error {
+			total++
+			return nil
+		})
+
+		if tempErr != nil {
+			// Error reading files
+			status = -1
+			err    = tempErr
+		}
+	}
+
+	//
+	// Write each run of KeyValue pairs to its new file, next to the old files:
+	// *NOTE* The k-th pair goes to new file k*nOutputs/total, which gives every file
+	//        total/nOutputs pairs, rounded down or up
+	//
+	var written int = 0
+
+	if status == 0 {
+		var keyValues []KeyValue = nil
+		var index     int        = 0
+
+		tempErr := w.forEachMergeKeyValue(nReduce, func(kv KeyValue) error {
+			target := index * nOutputs / total
+
+			index++
+
+			for written < target {
+				err := w.writeOutputFile(rebalanceName(w.mergeName(written)), keyValues)
+
+				if err != nil {
+					return err
+				}
+
+				keyValues = keyValues[:0]
+				written++
+			}
+
+			keyValues = append(keyValues, kv)
+
+			return nil
+		})
+
+		//
+		// Write the last new file, and any left empty as there are fewer pairs than files:
+		//
+		for tempErr == nil && written < nOutputs {
+			tempErr = w.writeOutputFile(rebalanceName(w.mergeName(written)), keyValues)
+
+			if tempErr == nil {
+				keyValues = nil
+				written++
+			}
+		}
+
+		if tempErr != nil {
+			// Error reading or writing files
+			status = -1
+			err    = tempErr
+		}
+	}
+
+	//
+	// Replace the old files with the new ones:
+	// *NOTE* Old files beyond nOutputs are removed, as they are no longer part of the output
+	//
+	if status == 0 {
+		storage := w.storage()
+
+		for i := 0; i < nReduce && status == 0; i++ {
+			for _, fileName := range []string{markerName(w.mergeName(i)), w.mergeName(i)} {
+				tempErr := storage.Remove(fileName)
+
+				if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+					// Error removing file
+					status = -1
+					err    = tempErr
+					break
+				}
+			}
+		}
+
+		for i := 0; i < nOutputs && status == 0; i++ {
+			tempErr := storage.Rename(rebalanceName(w.mergeName(i)), w.mergeName(i))
+
+			if tempErr == nil && w.Durable {
+				tempErr = syncDir(storage, w.mergeName(i))
+			}
+
+			if tempErr != nil {
+				// Error renaming file
+				status = -1
+				err    = tempErr
+			}
+		}
+	}
+
+	//
+	// Handle any error writing the new files by removing those written:
+	//
+	if status != 0 && written < nOutputs {
+		for i := 0; i < written; i++ {
+			w.storage().Remove(rebalanceName(w.mergeName(i)))
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [Rebalance.Rebalance]: %s\n", err.Error())
+	}
+
+	return err
+}
+
+//
+// rebalanceName
+//
+// Builds the name a new merge file is written to while rebalancing (see Worker.Rebalance).
+//
+//      mergeFileName - the name of the merge file it will replace
+//
+// Returns the new merge file's temporary name.
+//
+func rebalanceName(mergeFileName string) string {
+	return mergeFileName + ".rebalance"
+}
+
+//
+// forEachMergeKeyValue
+//
+// Decodes the KeyValue pairs of a job's merge files one at a time: in key order if the files
+// are sorted (see Worker.SortOutput), otherwise file by file.
+//
+//      nReduce - the number of Reduce tasks that were run
+//      visit   - called with each decoded KeyValue; an error stops the decoding
+//
+// Returns nil on success. Otherwise, the error reading a file or returned by visit.
+//
+func (w *Worker) forEachMergeKeyValue(nReduce int, visit func(kv KeyValue) error) error {
+	files, readers, names, err := w.openMergeFiles(nReduce)
+
+	if err == nil && w.SortOutput {
+		err = mergeSorted(readers, names, visit)
+	} else if err == nil {
+		for i := 0; i < len(readers) && err == nil; i++ {
+			err = decodeKeyValues(readers[i], visit)
+		}
+	}
+
+	for _, file := range files {
+		file.Close()
+	}
+
+	return err
+}
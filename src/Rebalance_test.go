@@ -0,0 +1,182 @@
+//
+// Rebalance_test.go
+//
+// This file contains tests for rewriting merge files into equally sized files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// writeMergeFiles
+//
+// Writes the merge files of a job directly, as if its Reduce tasks had written them.
+//
+//      t     - the test
+//      w     - the Worker
+//      files - the KeyValues of each merge file
+//
+func writeMergeFiles(t testing.TB, w *Worker, files [][]KeyValue) {
+	t.Helper()
+
+	for r, keyValues := range files {
+		if err := w.writeOutputFile(w.mergeName(r), keyValues); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//
+// skewedMergeFiles
+//
+// Builds the KeyValues of three skewed merge files, of 1, 4 and 12 KeyValues, each sorted by
+// key, with keys interleaved between the files.
+//
+// Returns the KeyValues of each file.
+//
+func skewedMergeFiles() [][]KeyValue {
+	files := make([][]KeyValue, 3)
+
+	for i := 0; i < 17; i++ {
+		r := 2
+
+		if i == 8 {
+			r = 0
+		} else if i%5 == 1 {
+			r = 1
+		}
+
+		files[r] = append(files[r], KeyValue{fmt.Sprintf("key-%02d", i), fmt.Sprint(i)})
+	}
+
+	return files
+}
+
+//
+// TestRebalance
+//
+// Rebalances three skewed merge files, sorted and unsorted, into four, and checks that every
+// KeyValue is preserved, that the new files differ in size by at most one, that the sorted
+// files concatenate to the global key order, that the unsorted ones keep partition order, and
+// that no temporary file is left behind.
+//
+func TestRebalance(t *testing.T) {
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "rebalance")
+		w.SortOutput = sorted
+
+		files := skewedMergeFiles()
+
+		writeMergeFiles(t, w, files)
+
+		if err := w.Rebalance(len(files), 4); err != nil {
+			t.Fatalf("sorted %v: %v", sorted, err)
+		}
+
+		var rebalanced []KeyValue = nil
+
+		for r := 0; r < 4; r++ {
+			keyValues := readKeyValueFile(t, w, w.mergeName(r))
+
+			if len(keyValues) < 4 || len(keyValues) > 5 {
+				t.Fatalf("sorted %v: merge file %d holds %d KeyValues, expected 4 or 5", sorted, r, len(keyValues))
+			}
+
+			rebalanced = append(rebalanced, keyValues...)
+		}
+
+		expected := slices.Concat(files...)
+
+		if sorted {
+			sortKeyValues(expected)
+		}
+
+		if !slices.Equal(rebalanced, expected) {
+			t.Fatalf("sorted %v: rebalanced into %v, expected %v", sorted, rebalanced, expected)
+		}
+
+		for _, name := range listDir(t, w.OutDir) {
+			if strings.HasSuffix(name, ".rebalance") {
+				t.Fatalf("sorted %v: temporary file %s left behind", sorted, name)
+			}
+		}
+	}
+}
+
+//
+// TestRebalanceFewer
+//
+// Rebalances four merge files into two, and checks that the old files beyond the new ones
+// are removed, with the completion markers of the old files.
+//
+func TestRebalanceFewer(t *testing.T) {
+	w := newTestWorker(t, "rebalance")
+
+	files := [][]KeyValue{{{"a", "1"}}, {{"b", "1"}, {"c", "1"}}, nil, {{"d", "1"}}}
+
+	writeMergeFiles(t, w, files)
+
+	for r := range files {
+		if err := w.writeCompletionMarker(w.mergeName(r)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Rebalance(len(files), 2); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 2), map[string]string{"a": "1", "b": "1", "c": "1", "d": "1"})
+
+	for r := range files {
+		if _, err := w.storage().Stat(markerName(w.mergeName(r))); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("completion marker of merge file %d left behind: %v", r, err)
+		}
+
+		if _, err := w.storage().Stat(w.mergeName(r)); (r < 2) != (err == nil) {
+			t.Fatalf("merge file %d exists %v after rebalancing into 2", r, err == nil)
+		}
+	}
+}
+
+//
+// TestRebalanceInvalid
+//
+// Checks that rebalancing into fewer than one file fails, and leaves the merge files alone.
+//
+func TestRebalanceInvalid(t *testing.T) {
+	w := newTestWorker(t, "rebalance")
+
+	writeMergeFiles(t, w, [][]KeyValue{{{"a", "1"}}})
+
+	if err := w.Rebalance(1, 0); err == nil {
+		t.Fatal("rebalanced into 0 files")
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "1"})
+}
@@ -0,0 +1,233 @@
+//
+// RecordReader.go
+//
+// This file contains functionality for a 'map' worker whose input is split into records by a
+// RecordReader, so that any input format (fixed-width, length-prefixed, XML, ...) can be
+// mapped one record at a time.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"io"
+	"iter"
+	"strings"
+)
+
+//
+// RecordReader
+//
+// Splits an input into the records passed to a Map function (see Worker.MapRecordReader).
+//
+// Next returns the next record, and true; or false once the input is exhausted. A non-nil
+// error fails the Map task.
+//
+type RecordReader interface {
+	Next() (record string, ok bool, err error)
+}
+
+//
+// lineReader
+//
+// A RecordReader whose records are the lines of its input (see NewLineReader).
+//
+type lineReader struct {
+	reader *bufio.Reader
+}
+
+//
+// NewLineReader
+//
+// Builds a RecordReader whose records are the lines of an input, without their "\n" or
+// "\r\n" line endings. The last line need not end with a line ending. Lines can be of any
+// length.
+//
+//      input - the reader of the input
+//
+// Returns the RecordReader.
+//
+func NewLineReader(input io.Reader) RecordReader {
+	return &lineReader{reader: bufio.NewReader(input)}
+}
+
+//
+// Next
+//
+// Reads the next line (see RecordReader).
+//
+func (r *lineReader) Next() (string, bool, error) {
+	line, err := r.reader.ReadString('\n')
+
+	if err == io.EOF {
+		// Last line, if not empty
+		return line, line != "", nil
+	} else if err != nil {
+		// Error reading
+		return "", false, err
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return line, true, nil
+}
+
+//
+// wholeReader
+//
+// A RecordReader whose single record is its whole input (see NewWholeReader).
+//
+type wholeReader struct {
+	input io.Reader
+	done  bool
+}
+
+//
+// NewWholeReader
+//
+// Builds a RecordReader whose single record is the whole contents of an input, as mapped by
+// Worker.MapReader.
+//
+//      input - the reader of the input
+//
+// Returns the RecordReader.
+//
+func NewWholeReader(input io.Reader) RecordReader {
+	return &wholeReader{input: input}
+}
+
+//
+// Next
+//
+// Reads the whole input, the first time it is called (see RecordReader).
+//
+func (r *wholeReader) Next() (string, bool, error) {
+	if r.done {
+		return "", false, nil
+	}
+
+	r.done = true
+
+	contentBytes, err := io.ReadAll(r.input)
+
+	if err != nil {
+		// Error reading
+		return "", false, err
+	}
+
+	return string(contentBytes), true, nil
+}
+
+//
+// doMapRecords
+//
+// Does the job of a map worker over an input file split by a RecordReader, with the default
+// configuration (see Worker.MapRecordReader).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      newReader     - builds the RecordReader of the file, e.g. NewLineReader
+//      mapFunc		  - the user-defined Map function, called once per record
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapRecords(
+	jobName       string,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	newReader     func(input io.Reader) RecordReader,
+	mapFunc       func(file string, record string) []KeyValue,
+) (MapStats, error) {
+	w := &Worker{JobName: jobName}
+
+	file, err := w.storage().Open(inFile)
+
+	if err != nil {
+		// Error opening file
+		w.logf("Function error [RecordReader.doMapRecords]: %s\n", err.Error())
+		return MapStats{}, err
+	}
+
+	defer file.Close()
+
+	return w.MapRecordReader(mapTaskNumber, inFile, newReader(file), nReduce, mapFunc)
+}
+
+//
+// MapRecordReader
+//
+// This function does the job of a map worker like MapReader, but calls the Map function once
+// per record of a RecordReader, which keeps the Map function independent of the input's
+// format. Records are read as they are mapped, so the input is streamed rather than read
+// whole (unless the RecordReader reads it whole). An error reading a record fails the task.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      name          - the name of the input, passed to mapFunc as its file
+//      reader        - splits the input into records
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function, called once per record
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapRecordReader(
+	mapTaskNumber int,
+	name          string,
+	reader        RecordReader,
+	nReduce       int,
+	mapFunc       func(file string, record string) []KeyValue,
+) (MapStats, error) {
+	return mapRecords(w, mapTaskNumber, name, 0, nReduce, readerRecords(reader), func(file string, record string) ([]KeyValue, error) {
+		return mapFunc(file, record), nil
+	})
+}
+
+//
+// readerRecords
+//
+// Reads the records of a RecordReader as a sequence (see mapRecords).
+//
+//      reader - splits the input into records
+//
+// Returns the sequence of the input's records, ending with the error that ended reading, if
+// any.
+//
+func readerRecords(reader RecordReader) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		for {
+			record, ok, err := reader.Next()
+
+			if err != nil {
+				// Error reading record
+				yield("", err)
+				return
+			}
+
+			if !ok || !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+//
+// RecordReader_test.go
+//
+// This file contains tests for RecordReaders and Map over them.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// fixedWidthReader
+//
+// A RecordReader whose records are fixed-width fields of its input, with their space padding
+// trimmed. A short last field is an error.
+//
+type fixedWidthReader struct {
+	input io.Reader
+	width int
+}
+
+//
+// Next
+//
+// Reads the next field (see RecordReader).
+//
+func (r *fixedWidthReader) Next() (string, bool, error) {
+	field := make([]byte, r.width)
+
+	_, err := io.ReadFull(r.input, field)
+
+	if err == io.EOF {
+		return "", false, nil
+	} else if err != nil {
+		return "", false, err
+	}
+
+	return strings.TrimRight(string(field), " "), true, nil
+}
+
+//
+// readRecords
+//
+// Reads every record of a RecordReader, failing the test on an error.
+//
+//      t      - the test
+//      reader - the RecordReader
+//
+// Returns the records, in order.
+//
+func readRecords(t testing.TB, reader RecordReader) []string {
+	t.Helper()
+
+	var records []string = nil
+
+	for {
+		record, ok, err := reader.Next()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !ok {
+			return records
+		}
+
+		records = append(records, record)
+	}
+}
+
+//
+// TestRecordReaders
+//
+// Checks the records of the line and whole-input RecordReaders.
+//
+func TestRecordReaders(t *testing.T) {
+	input := "one\r\ntwo\n\nthree"
+
+	for _, test := range []struct {
+		name     string
+		reader   RecordReader
+		expected []string
+	}{
+		{"line", NewLineReader(strings.NewReader(input)), []string{"one", "two", "", "three"}},
+		{"line, trailing newline", NewLineReader(strings.NewReader("one\n")), []string{"one"}},
+		{"line, empty", NewLineReader(strings.NewReader("")), nil},
+		{"whole", NewWholeReader(strings.NewReader(input)), []string{input}},
+	} {
+		if records := readRecords(t, test.reader); !slices.Equal(records, test.expected) {
+			t.Errorf("%s: records %q, expected %q", test.name, records, test.expected)
+		}
+	}
+}
+
+//
+// TestMapRecordReaderFixedWidth
+//
+// Runs a word count job over a fixed-width input with a custom RecordReader, and checks that
+// the Map function is called once per field.
+//
+func TestMapRecordReaderFixedWidth(t *testing.T) {
+	w := newTestWorker(t, "fixed")
+
+	input := "apple pear  apple plum  pear  apple "
+	calls := 0
+
+	reader := &fixedWidthReader{input: strings.NewReader(input), width: 6}
+
+	_, err := w.MapRecordReader(0, "fixed", reader, 2, func(file string, record string) []KeyValue {
+		calls++
+		return []KeyValue{{record, "1"}}
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls != 6 {
+		t.Fatalf("Map function called %d times, expected once per field (6)", calls)
+	}
+
+	for r := 0; r < 2; r++ {
+		if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkOutput(t, readOutput(t, w, 2), map[string]string{"apple": "3", "pear": "2", "plum": "1"})
+}
+
+//
+// TestMapRecordReaderError
+//
+// Checks that an error reading a record fails the Map task.
+//
+func TestMapRecordReaderError(t *testing.T) {
+	w := newTestWorker(t, "short")
+
+	// The last field is short
+	reader := &fixedWidthReader{input: strings.NewReader("apple pea"), width: 6}
+
+	_, err := w.MapRecordReader(0, "short", reader, 1, wordOnesMap)
+
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("error %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
@@ -0,0 +1,99 @@
+//
+// ReduceContext.go
+//
+// This file contains functionality for reduce functions that need to know which task they
+// run in, or the job's configuration (see Worker.ReduceWithContext).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// ReduceContext
+//
+// Describes the Reduce task a reduce function is called by (see Worker.ReduceWithContext).
+//
+//      JobName          - the name of the MapReduce job
+//      ReduceTaskNumber - the unique number assigned to the Reduce task
+//      NMap             - the number of Map tasks that were run
+//      Config           - the job's user configuration (see Worker.Config), e.g. a
+//                         threshold. It is shared by every task, and by every call when
+//                         reducing in parallel, so it is read-only
+//
+type ReduceContext struct {
+	JobName          string
+	ReduceTaskNumber int
+	NMap             int
+	Config           map[string]string
+}
+
+//
+// doReduceWithContext
+//
+// Does the job of a reduce worker with a context-aware reduce function, with the default
+// configuration (see Worker.ReduceWithContext).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func doReduceWithContext(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(ctx ReduceContext, key string, values []string) string,
+) (string, error) {
+	return (&Worker{JobName: jobName}).ReduceWithContext(reduceTaskNumber, nMap, reduceFunc)
+}
+
+//
+// ReduceWithContext
+//
+// This function does the job of a reduce worker like Reduce, but also passes the reduce
+// function a ReduceContext describing its task and the job's configuration, so that one
+// reduce function can be configured per job without global state or closures.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task; any previous merge file is left in place.
+//
+func (w *Worker) ReduceWithContext(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(ctx ReduceContext, key string, values []string) string,
+) (string, error) {
+	ctx := ReduceContext{
+		JobName:          w.JobName,
+		ReduceTaskNumber: reduceTaskNumber,
+		NMap:             nMap,
+		Config:           w.Config,
+	}
+
+	return w.Reduce(reduceTaskNumber, nMap, func(key string, values []string) string {
+		return reduceFunc(ctx, key, values)
+	})
+}
@@ -0,0 +1,158 @@
+//
+// ReduceContext_test.go
+//
+// This file contains tests for passing the job's configuration to map and reduce functions.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// thresholdReduce
+//
+// A context-aware word count Reduce function that counts only the words occurring at least
+// the "threshold" of the job's configuration times, and returns "" for the others.
+//
+//      ctx    - the context of the Reduce task
+//      key    - the word
+//      values - the word's counts
+//
+// Returns the word's count, or "" if it is under the threshold.
+//
+func thresholdReduce(ctx ReduceContext, key string, values []string) string {
+	threshold, err := strconv.Atoi(ctx.Config["threshold"])
+
+	if err != nil {
+		return "error"
+	}
+
+	count, _ := strconv.Atoi(WordCountReduce(key, values))
+
+	if count < threshold {
+		return ""
+	}
+
+	return strconv.Itoa(count)
+}
+
+//
+// TestReduceConfigThreshold
+//
+// Runs a word count job whose Reduce function reads a threshold from the job's
+// configuration, at several thresholds, filtering its output by the results, and checks
+// that exactly the words occurring at least the threshold times are written.
+//
+func TestReduceConfigThreshold(t *testing.T) {
+	inputs := []string{"the fox and the dog", "the fox and a cat"}
+	counts := countWords(inputs...)
+
+	for _, threshold := range []int{1, 2, 3, 4} {
+		w := newTestWorker(t, "config")
+		w.Config       = map[string]string{"threshold": strconv.Itoa(threshold)}
+		w.OutputFilter = func(keyValues []KeyValue) []KeyValue {
+			var kept []KeyValue = nil
+
+			for _, kv := range keyValues {
+				if kv.Value != "" {
+					kept = append(kept, kv)
+				}
+			}
+
+			return kept
+		}
+
+		for m, input := range inputs {
+			inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+			if _, err := w.Map(m, inFile, 2, WordCountMap); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for r := 0; r < 2; r++ {
+			_, err := w.ReduceWithContext(r, len(inputs), func(ctx ReduceContext, key string, values []string) string {
+				if ctx.ReduceTaskNumber != r || ctx.NMap != len(inputs) || ctx.JobName != "config" {
+					return "error"
+				}
+
+				return thresholdReduce(ctx, key, values)
+			})
+
+			if err != nil {
+				t.Fatalf("threshold %d: %v", threshold, err)
+			}
+		}
+
+		expected := make(map[string]string)
+
+		for word, count := range counts {
+			if n, _ := strconv.Atoi(count); n >= threshold {
+				expected[word] = count
+			}
+		}
+
+		checkOutput(t, readOutput(t, w, 2), expected)
+	}
+}
+
+//
+// TestMapConfig
+//
+// Runs a Map function that reads the field to emit from the job's configuration, and
+// checks that it emits that field of each record.
+//
+func TestMapConfig(t *testing.T) {
+	w := newTestWorker(t, "config")
+	w.Config = map[string]string{"field": "1"}
+
+	inFile := writeInput(t, w, "input", "a,x,1\nb,y,2\nc,x,3")
+
+	_, err := w.MapWithContext(0, inFile, 1, func(ctx MapContext, file string, contents string) []KeyValue {
+		field, err := strconv.Atoi(ctx.Config["field"])
+
+		if err != nil {
+			t.Errorf("field %q: %v", ctx.Config["field"], err)
+			return nil
+		}
+
+		var keyValues []KeyValue = nil
+
+		for _, line := range strings.Split(contents, "\n") {
+			keyValues = append(keyValues, KeyValue{strings.Split(line, ",")[field], "1"})
+		}
+
+		return keyValues
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := w.Reduce(0, 1, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"x": "2", "y": "1"})
+}
@@ -0,0 +1,133 @@
+//
+// ReduceEmit.go
+//
+// This file contains functionality for a 'reduce' worker that passes its results to a
+// callback as they are produced, rather than writing a merge file (see DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// doReduceEmit
+//
+// Does the job of a reduce worker emitting its results, with the default configuration (see
+// Worker.ReduceEmit).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      emit             - called with each result; an error stops the task
+//
+// Returns nil on success. Otherwise, the error that failed the task.
+//
+func doReduceEmit(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	emit             func(kv KeyValue) error,
+) error {
+	return (&Worker{JobName: jobName}).ReduceEmit(reduceTaskNumber, nMap, reduceFunc, emit)
+}
+
+//
+// ReduceEmit
+//
+// This function does the job of a reduce worker like Reduce, but passes each result to emit
+// rather than writing a merge file, e.g. to pipe the results into a database or another
+// stage without an intermediate file. The intermediate files are grouped and reduced by the
+// same code as in Reduce (see Worker.producer), and the results finalized, filtered and
+// sorted as configured. As in ReduceTo, AppendOutput, SkipCompleted and CleanupIntermediate
+// do not apply.
+//
+// Each result is emitted as soon as it is produced, finalized if configured, so the results
+// are never held in memory as a whole. emit applies backpressure by blocking until its
+// consumer is ready, or by returning an error to stop the task.
+// Filtering and sorting need every result, so with an OutputFilter or SortOutput, the
+// results are collected first, and emitted once they are all produced. When reducing in
+// parallel (see Worker.ReduceParallelism), emit is called by one goroutine at a time.
+//
+// An error returned by emit stops the task: no more results are produced, and the task
+// fails with the error, attributed to ErrWriteMerge. Results emitted before the error are
+// not taken back.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      emit             - called with each result; an error stops the task
+//
+// Returns nil on success. Otherwise, the error that failed the task.
+//
+func (w *Worker) ReduceEmit(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	emit             func(kv KeyValue) error,
+) error {
+	var err error = nil
+
+	reduceFunc = w.dispatchReduceFunc(reduceFunc)
+
+	if w.OutputFilter == nil && !w.SortOutput {
+		//
+		// Emit each result as it is produced, finalizing it first if configured:
+		//
+		_, err = w.producer(reduceTaskNumber, nMap, reduceFunc, func(kv KeyValue) error {
+			if w.Finalize != nil {
+				tempErr := w.finalizeKeyValue(&kv)
+
+				if tempErr != nil {
+					// Error finalizing the key
+					return stageError(ErrReduceFunc, tempErr)
+				}
+			}
+
+			return emit(kv)
+		})()
+	} else {
+		//
+		// Collect the results to filter and sort them, then emit them:
+		//
+		keyValues, tempErr := w.producer(reduceTaskNumber, nMap, reduceFunc, nil)()
+
+		if tempErr == nil {
+			keyValues, tempErr = w.finishResults(keyValues)
+		}
+
+		for i := 0; i < len(keyValues) && tempErr == nil; i++ {
+			tempErr = emit(keyValues[i])
+
+			if tempErr != nil {
+				// Emitting the result failed
+				tempErr = stageError(ErrWriteMerge, tempErr)
+			}
+		}
+
+		err = tempErr
+	}
+
+	if err != nil {
+		w.logf("Function error [ReduceEmit.ReduceEmit]: %s\n", err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,158 @@
+//
+// ReduceEmit_test.go
+//
+// This file contains tests for streaming Reduce results into a callback.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+//
+// errTestEmit
+//
+// The error returned by the emit callback of the ReduceEmit tests to stop a task.
+//
+var errTestEmit = errors.New("emit stopped")
+
+//
+// TestReduceEmitMatchesFile
+//
+// Reduces each partition of a job into a callback, streamed and with sorted output, then to
+// its merge file, and checks that the callback received exactly the merge file's KeyValues
+// (in the same order, when sorted).
+//
+func TestReduceEmitMatchesFile(t *testing.T) {
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "reduceemit")
+		w.SortOutput = sorted
+
+		inFile := writeInput(t, w, "input", testInput)
+
+		if _, err := w.Map(0, inFile, 2, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		for r := 0; r < 2; r++ {
+			var emitted []KeyValue = nil
+
+			err := w.ReduceEmit(r, 1, WordCountReduce, func(kv KeyValue) error {
+				emitted = append(emitted, kv)
+				return nil
+			})
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+				t.Fatal(err)
+			}
+
+			written := readKeyValueFile(t, w, w.mergeName(r))
+
+			if !sorted {
+				sortKeyValues(emitted)
+				sortKeyValues(written)
+			}
+
+			if !slices.Equal(emitted, written) {
+				t.Fatalf("sorted %v: emitted %v, expected the merge file's %v", sorted, emitted, written)
+			}
+		}
+	}
+}
+
+//
+// TestReduceEmitFinalize
+//
+// Checks that streamed results are finalized before they are emitted.
+//
+func TestReduceEmitFinalize(t *testing.T) {
+	w := newTestWorker(t, "reduceemit")
+	w.Finalize = func(key string, combined string) string {
+		return key + "=" + combined
+	}
+
+	inFile := writeInput(t, w, "input", "a b a")
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	emitted := make(map[string]string)
+
+	err := w.ReduceEmit(0, 1, WordCountReduce, func(kv KeyValue) error {
+		emitted[kv.Key] = kv.Value
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := map[string]string{"a": "a=2", "b": "b=1"}; !maps.Equal(emitted, expected) {
+		t.Fatalf("emitted %v, expected %v", emitted, expected)
+	}
+}
+
+//
+// TestReduceEmitError
+//
+// Checks that an error returned by the emit callback, streamed and with sorted output,
+// stops the task at once and fails it with the error, attributed to ErrWriteMerge.
+//
+func TestReduceEmitError(t *testing.T) {
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "reduceemit")
+		w.SortOutput = sorted
+
+		inFile := writeInput(t, w, "input", "a b c d e f g h")
+
+		if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		var calls int = 0
+
+		err := w.ReduceEmit(0, 1, WordCountReduce, func(kv KeyValue) error {
+			calls++
+
+			if calls == 3 {
+				return errTestEmit
+			}
+
+			return nil
+		})
+
+		if !errors.Is(err, errTestEmit) || !errors.Is(err, ErrWriteMerge) {
+			t.Fatalf("sorted %v: ReduceEmit returned %v, expected %v and %v", sorted, err, errTestEmit, ErrWriteMerge)
+		}
+
+		if calls != 3 {
+			t.Fatalf("sorted %v: emit called %d times, expected 3", sorted, calls)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//
+// ReduceKeys.go
+//
+// This file contains functionality for collecting the distinct keys of a Reduce task's
+// partition, without reducing their values (see DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"maps"
+	"slices"
+)
+
+//
+// reduceKeys
+//
+// Collects the distinct keys of a Reduce task's partition with the default configuration
+// (see Worker.ReduceKeys).
+//
+//      jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap             - the number of Map tasks that were run
+//
+// Returns the sorted distinct keys, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func reduceKeys(jobName string, reduceTaskNumber int, nMap int) ([]string, error) {
+	return (&Worker{JobName: jobName}).ReduceKeys(reduceTaskNumber, nMap)
+}
+
+//
+// ReduceKeys
+//
+// Reads the intermediate files of a Reduce task like Reduce, but only collects their distinct
+// keys: no reduceFunc is called and no merge file is written. This is much cheaper than a
+// full Reduce for jobs that only need the set of keys (e.g. building a dictionary), as the
+// values are never held in memory.
+//
+// Missing intermediate files are treated as empty, as in Reduce. Keys are normalized and
+// grouped first (see Worker.Normalize and Worker.KeyEquality), so they are the keys Reduce
+// would have passed to reduceFunc.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap             - the number of Map tasks that were run
+//
+// Returns the sorted distinct keys, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) ReduceKeys(reduceTaskNumber int, nMap int) ([]string, error) {
+	var status int   = 0
+	var err    error = nil
+
+	//
+	// Decode files into a set of keys:
+	//
+	keySet  := make(map[string]struct{})
+	grouper := newKeyGrouper(w.KeyEquality)
+
+	for _, i := range w.intermediateTasks(nMap) {
+		tempErr := w.forEachIntermediate(i, reduceTaskNumber, func(kv KeyValue) error {
+			if w.Normalize != nil {
+				kv.Key = w.Normalize(kv.Key)
+			}
+
+			kv.Key = grouper.group(kv.Key)
+
+			keySet[kv.Key] = struct{}{}
+
+			return nil
+		})
+
+		if tempErr != nil {
+			// Error reading file
+			status = -1
+			err    = stageError(ErrDecodeIntermediate, tempErr)
+			break
+		}
+	}
+
+	//
+	// Sort the distinct keys:
+	//
+	var keys []string = nil
+
+	if status == 0 {
+		keys = slices.Sorted(maps.Keys(keySet))
+	} else {
+		w.logf("Function error [ReduceKeys.ReduceKeys]: %s\n", err.Error())
+	}
+
+	return keys, err
+}
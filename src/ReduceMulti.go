@@ -0,0 +1,170 @@
+//
+// ReduceMulti.go
+//
+// This file contains functionality for a 'reduce' worker whose reduce function expands each
+// key into any number of output KeyValues, rather than reduce it to one value.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+//
+// ReduceMulti
+//
+// This function does the job of a reduce worker like Reduce, but with a reduce function that
+// returns any number of KeyValues for a key (none, one, or several), e.g. to expand a key
+// into one record per distinct value. The KeyValues of every call are written to the merge
+// file in the order of the keys (see Worker.InsertionOrder), then finalized, filtered and
+// sorted like any other results.
+//
+// Output keys need not be the key reduced, so two calls may emit the same output key; the
+// merge file then holds both KeyValues. When that is a logic error (see
+// Worker.StrictUniqueKeys), the task fails instead, naming the keys whose calls emitted it.
+//
+// As reduceFunc cannot re-reduce its own output, ReduceMulti cannot be used with
+// AppendOutput or MaxValuesPerKey, and the Reduce functions of Reducers and ReducerFor are
+// not used.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task; any previous merge file is left in place.
+//
+func (w *Worker) ReduceMulti(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) []KeyValue,
+) (string, error) {
+	if w.AppendOutput || w.MaxValuesPerKey > 0 {
+		err := errors.New("ReduceMulti cannot be used with AppendOutput or MaxValuesPerKey")
+
+		w.logf("Function error [ReduceMulti.ReduceMulti]: %s\n", err.Error())
+		return "", err
+	}
+
+	outputs := &multiOutputs{keyValues: make(map[string][]KeyValue)}
+
+	//
+	// Collect the output of each call by its key, and give the task an empty result per key
+	// to keep the keys' order by:
+	//
+	singleReduceFunc := func(key string, values []string) string {
+		outputs.add(key, reduceFunc(key, values))
+		return ""
+	}
+
+	produce := w.producer(reduceTaskNumber, nMap, singleReduceFunc, nil)
+
+	return w.reduce(reduceTaskNumber, nMap, singleReduceFunc, func() ([]KeyValue, error) {
+		results, err := produce()
+
+		if err == nil {
+			results, err = outputs.expand(results, w.StrictUniqueKeys)
+		}
+
+		return results, err
+	}, nil)
+}
+
+//
+// multiOutputs
+//
+// The KeyValues returned by the calls of a multi-output Reduce function, by the key reduced.
+// Adding is locked, since calls may run concurrently (see Worker.ReduceParallelism), and a
+// call that times out is abandoned while it may still be running (see callWithTimeout).
+//
+type multiOutputs struct {
+	mutex     sync.Mutex
+	keyValues map[string][]KeyValue
+}
+
+//
+// add
+//
+// Records the KeyValues returned by the call for a key.
+//
+//      key       - the key reduced
+//      keyValues - the KeyValues the call returned
+//
+func (m *multiOutputs) add(key string, keyValues []KeyValue) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.keyValues[key] = keyValues
+}
+
+//
+// expand
+//
+// Replaces the result of each key reduced with the KeyValues its call returned.
+//
+//      results          - the results of the task, one per key reduced, in order
+//      strictUniqueKeys - whether an output key emitted more than once is an error
+//
+// Returns the KeyValues of every call, in the order of the results, and nil on success.
+// Otherwise, the error naming a duplicate output key.
+//
+func (m *multiOutputs) expand(results []KeyValue, strictUniqueKeys bool) ([]KeyValue, error) {
+	var expanded []KeyValue = nil
+	var err      error      = nil
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	//
+	// Track the key whose call emitted each output key, if checking for duplicates:
+	//
+	var emittedBy map[string]string = nil
+
+	if strictUniqueKeys {
+		emittedBy = make(map[string]string)
+	}
+
+	for _, result := range results {
+		for _, kv := range m.keyValues[result.Key] {
+			if strictUniqueKeys {
+				other, exists := emittedBy[kv.Key]
+
+				if exists && other == result.Key {
+					err = fmt.Errorf("output key %q emitted twice by the Reduce call for %q", kv.Key, result.Key)
+				} else if exists {
+					err = fmt.Errorf("output key %q emitted by the Reduce calls for %q and %q", kv.Key, other, result.Key)
+				}
+
+				if err != nil {
+					return nil, stageError(ErrReduceFunc, err)
+				}
+
+				emittedBy[kv.Key] = result.Key
+			}
+
+			expanded = append(expanded, kv)
+		}
+	}
+
+	return expanded, nil
+}
@@ -0,0 +1,166 @@
+//
+// ReduceMulti_test.go
+//
+// This file contains tests for Reduce functions that expand a key into several KeyValues.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// expandReduce
+//
+// A multi-output Reduce function that emits one KeyValue per distinct value of a key, keyed
+// by the key and the value.
+//
+//      key    - the key
+//      values - the values of the key
+//
+// Returns the KeyValues of the distinct values.
+//
+func expandReduce(key string, values []string) []KeyValue {
+	var keyValues []KeyValue = nil
+
+	for _, value := range distinctValues(values) {
+		keyValues = append(keyValues, KeyValue{key + "/" + value, "1"})
+	}
+
+	return keyValues
+}
+
+//
+// mapKeyValues
+//
+// Runs a Map task that emits fixed KeyValues into a single partition.
+//
+//      t         - the test
+//      w         - the Worker that runs the task
+//      keyValues - the KeyValues to emit
+//
+func mapKeyValues(t testing.TB, w *Worker, keyValues ...KeyValue) {
+	t.Helper()
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, emitMap(keyValues...)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//
+// TestReduceMulti
+//
+// Runs an expansion Reduce function, with and without StrictUniqueKeys, and checks that
+// every KeyValue it emits is written.
+//
+func TestReduceMulti(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		w := newTestWorker(t, "multi")
+		w.StrictUniqueKeys = strict
+
+		mapKeyValues(t, w, KeyValue{"a", "x"}, KeyValue{"a", "y"}, KeyValue{"a", "x"}, KeyValue{"b", "z"})
+
+		if _, err := w.ReduceMulti(0, 1, expandReduce); err != nil {
+			t.Fatalf("strict %v: %v", strict, err)
+		}
+
+		checkOutput(t, readOutput(t, w, 1), map[string]string{"a/x": "1", "a/y": "1", "b/z": "1"})
+	}
+}
+
+//
+// TestReduceMultiDuplicateKeys
+//
+// Runs Reduce functions that emit an output key twice, from two calls and from one call,
+// and checks that with StrictUniqueKeys the task fails with ErrReduceFunc, naming the key,
+// and writes no merge file, and that without it the merge file holds both KeyValues.
+//
+func TestReduceMultiDuplicateKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		reduceFunc func(key string, values []string) []KeyValue
+	}{
+		{"across calls", func(key string, values []string) []KeyValue {
+			return []KeyValue{{key, "1"}, {"total", key}}
+		}},
+		{"within a call", func(key string, values []string) []KeyValue {
+			return []KeyValue{{key, "1"}, {key + "-copy", "1"}, {key, "2"}}
+		}},
+	}
+
+	for _, test := range tests {
+		for _, strict := range []bool{false, true} {
+			w := newTestWorker(t, "multi")
+			w.StrictUniqueKeys = strict
+
+			mapKeyValues(t, w, KeyValue{"a", "x"}, KeyValue{"b", "y"})
+
+			_, err := w.ReduceMulti(0, 1, test.reduceFunc)
+
+			if !strict {
+				if err != nil {
+					t.Fatalf("%s: %v", test.name, err)
+				}
+
+				var keys []string = nil
+
+				for _, kv := range readKeyValueFile(t, w, w.mergeName(0)) {
+					keys = append(keys, kv.Key)
+				}
+
+				slices.Sort(keys)
+
+				if len(slices.Compact(slices.Clone(keys))) == len(keys) {
+					t.Fatalf("%s: merge file keys %v, expected a duplicate", test.name, keys)
+				}
+
+				continue
+			}
+
+			if !errors.Is(err, ErrReduceFunc) || !strings.Contains(err.Error(), "emitted") {
+				t.Fatalf("%s: ReduceMulti returned %v, expected a duplicate key %v", test.name, err, ErrReduceFunc)
+			}
+
+			if _, err := w.storage().Stat(w.mergeName(0)); err == nil {
+				t.Fatalf("%s: failed Reduce task left merge file %s", test.name, w.mergeName(0))
+			}
+		}
+	}
+}
+
+//
+// TestReduceMultiAppend
+//
+// Checks that ReduceMulti rejects AppendOutput, whose re-reducing it cannot do.
+//
+func TestReduceMultiAppend(t *testing.T) {
+	w := newTestWorker(t, "multi")
+	w.AppendOutput = true
+
+	if _, err := w.ReduceMulti(0, 1, expandReduce); err == nil {
+		t.Fatal("ReduceMulti ran with AppendOutput")
+	}
+}
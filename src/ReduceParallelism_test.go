@@ -0,0 +1,126 @@
+//
+// ReduceParallelism_test.go
+//
+// This file contains tests for calling the Reduce function for several keys at once.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+//
+// TestReduceParallelism
+//
+// Runs a word count job with an artificially slow Reduce function at several pool sizes,
+// with sorted output, and checks that the merge file is the same at every size, that the output is correct,
+// and that no more keys than the pool size are reduced at once.
+//
+func TestReduceParallelism(t *testing.T) {
+	input := "the quick brown fox jumps over the lazy dog while the cat naps by the warm fire"
+
+	var expectedMerge string = ""
+
+	for _, parallelism := range []int{0, 1, 2, 4, 16} {
+		var running atomic.Int32
+		var peak    atomic.Int32
+
+		slowReduce := func(key string, values []string) string {
+			now := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				previous := peak.Load()
+
+				if now <= previous || peak.CompareAndSwap(previous, now) {
+					break
+				}
+			}
+
+			time.Sleep(time.Millisecond)
+
+			return WordCountReduce(key, values)
+		}
+
+		w := newTestWorker(t, "reduceparallelism")
+		w.ReduceParallelism = parallelism
+		w.SortOutput        = true
+
+		inFile := writeInput(t, w, "input", input)
+
+		if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := w.Reduce(0, 1, slowReduce); err != nil {
+			t.Fatalf("parallelism %d: %v", parallelism, err)
+		}
+
+		checkOutput(t, readOutput(t, w, 1), countWords(input))
+
+		if merge := readFile(t, w, w.mergeName(0)); expectedMerge == "" {
+			expectedMerge = merge
+		} else if merge != expectedMerge {
+			t.Fatalf("parallelism %d: merge file %q, expected %q", parallelism, merge, expectedMerge)
+		}
+
+		if limit := max(parallelism, 1); int(peak.Load()) > limit {
+			t.Fatalf("parallelism %d: %d keys reduced at once", parallelism, peak.Load())
+		}
+	}
+}
+
+//
+// TestReduceParallelismError
+//
+// Checks that a Reduce function failing for one key, among keys reduced in parallel, fails
+// the Reduce task with ErrReduceFunc and writes no merge file.
+//
+func TestReduceParallelismError(t *testing.T) {
+	w := newTestWorker(t, "reduceparallelism")
+	w.ReduceParallelism = 4
+
+	inFile := writeInput(t, w, "input", "a b c d e f g h")
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.Reduce(0, 1, func(key string, values []string) string {
+		if key == "e" {
+			return "error"
+		}
+
+		return strings.Join(values, ",")
+	})
+
+	if !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("Reduce returned %v, expected %v", err, ErrReduceFunc)
+	}
+
+	if _, err := w.storage().Stat(w.mergeName(0)); err == nil {
+		t.Fatalf("failed Reduce task left merge file %s", w.mergeName(0))
+	}
+}
@@ -0,0 +1,104 @@
+//
+// ReduceTo.go
+//
+// This file contains functionality for writing the results of a 'reduce' worker to a writer
+// rather than a merge file, e.g. to serve them over HTTP or pipe them into another process.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+)
+
+//
+// doReduceTo
+//
+// Does the job of a reduce worker writing to a writer, with the default configuration (see
+// Worker.ReduceTo).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      out              - the writer to write the results to
+//
+// Returns nil on success. Otherwise, the error that failed the task.
+//
+func doReduceTo(
+	jobName          string,
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	out              io.Writer,
+) error {
+	return (&Worker{JobName: jobName}).ReduceTo(reduceTaskNumber, nMap, reduceFunc, out)
+}
+
+//
+// ReduceTo
+//
+// This function does the job of a reduce worker like Reduce, but writes its results to a
+// writer, encoded exactly as Reduce would encode its merge file (see Worker.OutputFormat and
+// Worker.OutputCompression), rather than to a merge file. The intermediate files are grouped
+// and reduced as in Reduce, and the results finalized, filtered and sorted as configured.
+// Since there is no merge file, AppendOutput and SkipCompleted do not apply, and the
+// intermediate files are kept (CleanupIntermediate does not apply either), so the task can
+// be re-run if the writer's consumer fails.
+//
+// The results are written once they are all produced, so a failure to produce them writes
+// nothing. A failure writing them may leave the writer with part of them.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//      out              - the writer to write the results to
+//
+// Returns nil on success. Otherwise, the error that failed the task.
+//
+func (w *Worker) ReduceTo(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+	out              io.Writer,
+) error {
+	reduceFunc = w.dispatchReduceFunc(reduceFunc)
+
+	keyValues, err := w.producer(reduceTaskNumber, nMap, reduceFunc, nil)()
+
+	if err == nil {
+		keyValues, err = w.finishResults(keyValues)
+	}
+
+	if err == nil {
+		err = w.encodeKeyValues(out, keyValues)
+
+		if err != nil {
+			// Error encoding or writing
+			err = stageError(ErrWriteMerge, err)
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [ReduceTo.ReduceTo]: %s\n", err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,82 @@
+//
+// ReduceTo_test.go
+//
+// This file contains tests for Reduce tasks writing to a writer.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+//
+// TestReduceToMatchesFile
+//
+// Reduces each partition of a job to a buffer, then to its merge file, in each output format
+// and with gzip, and checks that the buffer holds exactly the merge file's bytes, and that
+// ReduceTo writes no merge file and keeps the intermediate files.
+//
+func TestReduceToMatchesFile(t *testing.T) {
+	for _, test := range []struct {
+		format      OutputFormat
+		compression Compression
+	}{
+		{OutputFormatJSON, CompressionNone},
+		{OutputFormatTSV, CompressionNone},
+		{OutputFormatCSV, CompressionNone},
+		{OutputFormatJSONArray, CompressionNone},
+		{OutputFormatJSON, CompressionGzip},
+	} {
+		w := newTestWorker(t, "reduceto")
+		w.OutputFormat      = test.format
+		w.OutputCompression = test.compression
+		w.SortOutput        = true
+
+		inFile := writeInput(t, w, "input", testInput)
+
+		if _, err := w.Map(0, inFile, 2, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+
+		for r := 0; r < 2; r++ {
+			var buffer bytes.Buffer
+
+			if err := w.ReduceTo(r, 1, WordCountReduce, &buffer); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := w.storage().Stat(w.mergeName(r)); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("%s, %s: ReduceTo wrote merge file %s: %v", test.format, test.compression, w.mergeName(r), err)
+			}
+
+			if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+				t.Fatal(err)
+			}
+
+			if contents := readFile(t, w, w.mergeName(r)); buffer.String() != contents {
+				t.Fatalf("%s, %s: ReduceTo wrote %q, expected the merge file's %q", test.format, test.compression, buffer.String(), contents)
+			}
+		}
+	}
+}
@@ -0,0 +1,174 @@
+//
+// Repartition.go
+//
+// This file contains functionality for rewriting the intermediate files of a job into a
+// different number of partitions, so that a wrong choice of nReduce does not cost re-running
+// the Map phase.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+)
+
+//
+// repartition
+//
+// Repartitions the intermediate files of a job with the default configuration (see
+// Worker.Repartition).
+//
+// 		jobName     - the name of the MapReduce job
+//      nMap        - the number of Map tasks that were run
+//      oldNReduce  - the number of partitions the files were written for
+//      newNReduce  - the number of partitions to rewrite them into
+//      partitioner - assigns each key to a new partition (nil means partitionFor)
+//
+// Returns nil on success. Otherwise, the error that failed the repartitioning.
+//
+func repartition(
+	jobName     string,
+	nMap        int,
+	oldNReduce  int,
+	newNReduce  int,
+	partitioner func(key string, nReduce int) int,
+) error {
+	return (&Worker{JobName: jobName, Partitioner: partitioner}).Repartition(nMap, oldNReduce, newNReduce)
+}
+
+//
+// Repartition
+//
+// Rewrites the intermediate files of a job, written by its Map tasks for oldNReduce Reduce
+// tasks, into files for newNReduce Reduce tasks, as if the Map tasks had been run with
+// newNReduce. Each KeyValue is assigned to its new partition the way Map assigns it (see
+// Worker.Partitioner and Worker.PartitionKey), so the Worker must be configured as the Map
+// tasks were (naming, layout, compression, sorting), apart from any new Partitioner. Files
+// of old partitions beyond newNReduce are removed.
+//
+// The Map tasks are repartitioned one at a time, each read whole into memory (as its Map
+// task held it) before its files are rewritten in place. A Map task whose files cannot be
+// rewritten is left with missing or mixed partitions, so it must then be re-run (see
+// MissingPartitions); the Map tasks repartitioned before it are complete.
+//
+//      nMap       - the number of Map tasks that were run
+//      oldNReduce - the number of partitions the files were written for
+//      newNReduce - the number of partitions to rewrite them into
+//
+// Returns nil on success. Otherwise, the error that failed the repartitioning.
+//
+func (w *Worker) Repartition(nMap int, oldNReduce int, newNReduce int) error {
+	var err error = nil
+
+	if newNReduce < 1 {
+		err = errors.New("Repartition requires at least one new partition")
+	}
+
+	for _, i := range w.mapTasks(nMap) {
+		if err != nil {
+			break
+		}
+
+		err = w.repartitionMapTask(i, oldNReduce, newNReduce)
+	}
+
+	if err != nil {
+		w.logf("Function error [Repartition.Repartition]: %s\n", err.Error())
+	}
+
+	return err
+}
+
+//
+// repartitionMapTask
+//
+// Rewrites the intermediate files of one Map task into a new number of partitions (see
+// Repartition).
+//
+//      mapTaskNumber - the number of the Map task whose files are rewritten
+//      oldNReduce    - the number of partitions the files were written for
+//      newNReduce    - the number of partitions to rewrite them into
+//
+// Returns nil on success. Otherwise, the error that failed the rewrite.
+//
+func (w *Worker) repartitionMapTask(mapTaskNumber int, oldNReduce int, newNReduce int) error {
+	var err error = nil
+
+	//
+	// Decode every old partition, assigning each KeyValue to its new partition:
+	//
+	partitions := make([][]KeyValue, newNReduce)
+
+	for r := 0; r < oldNReduce && err == nil; r++ {
+		err = w.forEachIntermediate(mapTaskNumber, r, func(kv KeyValue) error {
+			i := w.partitionFor(w.partitionKey(kv), newNReduce)
+
+			partitions[i] = append(partitions[i], kv)
+
+			return nil
+		})
+
+		err = stageError(ErrDecodeIntermediate, err)
+	}
+
+	//
+	// Keep the new partitions sorted, if the old ones were:
+	//
+	if err == nil && w.SortPartitions {
+		for _, partition := range partitions {
+			sortKeyValues(partition)
+		}
+	}
+
+	//
+	// Remove the files of old partitions beyond the new ones:
+	// *NOTE* A combined file holds every partition, so is simply replaced
+	//
+	for r := newNReduce; r < oldNReduce && err == nil && !w.CombinedLayout; r++ {
+		tempErr := w.removeIntermediateFile(w.reduceName(mapTaskNumber, r))
+
+		if tempErr != nil {
+			// Error removing file
+			err = stageError(ErrWriteIntermediate, tempErr)
+		}
+	}
+
+	//
+	// Write the new partitions, replacing the old files:
+	//
+	if err == nil {
+		stats := MapStats{PartitionRecords: make([]int, newNReduce)}
+
+		out, tempErr := w.writePartitions(nil, mapTaskNumber, partitions, &stats)
+
+		if tempErr == nil {
+			tempErr = out.close()
+		}
+
+		if tempErr != nil && out != nil {
+			// Error writing files
+			tempErr = errors.Join(tempErr, out.remove())
+		}
+
+		err = tempErr
+	}
+
+	return err
+}
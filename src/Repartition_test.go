@@ -0,0 +1,89 @@
+//
+// Repartition_test.go
+//
+// This file contains tests for repartitioning intermediate files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"testing"
+)
+
+//
+// TestRepartition
+//
+// Maps a job for 4 Reduce tasks, repartitions it into 8, and checks that every KeyValue is
+// in the partition Map would have assigned it with 8, and that reducing the 8 partitions
+// gives the job's output. Then repartitions back into 3, and checks that the files of the
+// partitions beyond 3 are removed.
+//
+func TestRepartition(t *testing.T) {
+	w := newTestWorker(t, "repart")
+
+	inputs := []string{testInput, "a fox and a dog", "zebra the yak"}
+
+	for m, input := range inputs {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		if _, err := w.Map(m, inFile, 4, WordCountMap); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, test := range []struct {
+		oldNReduce int
+		newNReduce int
+	}{
+		{4, 8},
+		{8, 3},
+	} {
+		if err := w.Repartition(len(inputs), test.oldNReduce, test.newNReduce); err != nil {
+			t.Fatal(err)
+		}
+
+		for m := range inputs {
+			for r := 0; r < test.newNReduce; r++ {
+				for _, kv := range readKeyValueFile(t, w, w.reduceName(m, r)) {
+					if p := w.partitionFor(kv.Key, test.newNReduce); p != r {
+						t.Fatalf("%d to %d: key %q in partition %d, expected %d", test.oldNReduce, test.newNReduce, kv.Key, r, p)
+					}
+				}
+			}
+
+			for r := test.newNReduce; r < test.oldNReduce; r++ {
+				if _, err := w.storage().Stat(w.reduceName(m, r)); !errors.Is(err, fs.ErrNotExist) {
+					t.Fatalf("%d to %d: old partition file %s not removed: %v", test.oldNReduce, test.newNReduce, w.reduceName(m, r), err)
+				}
+			}
+		}
+
+		for r := 0; r < test.newNReduce; r++ {
+			if _, err := w.Reduce(r, len(inputs), WordCountReduce); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		checkOutput(t, readOutput(t, w, test.newNReduce), countWords(inputs...))
+	}
+}
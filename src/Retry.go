@@ -0,0 +1,173 @@
+//
+// Retry.go
+//
+// This file contains functionality for retrying a 'map' task that failed for a reason that
+// may be transient, e.g. a flaky input mount.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+	"time"
+)
+
+//
+// RetryMap
+//
+// Does the job of a map worker with the default configuration, retrying it on retryable
+// errors (see Worker.RetryMap).
+//
+// 		jobName       - the name of the MapReduce job
+//      attempts      - the maximum number of times the task is run
+//      backoff       - the wait before the first retry, doubled before each later one
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the statistics of the last attempt, and nil on success. Otherwise, the error that
+// failed the last attempt.
+//
+func RetryMap(
+	jobName       string,
+	attempts      int,
+	backoff       time.Duration,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) ([]KeyValue, error),
+) (MapStats, error) {
+	return (&Worker{JobName: jobName}).RetryMap(attempts, backoff, mapTaskNumber, inFile, nReduce, mapFunc)
+}
+
+//
+// RetryMap
+//
+// This function does the job of a map worker like MapErr, but re-runs the task when it fails
+// with a retryable error (see retryableMapError), up to attempts times in all. This keeps the
+// retry policy in one place, rather than in every scheduler.
+//
+// Only failures that may succeed on another try are retried: reading the input
+// (ErrReadInput) and writing the intermediate files (ErrWriteIntermediate), unless the disk
+// is full (ErrDiskFull). A failure of the Map function (ErrMapFunc), of encoding
+// (ErrEncodeIntermediate), or of the configuration would fail again, so it is returned
+// immediately.
+//
+// Every attempt starts from a clean state: the intermediate files of a failed attempt are
+// removed before the next one, even if the failed attempt could not remove them itself.
+// Before each retry, RetryMap waits for the backoff, which doubles after every retry.
+//
+//      attempts      - the maximum number of times the task is run (less than one means once)
+//      backoff       - the wait before the first retry, doubled before each later one
+//      mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the statistics of the last attempt, and nil on success. Otherwise, the error that
+// failed the last attempt.
+//
+func (w *Worker) RetryMap(
+	attempts      int,
+	backoff       time.Duration,
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) ([]KeyValue, error),
+) (MapStats, error) {
+	stats, err := w.MapErr(mapTaskNumber, inFile, nReduce, mapFunc)
+
+	for attempt := 2; attempt <= attempts && retryableMapError(err); attempt++ {
+		w.logf("Function warning [Retry.RetryMap]: attempt %d of %d failed, retrying in %s\n", attempt-1, attempts, backoff)
+
+		//
+		// Clean up after the failed attempt, and wait:
+		//
+		tempErr := w.removeMapOutput(mapTaskNumber, nReduce)
+
+		if tempErr != nil {
+			// Error removing files
+			// *NOTE* Not failing the task, as the next attempt replaces the files anyway
+			w.logf("Function warning [Retry.RetryMap]: %s\n", tempErr.Error())
+		}
+
+		time.Sleep(backoff)
+
+		backoff *= 2
+
+		stats, err = w.MapErr(mapTaskNumber, inFile, nReduce, mapFunc)
+	}
+
+	return stats, err
+}
+
+//
+// retryableMapError
+//
+// Reports whether a Map task that failed with an error may succeed if it is re-run.
+//
+//      err - the error that failed the task
+//
+// Returns true if the error is retryable.
+//
+func retryableMapError(err error) bool {
+	if err == nil || errors.Is(err, ErrDiskFull) {
+		return false
+	}
+
+	return errors.Is(err, ErrReadInput) || errors.Is(err, ErrWriteIntermediate)
+}
+
+//
+// removeMapOutput
+//
+// Removes the intermediate files written by a Map task, with any segments (or its combined
+// file and index, in the combined layout). Files that are already gone are skipped.
+//
+//      mapTaskNumber - the number of the Map task that wrote the files
+//      nReduce       - the number of Reduce tasks that will be run
+//
+// Returns nil on success. Otherwise, the errors removing files (every file is attempted).
+//
+func (w *Worker) removeMapOutput(mapTaskNumber int, nReduce int) error {
+	var errs []error = nil
+
+	if w.CombinedLayout {
+		for _, fileName := range []string{w.combinedName(mapTaskNumber) + ".index", w.combinedName(mapTaskNumber)} {
+			tempErr := w.storage().Remove(fileName)
+
+			if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+				errs = append(errs, tempErr)
+			}
+		}
+	} else {
+		for i := 0; i < nReduce; i++ {
+			tempErr := w.removeIntermediateFile(w.reduceName(mapTaskNumber, i))
+
+			if tempErr != nil {
+				errs = append(errs, tempErr)
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+//
+// Retry_test.go
+//
+// This file contains tests for retrying Map tasks.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+//
+// errTestFlaky
+//
+// The error a flakyStorage's failing opens return.
+//
+var errTestFlaky = errors.New("flaky input mount")
+
+//
+// flakyStorage
+//
+// Wraps a Storage so that its first opens fail, like a flaky input mount. Not safe for
+// concurrent use.
+//
+//      Storage  - the wrapped storage
+//      failures - the number of opens that fail before opens succeed
+//      opens    - the number of opens so far
+//
+type flakyStorage struct {
+	Storage
+	failures int
+	opens    int
+}
+
+func (s *flakyStorage) Open(name string) (io.ReadCloser, error) {
+	s.opens++
+
+	if s.opens <= s.failures {
+		return nil, errTestFlaky
+	}
+
+	return s.Storage.Open(name)
+}
+
+//
+// TestRetryMap
+//
+// Checks that RetryMap re-runs a task whose input fails to open until it succeeds, and gives
+// up after the given number of attempts, returning the last error.
+//
+func TestRetryMap(t *testing.T) {
+	for _, test := range []struct {
+		failures int
+		attempts int
+		opens    int
+		ok       bool
+	}{
+		{0, 3, 1, true},
+		{2, 3, 3, true},
+		{5, 3, 3, false},
+		{1, 0, 1, false},
+	} {
+		w := newTestWorker(t, "retry")
+
+		inFile  := writeInput(t, w, "input", testInput)
+		storage := &flakyStorage{Storage: w.storage(), failures: test.failures}
+
+		w.Storage = storage
+
+		_, err := w.RetryMap(test.attempts, time.Millisecond, 0, inFile, 2, func(file string, contents string) ([]KeyValue, error) {
+			return WordCountMap(file, contents), nil
+		})
+
+		if storage.opens != test.opens {
+			t.Errorf("%d failures, %d attempts: opened %d times, expected %d", test.failures, test.attempts, storage.opens, test.opens)
+		}
+
+		if test.ok {
+			if err != nil {
+				t.Fatalf("%d failures, %d attempts: %v", test.failures, test.attempts, err)
+			}
+
+			for r := 0; r < 2; r++ {
+				if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			checkOutput(t, readOutput(t, w, 2), countWords(testInput))
+		} else if !errors.Is(err, ErrReadInput) || !errors.Is(err, errTestFlaky) {
+			t.Errorf("%d failures, %d attempts: error %v, expected %v", test.failures, test.attempts, err, errTestFlaky)
+		}
+	}
+}
+
+//
+// TestRetryMapNotRetryable
+//
+// Checks that RetryMap does not retry a task failed by its Map function.
+//
+func TestRetryMapNotRetryable(t *testing.T) {
+	w := newTestWorker(t, "noretry")
+
+	inFile := writeInput(t, w, "input", testInput)
+	calls  := 0
+
+	_, err := w.RetryMap(3, time.Millisecond, 0, inFile, 1, func(file string, contents string) ([]KeyValue, error) {
+		calls++
+		return nil, errors.New("bad record")
+	})
+
+	if !errors.Is(err, ErrMapFunc) || calls != 1 {
+		t.Fatalf("error %v after %d calls, expected %v after one", err, calls, ErrMapFunc)
+	}
+}
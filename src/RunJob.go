@@ -0,0 +1,321 @@
+//
+// RunJob.go
+//
+// This file contains functionality for running a whole MapReduce job in this process, with
+// a 'map' worker per input file followed by a 'reduce' worker per partition (see DoMap.go
+// and DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"sync"
+)
+
+//
+// RunJob
+//
+// Runs a MapReduce job with the default configuration (see Worker.RunJob).
+//
+//      jobName    - the name of the MapReduce job
+//      inFiles    - the names of the input files, one per Map task
+//      nReduce    - the number of Reduce tasks to be run
+//      mapFunc    - the user-defined Map function
+//      reduceFunc - the user-defined Reduce function
+//
+// Returns nil on success. Otherwise, the errors of the failed tasks.
+//
+func RunJob(
+	jobName    string,
+	inFiles    []string,
+	nReduce    int,
+	mapFunc    func(file string, contents string) []KeyValue,
+	reduceFunc func(key string, values []string) string,
+) error {
+	return (&Worker{JobName: jobName}).RunJob(inFiles, nReduce, mapFunc, reduceFunc)
+}
+
+//
+// RunJob
+//
+// Runs a MapReduce job: a Map task for each input file accepted by the input filter (see
+// Worker.InputFilter; without one, nMap is len(inFiles)), or for each split of the input
+// files if splitting (see Worker.SplitSize and MapSplit), then, once every Map task has
+// completed, each of the nReduce Reduce tasks. Up to Parallelism tasks of a phase are run
+// at once by a pool of goroutines, so the Worker's functions (e.g. Partitioner, Normalize)
+// and the user-defined functions must be safe for concurrent use.
+// The output does not depend on the parallelism.
+//
+// Once every task has completed, the job's manifest is written if requested (see
+// Worker.EmitManifest). An output that cannot be counted for the manifest (see
+// checkManifest) fails the job before any task runs; an error writing the manifest fails it
+// after, but leaves its complete merge files in place.
+//
+// The first failed task cancels the tasks that have not started yet, and the job stops once
+// the running tasks have finished. Its intermediate files are then removed, with the merge
+// and side files its Reduce tasks created, so that a failed job leaves nothing behind. A
+// merge file that existed before the job, e.g. one being appended to (see
+// Worker.AppendOutput), is kept with its side files, as are the files of an earlier job's
+// manifest.
+//
+//      inFiles    - the names of the input files, one per Map task
+//      nReduce    - the number of Reduce tasks to be run
+//      mapFunc    - the user-defined Map function
+//      reduceFunc - the user-defined Reduce function
+//
+// Returns nil on success. Otherwise, the errors of the failed tasks.
+//
+func (w *Worker) RunJob(
+	inFiles    []string,
+	nReduce    int,
+	mapFunc    func(file string, contents string) []KeyValue,
+	reduceFunc func(key string, values []string) string,
+) error {
+	_, err := w.RunJobWithContext(inFiles, nReduce, func(ctx MapContext, file string, contents string) []KeyValue {
+		return mapFunc(file, contents)
+	}, reduceFunc)
+
+	return err
+}
+
+//
+// RunJobWithContext
+//
+// Runs a MapReduce job like RunJob, but with a context-aware map function (see
+// MapWithContext), and returns the job-wide statistics: the statistics of every Map task
+// added together, with the counters of every task merged (see Counters.Merge). Each task
+// increments its own counters, so the tasks share no state while they run; their counters
+// are merged in task order once every Map task has completed.
+//
+//      inFiles    - the names of the input files, one per Map task
+//      nReduce    - the number of Reduce tasks to be run
+//      mapFunc    - the user-defined, context-aware Map function
+//      reduceFunc - the user-defined Reduce function
+//
+// Returns the job-wide statistics of the Map tasks that ran, and nil on success. Otherwise,
+// the errors of the failed tasks.
+//
+func (w *Worker) RunJobWithContext(
+	inFiles    []string,
+	nReduce    int,
+	mapFunc    func(ctx MapContext, file string, contents string) []KeyValue,
+	reduceFunc func(key string, values []string) string,
+) (MapStats, error) {
+	var status     int      = 0
+	var err        error    = nil
+	var totalStats MapStats = MapStats{}
+
+	inFiles = w.filterInputs(inFiles)
+
+	nMap := len(inFiles)
+
+	if w.EmitManifest {
+		tempErr := w.checkManifest()
+
+		if tempErr != nil {
+			// Manifest could not be written, so run nothing
+			w.logf("Function error [RunJob.RunJobWithContext]: %s\n", tempErr.Error())
+			return totalStats, tempErr
+		}
+	}
+
+	//
+	// Split the input files, if requested:
+	//
+	var splits []InputSplit = nil
+
+	if w.SplitSize > 0 {
+		var tempErr error = nil
+
+		splits, tempErr = w.InputSplits(inFiles, w.SplitSize)
+		nMap            = len(splits)
+
+		if tempErr != nil {
+			status = -1
+			err    = tempErr
+		}
+	}
+
+	//
+	// Run the Map tasks:
+	//
+	mapStats := make([]MapStats, nMap)
+
+	if status == 0 {
+		tempErr := runTasks(w.Parallelism, nMap, func(task int) error {
+			var taskErr error
+
+			if splits != nil {
+				mapStats[task], taskErr = w.mapWithContext(task, nReduce, mapFunc, func(taskMapFunc func(file string, contents string) []KeyValue) (MapStats, error) {
+					return w.MapSplit(task, splits[task], nReduce, taskMapFunc)
+				})
+
+				if taskErr != nil {
+					taskErr = fmt.Errorf("Map task %d (%s): %w", task, splits[task], taskErr)
+				}
+			} else {
+				mapStats[task], taskErr = w.MapWithContext(task, inFiles[task], nReduce, mapFunc)
+
+				if taskErr != nil {
+					taskErr = fmt.Errorf("Map task %d (%s): %w", task, inFiles[task], taskErr)
+				}
+			}
+
+			return taskErr
+		})
+
+		if tempErr != nil {
+			status = -1
+			err    = tempErr
+		}
+
+		for _, stats := range mapStats {
+			totalStats.add(stats)
+		}
+	}
+
+	//
+	// Run the Reduce tasks, noting which ones created their merge file:
+	//
+	var created []bool = nil
+
+	if status == 0 {
+		created = make([]bool, nReduce)
+
+		tempErr := runTasks(w.Parallelism, nReduce, func(task int) error {
+			_, statErr  := w.storage().Stat(w.mergeName(task))
+			_, taskErr := w.Reduce(task, nMap, reduceFunc)
+
+			if taskErr != nil {
+				taskErr = fmt.Errorf("Reduce task %d: %w", task, taskErr)
+			} else {
+				created[task] = errors.Is(statErr, fs.ErrNotExist)
+			}
+
+			return taskErr
+		})
+
+		if tempErr != nil {
+			status = -1
+			err    = tempErr
+		}
+	}
+
+	//
+	// Write the job's manifest, if requested:
+	//
+	if status == 0 && w.EmitManifest {
+		_, tempErr := w.WriteManifest(nMap, nReduce, totalStats)
+
+		if tempErr != nil {
+			// Error writing manifest
+			// *NOTE* Not removing the job's files, as its merge files are complete
+			err = tempErr
+		}
+	}
+
+	//
+	// Handle any error by removing the job's intermediate files, and the outputs it created,
+	// and return:
+	// *NOTE* Merge files that existed before the job (e.g. being appended to) are kept
+	//
+	if status != 0 {
+		errs := []error{w.CleanupJob(nMap, nReduce)}
+
+		for task, isNew := range created {
+			if isNew {
+				errs = append(errs, w.removeReduceOutput(task))
+			}
+		}
+
+		tempErr := errors.Join(errs...)
+
+		if tempErr != nil {
+			w.logf("Function warning [RunJob.RunJobWithContext]: %s\n", tempErr.Error())
+		}
+	}
+
+	return totalStats, err
+}
+
+//
+// runTasks
+//
+// Runs tasks on a pool of goroutines. The first failed task cancels the tasks that have not
+// started yet; tasks that are already running are left to finish.
+//
+//      parallelism - the maximum number of tasks to run at once (zero means one)
+//      nTasks      - the number of tasks, numbered from zero
+//      run         - runs a task
+//
+// Returns nil on success. Otherwise, the errors of the failed tasks, in task order.
+//
+func runTasks(parallelism int, nTasks int, run func(task int) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer cancel()
+
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+
+	errs  := make([]error, nTasks)
+	tasks := make(chan int)
+
+	var waitGroup sync.WaitGroup
+
+	for i := 0; i < parallelism; i++ {
+		waitGroup.Add(1)
+
+		go func() {
+			defer waitGroup.Done()
+
+			for task := range tasks {
+				errs[task] = run(task)
+
+				if errs[task] != nil {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	//
+	// Hand out the tasks until they run out, or a task fails:
+	//
+	feeding := true
+
+	for task := 0; task < nTasks && feeding; task++ {
+		select {
+		case tasks <- task:
+		case <-ctx.Done():
+			feeding = false
+		}
+	}
+
+	close(tasks)
+	waitGroup.Wait()
+
+	return errors.Join(errs...)
+}
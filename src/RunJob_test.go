@@ -0,0 +1,158 @@
+//
+// RunJob_test.go
+//
+// This file contains tests for running whole jobs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+	"strconv"
+	"testing"
+)
+
+//
+// testInputs
+//
+// The inputs of the job tests, one per Map task.
+//
+var testInputs = []string{
+	testInput,
+	"a fox and a dog and a cat",
+	"",
+	"the cat sat on the mat",
+}
+
+//
+// writeInputs
+//
+// Writes the inputs of a job to a Worker's output directory (see writeInput).
+//
+//      t      - the test
+//      w      - the Worker
+//      inputs - the contents of the input files
+//
+// Returns the paths of the input files, in order.
+//
+func writeInputs(t testing.TB, w *Worker, inputs []string) []string {
+	t.Helper()
+
+	var inFiles []string = nil
+
+	for i, input := range inputs {
+		inFiles = append(inFiles, writeInput(t, w, "input-"+strconv.Itoa(i), input))
+	}
+
+	return inFiles
+}
+
+//
+// TestRunJobWordCount
+//
+// Runs a word count job with RunJob, and checks its output.
+//
+func TestRunJobWordCount(t *testing.T) {
+	w := newTestWorker(t, "runjob")
+
+	if err := w.RunJob(writeInputs(t, w, testInputs), 3, WordCountMap, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 3), countWords(testInputs...))
+}
+
+//
+// TestRunJobParallelism
+//
+// Checks that a job's output is the same whatever its parallelism.
+//
+func TestRunJobParallelism(t *testing.T) {
+	expected := countWords(testInputs...)
+
+	for _, parallelism := range []int{0, 1, 2, 8} {
+		w := newTestWorker(t, "parallel")
+		w.Parallelism = parallelism
+
+		if err := w.RunJob(writeInputs(t, w, testInputs), 3, WordCountMap, WordCountReduce); err != nil {
+			t.Fatalf("parallelism %d: %v", parallelism, err)
+		}
+
+		checkOutput(t, readOutput(t, w, 3), expected)
+	}
+}
+
+//
+// TestRunJobFailureKeepsEarlierOutput
+//
+// Fails an appending job's second run in one Reduce task, after another task created its
+// merge file, and checks that the failed run removes the merge file it created and its
+// intermediate files, but keeps the merge file of the first run unchanged.
+//
+func TestRunJobFailureKeepsEarlierOutput(t *testing.T) {
+	w := newTestWorker(t, "keep")
+	w.Partitioner  = RangePartitioner([]string{"m"})
+	w.AppendOutput = true
+	w.SortOutput   = true
+
+	inFiles := writeInputs(t, w, testInputs)
+
+	if err := w.RunJob(inFiles, 2, WordCountMap, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// Leave partition 0 without a merge file, so the second run creates it:
+	//
+	if err := w.storage().Remove(w.mergeName(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	earlier := readFile(t, w, w.mergeName(1))
+
+	err := w.RunJob(inFiles, 2, WordCountMap, func(key string, values []string) string {
+		if key >= "m" {
+			panic("injected reduce failure")
+		}
+
+		return WordCountReduce(key, values)
+	})
+
+	if !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrReduceFunc)
+	}
+
+	if _, err := w.storage().Stat(w.mergeName(0)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("merge file created by the failed run not removed (%v)", err)
+	}
+
+	if output := readFile(t, w, w.mergeName(1)); output != earlier {
+		t.Errorf("earlier merge file holds %q, expected %q", output, earlier)
+	}
+
+	for m := range inFiles {
+		for r := 0; r < 2; r++ {
+			if _, err := w.storage().Stat(w.reduceName(m, r)); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("intermediate file %s not removed (%v)", w.reduceName(m, r), err)
+			}
+		}
+	}
+}
@@ -0,0 +1,93 @@
+//
+// Sampling.go
+//
+// This file contains functionality for 'map' workers that map only a deterministic sample of
+// their input records (see Worker.SampleRate), for approximate jobs over huge inputs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+//
+// sampled
+//
+// Decides whether an input record is in the sample (see Worker.SampleRate). The decision
+// hashes the seed, the name of the input, the input's offset within its file and the
+// record's position in the input, so the same seed selects the same records of an input on
+// every run, whatever the parallelism, without reading the records themselves. Each record
+// is selected with probability SampleRate; the offset tells apart the splits of one file
+// (see MapSplit), whose records are otherwise at the same positions.
+//
+//      name  - the name of the input holding the record
+//      start - the byte offset of the input within its file (zero unless it is a split)
+//      index - the position of the record in the input, from zero
+//
+// Returns true if the record is mapped, false if it is left out.
+//
+func (w *Worker) sampled(name string, start int64, index int) bool {
+	if w.SampleRate <= 0 || w.SampleRate >= 1 {
+		// Not sampling
+		return true
+	}
+
+	var buffer [8]byte
+
+	hash := fnv.New64a()
+
+	binary.LittleEndian.PutUint64(buffer[:], uint64(w.SampleSeed))
+	hash.Write(buffer[:])
+	hash.Write([]byte(name))
+
+	if start != 0 {
+		// *NOTE* Only hashing a split's offset, so whole inputs keep their earlier samples
+		binary.LittleEndian.PutUint64(buffer[:], uint64(start))
+		hash.Write(buffer[:])
+	}
+
+	binary.LittleEndian.PutUint64(buffer[:], uint64(index))
+	hash.Write(buffer[:])
+
+	//
+	// Map the hash's top 53 bits onto [0, 1), and compare with the rate:
+	//
+	return float64(hash.Sum64() >> 11) / (1 << 53) < w.SampleRate
+}
+
+//
+// SampleRate
+//
+// Computes the fraction of input records that were mapped, rather than left out by sampling
+// (see Worker.SampleRate), over every task these statistics cover. Dividing a count over the
+// sample by it estimates the count over the whole input.
+//
+// Returns the effective sample rate: 1 if no record was left out (including when not
+// sampling), 0 if every record was.
+//
+func (s MapStats) SampleRate() float64 {
+	if s.SampledOutRecords == 0 {
+		return 1
+	}
+
+	return float64(s.InputRecords) / float64(s.InputRecords + s.SampledOutRecords)
+}
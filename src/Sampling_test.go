@@ -0,0 +1,162 @@
+//
+// Sampling_test.go
+//
+// This file contains tests for mapping a deterministic sample of the input.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// numberedLines
+//
+// Builds an input of numbered lines.
+//
+//      n - the number of lines
+//
+// Returns the input.
+//
+func numberedLines(n int) string {
+	var builder strings.Builder
+
+	for i := 0; i < n; i++ {
+		builder.WriteString("line-" + strconv.Itoa(i) + "\n")
+	}
+
+	return builder.String()
+}
+
+//
+// sampleLines
+//
+// Runs a Map task over the lines of an input, sampling them with a seed, and collects the
+// lines mapped.
+//
+//      t     - the test
+//      w     - the Worker that runs the task, with its sample rate set
+//      seed  - the seed of the sample
+//      name  - the name of the input
+//      input - the input
+//
+// Returns the lines mapped, in order, and the task's statistics.
+//
+func sampleLines(t *testing.T, w *Worker, seed int64, name string, input string) ([]string, MapStats) {
+	t.Helper()
+
+	w.SampleSeed = seed
+
+	var lines []string = nil
+
+	stats, err := w.MapRecordReader(0, name, NewLineReader(strings.NewReader(input)), 1, func(file string, record string) []KeyValue {
+		lines = append(lines, record)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return lines, stats
+}
+
+//
+// TestSampleSeed
+//
+// Samples the same input twice with each of several seeds, and checks that the same seed
+// selects the same lines, that different seeds select different lines, and that the
+// statistics account for every line and give the fraction mapped.
+//
+func TestSampleSeed(t *testing.T) {
+	const nLines = 1000
+
+	input := numberedLines(nLines)
+
+	w := newTestWorker(t, "sampling")
+	w.SampleRate = 0.1
+
+	var samples [][]string = nil
+
+	for _, seed := range []int64{1, 2, 3} {
+		first, stats := sampleLines(t, w, seed, "input", input)
+		again, _     := sampleLines(t, w, seed, "input", input)
+
+		if !slices.Equal(first, again) {
+			t.Fatalf("seed %d selected %v, then %v", seed, first, again)
+		}
+
+		if stats.InputRecords != len(first) || stats.InputRecords+stats.SampledOutRecords != nLines {
+			t.Fatalf("seed %d: %d records mapped and %d sampled out, expected %d and %d", seed, stats.InputRecords, stats.SampledOutRecords, len(first), nLines-len(first))
+		}
+
+		if rate := float64(len(first)) / nLines; stats.SampleRate() != rate {
+			t.Fatalf("seed %d: sample rate %v, expected %v", seed, stats.SampleRate(), rate)
+		}
+
+		for _, sample := range samples {
+			if slices.Equal(sample, first) {
+				t.Fatalf("seed %d selected the same lines as an earlier seed", seed)
+			}
+		}
+
+		samples = append(samples, first)
+	}
+}
+
+//
+// TestSampleRate
+//
+// Samples many inputs at several rates, and checks that the fraction of lines mapped over
+// them all is close to the rate, and that rates of zero and one map every line.
+//
+func TestSampleRate(t *testing.T) {
+	const nInputs = 50
+	const nLines  = 200
+
+	input := numberedLines(nLines)
+
+	for _, rate := range []float64{0, 0.05, 0.25, 0.5, 1} {
+		w := newTestWorker(t, "sampling")
+		w.SampleRate = rate
+
+		var total MapStats
+
+		for i := 0; i < nInputs; i++ {
+			_, stats := sampleLines(t, w, 7, "input-"+strconv.Itoa(i), input)
+			total.add(stats)
+		}
+
+		expected := rate
+
+		if rate == 0 {
+			expected = 1
+		}
+
+		if math.Abs(total.SampleRate()-expected) > 0.02 {
+			t.Fatalf("rate %v: sampled %v of %d lines", rate, total.SampleRate(), nInputs*nLines)
+		}
+	}
+}
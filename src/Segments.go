@@ -0,0 +1,187 @@
+//
+// Segments.go
+//
+// This file contains functionality for intermediate files that are rolled over into numbered
+// segment files once they grow past a size threshold (see Worker.SegmentSize).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"strconv"
+)
+
+//
+// segmentName
+//
+// Builds the name of a segment of an intermediate file.
+//
+//      fileName - the name of the intermediate file
+//      segment  - the number of the segment, from zero
+//
+// Returns the segment file name (e.g. "mrtmp.job-0-1.2").
+//
+func segmentName(fileName string, segment int) string {
+	return fileName + "." + strconv.Itoa(segment)
+}
+
+//
+// intermediateFiles
+//
+// Finds the files holding an intermediate file's KeyValue pairs: the file itself, or, if it
+// was rolled over, its segments, in order. Segments are numbered contiguously, so the first
+// missing segment ends them.
+//
+//      fileName - the name of the intermediate file
+//
+// Returns the names of the files that exist (nil if none do), and any error other than a
+// file not existing.
+//
+func (w *Worker) intermediateFiles(fileName string) ([]string, error) {
+	var fileNames []string = nil
+
+	_, err := w.storage().Stat(fileName)
+
+	if err == nil {
+		return []string{fileName}, nil
+	}
+
+	for i := 0; err == nil || errors.Is(err, fs.ErrNotExist); i++ {
+		_, err = w.storage().Stat(segmentName(fileName, i))
+
+		if err != nil {
+			// No more segments (or an unexpected error acquiring file stats)
+			break
+		}
+
+		fileNames = append(fileNames, segmentName(fileName, i))
+	}
+
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+
+	return fileNames, err
+}
+
+//
+// removeIntermediateFile
+//
+// Removes an intermediate file and all of its segments, if any. Files that are already gone
+// are skipped. Segments are removed in order, up to the first that is missing or cannot be
+// removed.
+//
+//      fileName - the name of the intermediate file
+//
+// Returns nil on success. Otherwise, the errors removing files.
+//
+func (w *Worker) removeIntermediateFile(fileName string) error {
+	var errs []error = nil
+
+	tempErr := w.storage().Remove(fileName)
+
+	if tempErr != nil && !errors.Is(tempErr, fs.ErrNotExist) {
+		errs = append(errs, tempErr)
+	}
+
+	for i := 0; ; i++ {
+		tempErr = w.storage().Remove(segmentName(fileName, i))
+
+		if tempErr != nil {
+			if !errors.Is(tempErr, fs.ErrNotExist) {
+				// Error removing segment: later segments are left, as they would be unreachable
+				errs = append(errs, tempErr)
+			}
+
+			break
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+//
+// segmentReader
+//
+// Reads the segments of a rolled over intermediate file as one stream of KeyValue pairs,
+// opening each segment once the previous one is exhausted. Each segment is decompressed on
+// its own, as each is a complete file (see partitionWriter.rollOver).
+//
+type segmentReader struct {
+	storage    Storage
+	fileNames  []string
+	bufferSize int
+	file       io.ReadCloser
+	reader     io.Reader
+}
+
+func (s *segmentReader) Read(p []byte) (int, error) {
+	for {
+		if s.file == nil {
+			if len(s.fileNames) == 0 {
+				return 0, io.EOF
+			}
+
+			file, reader, err := openKeyValueFile(s.storage, s.fileNames[0], s.bufferSize)
+
+			if err != nil {
+				return 0, err
+			}
+
+			if file == nil {
+				return 0, &fs.PathError{Op: "open", Path: s.fileNames[0], Err: fs.ErrNotExist}
+			}
+
+			s.file      = file
+			s.reader    = reader
+			s.fileNames = s.fileNames[1:]
+		}
+
+		n, err := s.reader.Read(p)
+
+		if err == io.EOF {
+			// Segment exhausted: move on to the next
+			err    = s.file.Close()
+			s.file = nil
+
+			if n > 0 || err != nil {
+				return n, err
+			}
+
+			continue
+		}
+
+		return n, err
+	}
+}
+
+func (s *segmentReader) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	err   := s.file.Close()
+	s.file = nil
+
+	return err
+}
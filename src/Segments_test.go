@@ -0,0 +1,108 @@
+//
+// Segments_test.go
+//
+// This file contains tests for rolling intermediate files over into segments.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+)
+
+//
+// TestSegments
+//
+// Runs a word count job with a small SegmentSize, plain and gzipped, and checks that each
+// partition is written as several contiguous segments, in place of a single file, that the
+// segments hold every KeyValue Map emitted, and that Reduce reads them all back.
+//
+func TestSegments(t *testing.T) {
+	input := strings.Repeat("alpha bravo charlie delta echo foxtrot golf hotel india juliet ", 20)
+
+	for _, compression := range []Compression{CompressionNone, CompressionGzip} {
+		w := newTestWorker(t, "segments")
+		w.SegmentSize = 64
+		w.Compression = compression
+
+		inFile := writeInput(t, w, "input", input)
+
+		stats, err := w.Map(0, inFile, 2, wordOnesMap)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for r := 0; r < 2; r++ {
+			fileName := w.reduceName(0, r)
+
+			if _, err := w.storage().Stat(fileName); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("%s: partition %d written whole: %v", compression, r, err)
+			}
+
+			segments, err := w.intermediateFiles(fileName)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(segments) < 2 {
+				t.Fatalf("%s: partition %d in %d segments, expected several", compression, r, len(segments))
+			}
+
+			var records int = 0
+
+			for i, segment := range segments {
+				if segment != segmentName(fileName, i) {
+					t.Fatalf("%s: segment %d is %s, expected %s", compression, i, segment, segmentName(fileName, i))
+				}
+
+				records += len(readKeyValueFile(t, w, segment))
+			}
+
+			if records != stats.PartitionRecords[r] {
+				t.Fatalf("%s: partition %d segments hold %d KeyValues, expected %d", compression, r, records, stats.PartitionRecords[r])
+			}
+
+			if _, err := w.Reduce(r, 1, WordCountReduce); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		checkOutput(t, readOutput(t, w, 2), countWords(input))
+
+		if missing := w.MissingPartitions(1, 0); missing != nil {
+			t.Fatalf("%s: partitions of map tasks %v missing, expected none", compression, missing)
+		}
+
+		if err := w.CleanupJob(1, 2); err != nil {
+			t.Fatal(err)
+		}
+
+		for r := 0; r < 2; r++ {
+			if segments, err := w.intermediateFiles(w.reduceName(0, r)); segments != nil || err != nil {
+				t.Fatalf("%s: segments %v left after cleanup: %v", compression, segments, err)
+			}
+		}
+	}
+}
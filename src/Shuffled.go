@@ -0,0 +1,90 @@
+//
+// Shuffled.go
+//
+// This file contains functionality for reducing a partition that was shuffled outside the
+// job: read from a single file holding every KeyValue of the partition, rather than from
+// the output of each Map task (see Worker.PreShuffled).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"io"
+)
+
+//
+// doReduceShuffled
+//
+// Does the job of a reduce worker over a pre-shuffled file, with the default configuration
+// (see Worker.PreShuffled).
+//
+// 		jobName          - the name of the MapReduce job
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task.
+//
+func doReduceShuffled(
+	jobName          string,
+	reduceTaskNumber int,
+	reduceFunc       func(key string, values []string) string,
+) (string, error) {
+	return (&Worker{JobName: jobName, PreShuffled: true}).Reduce(reduceTaskNumber, 0, reduceFunc)
+}
+
+//
+// intermediateTasks
+//
+// Lists the Map tasks whose output a Reduce task reads (see Worker.MapTasks). A pre-shuffled
+// file holds the output of every Map task, so is read once, as if written by Map task 0 (see
+// Worker.PreShuffled).
+//
+//      nMap - the number of Map tasks that were run
+//
+// Returns the Map task numbers to read.
+//
+func (w *Worker) intermediateTasks(nMap int) []int {
+	if w.PreShuffled {
+		return []int{0}
+	}
+
+	return w.mapTasks(nMap)
+}
+
+//
+// openShuffled
+//
+// Opens the pre-shuffled file of a Reduce task for decoding (see openKeyValueFile).
+// *NOTE* A file that does not exist is not treated as an error, and returns a nil file,
+//        like the intermediate files it stands in for
+//
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//
+// Returns the opened file (which the caller must close), the reader to decode it from, the
+// name of the file (for errors), and any error opening it.
+//
+func (w *Worker) openShuffled(reduceTaskNumber int) (io.ReadCloser, io.Reader, string, error) {
+	name := w.shuffleName(reduceTaskNumber)
+
+	file, reader, err := openKeyValueFile(w.storage(), name, w.ReadBufferSize)
+
+	return file, reader, name, err
+}
@@ -0,0 +1,153 @@
+//
+// Shuffled_test.go
+//
+// This file contains tests for reducing from a single externally shuffled file.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// writeShuffled
+//
+// Shuffles the intermediate files of a job as an external system would: writes, for each
+// Reduce task, one pre-shuffled file holding the KeyValues of its partition from every Map
+// task, sorted by key as a whole if requested.
+//
+//      t       - the test
+//      w       - the Worker that ran the Map tasks
+//      nMap    - the number of Map tasks that were run
+//      nReduce - the number of Reduce tasks
+//      sorted  - whether to sort each file by key
+//
+func writeShuffled(t testing.TB, w *Worker, nMap int, nReduce int, sorted bool) {
+	t.Helper()
+
+	for r := 0; r < nReduce; r++ {
+		var keyValues []KeyValue = nil
+
+		for m := 0; m < nMap; m++ {
+			keyValues = append(keyValues, readKeyValueFile(t, w, w.reduceName(m, r))...)
+		}
+
+		if sorted {
+			slices.SortStableFunc(keyValues, func(a KeyValue, b KeyValue) int {
+				return strings.Compare(a.Key, b.Key)
+			})
+		}
+
+		var contents strings.Builder
+
+		for _, kv := range keyValues {
+			encoding, err := json.Marshal(kv)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			contents.Write(encoding)
+			contents.WriteByte('\n')
+		}
+
+		fileName := w.shuffleName(r)
+
+		writeInput(t, &Worker{Storage: w.Storage, OutDir: filepath.Dir(fileName)}, filepath.Base(fileName), contents.String())
+	}
+}
+
+//
+// TestPreShuffled
+//
+// Runs the Map tasks of a word count job, over sorted and unsorted partitions, reduces their
+// intermediate files, then shuffles them into one file per Reduce task and reduces those
+// instead, and checks that both write byte-identical merge files, and that cleaning up the
+// job leaves the pre-shuffled files alone.
+//
+func TestPreShuffled(t *testing.T) {
+	inputs := []string{testInput, "a fox and a dog", "the end of the fox"}
+
+	for _, sorted := range []bool{false, true} {
+		w := newTestWorker(t, "shuffled")
+		w.SortPartitions   = sorted
+		w.PartitionsSorted = sorted
+		w.SortOutput       = true
+
+		checkOutput(t, runWordCount(t, w, inputs, 2), countWords(inputs...))
+
+		var merges []string = nil
+
+		for r := 0; r < 2; r++ {
+			merges = append(merges, readFile(t, w, w.mergeName(r)))
+		}
+
+		writeShuffled(t, w, len(inputs), 2, sorted)
+
+		if err := w.CleanupJob(len(inputs), 2); err != nil {
+			t.Fatal(err)
+		}
+
+		shuffled := *w
+		shuffled.PreShuffled = true
+
+		for r := 0; r < 2; r++ {
+			if _, err := shuffled.Reduce(r, len(inputs), WordCountReduce); err != nil {
+				t.Fatalf("sorted %v: %v", sorted, err)
+			}
+
+			if merge := readFile(t, w, w.mergeName(r)); merge != merges[r] {
+				t.Fatalf("sorted %v: merge file %d %q from the pre-shuffled file, expected %q", sorted, r, merge, merges[r])
+			}
+		}
+
+		if err := shuffled.CleanupJob(len(inputs), 2); err != nil {
+			t.Fatal(err)
+		}
+
+		for r := 0; r < 2; r++ {
+			if _, err := w.storage().Stat(w.shuffleName(r)); err != nil {
+				t.Fatalf("sorted %v: pre-shuffled file %d removed: %v", sorted, r, err)
+			}
+		}
+	}
+}
+
+//
+// TestPreShuffledMissing
+//
+// Checks that a Reduce task whose pre-shuffled file does not exist writes an empty merge
+// file, as it would with no intermediate files.
+//
+func TestPreShuffledMissing(t *testing.T) {
+	w := newTestWorker(t, "shuffled")
+	w.PreShuffled = true
+
+	if _, err := w.Reduce(0, 3, WordCountReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{})
+}
@@ -0,0 +1,168 @@
+//
+// SideOutput.go
+//
+// This file contains functionality for a 'reduce' worker whose reduce function can route
+// records to side outputs (e.g. rejects or anomalies) besides its merge file.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"fmt"
+	"slices"
+	"sync"
+)
+
+//
+// ReduceSide
+//
+// This function does the job of a reduce worker like Reduce, but also passes reduceFunc an
+// emit function that writes a KeyValue to one of the Worker's side outputs (see
+// Worker.SideOutputs), so that one pass over the intermediate files can feed several
+// destinations. The value reduceFunc returns is written to the merge file as usual.
+//
+// Each side output of a task is written to its own side file, named after the merge file:
+// <merge file>.<side output> (see sideName; the extension of a compressed merge file goes
+// last). Side files are written in the output format (see Worker.OutputFormat), in the order
+// the records were emitted (or by key, when sorting the output), once the merge file has
+// been written. Every declared side output gets a side file, even if nothing was emitted to
+// it, so a rerun never leaves a stale one behind; side files are replaced rather than
+// appended to, even when appending.
+//
+// A record is written for every call of reduceFunc that emits it, not only the final call
+// for a key. reduceFunc is also called to reduce partial values when limiting values (see
+// Worker.MaxValuesPerKey), and to re-reduce [existing, new] when appending (see
+// Worker.AppendOutput), so with either, a reduceFunc must expect to see its own output as
+// values and emit accordingly, e.g. only for values it did not produce itself.
+//
+// Side files get the same treatment as the merge file: they are staged as temporary files
+// and renamed into place with it once every output of the task is written (see
+// stageOutputFile), so a task that fails leaves the previous side files in place, a
+// completion marker is only written once they are complete, and a failed job removes them
+// with its merge files (see RunJob). Emitting to a side output that was not declared
+// panics, which fails the task.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the path of the merge file, and nil on success. Otherwise, the error that failed
+// the task; any previous merge file is left in place.
+//
+func (w *Worker) ReduceSide(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string, emit func(output string, kv KeyValue)) string,
+) (string, error) {
+	sides := &sideOutputs{names: w.SideOutputs, keyValues: make(map[string][]KeyValue)}
+
+	sideReduceFunc := func(key string, values []string) string {
+		return reduceFunc(key, values, sides.emit)
+	}
+
+	return w.reduceWithSides(reduceTaskNumber, nMap, sideReduceFunc, sides)
+}
+
+//
+// sideOutputs
+//
+// The records emitted to the side outputs of a Reduce task. Emitting is locked, since a
+// Reduce function that times out is abandoned while it may still be emitting (see
+// callWithTimeout).
+//
+type sideOutputs struct {
+	names     []string
+	mutex     sync.Mutex
+	keyValues map[string][]KeyValue
+}
+
+//
+// emit
+//
+// Adds a KeyValue to a side output.
+//
+//      output - the name of the side output
+//      kv     - the KeyValue to be written to it
+//
+func (s *sideOutputs) emit(output string, kv KeyValue) {
+	if !slices.Contains(s.names, output) {
+		panic(fmt.Sprintf("undeclared side output %q", output))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.keyValues[output] = append(s.keyValues[output], kv)
+}
+
+//
+// sideName
+//
+// Builds the name of the side file of a Reduce task's side output.
+//
+//      reduceTaskNumber - the unique number assigned to the Reduce task
+//      output           - the name of the side output
+//
+// Returns the side file name.
+//
+func (w *Worker) sideName(reduceTaskNumber int, output string) string {
+	return w.mergeStem(reduceTaskNumber) + "." + output + w.OutputCompression.extension()
+}
+
+//
+// stageSideOutputs
+//
+// Writes the side file of each side output of a Reduce task to its temporary file, to be
+// renamed over any existing one with the merge file (see stageOutputFile).
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      sides            - the records emitted to the side outputs
+//
+// Returns the staged side files, and nil on success. Otherwise, the error that failed the
+// write; the files staged before it are still returned, so that the caller can discard
+// them.
+//
+func (w *Worker) stageSideOutputs(reduceTaskNumber int, sides *sideOutputs) ([]stagedFile, error) {
+	var err    error        = nil
+	var staged []stagedFile = nil
+
+	sides.mutex.Lock()
+	defer sides.mutex.Unlock()
+
+	for _, output := range sides.names {
+		keyValues := sides.keyValues[output]
+
+		if w.SortOutput {
+			sortKeyValues(keyValues)
+		}
+
+		sideFile, tempErr := w.stageOutputFile(w.sideName(reduceTaskNumber, output), keyValues)
+
+		if tempErr != nil {
+			// Error writing file
+			err = tempErr
+			break
+		}
+
+		staged = append(staged, sideFile)
+	}
+
+	return staged, err
+}
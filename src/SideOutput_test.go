@@ -0,0 +1,228 @@
+//
+// SideOutput_test.go
+//
+// This file contains tests for Reduce side outputs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+//
+// TestReduceSide
+//
+// Runs a job whose Reduce function writes valid counts to the merge file and routes invalid
+// ones to a "rejects" side output, and checks both files, and that a declared side output
+// with nothing emitted to it still gets an empty side file.
+//
+func TestReduceSide(t *testing.T) {
+	w := newTestWorker(t, "side")
+	w.SideOutputs = []string{"rejects", "anomalies"}
+	w.SortOutput  = true
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	mapFunc := emitMap(KeyValue{"a", "1"}, KeyValue{"b", "x"}, KeyValue{"a", "2"}, KeyValue{"c", "y"})
+
+	if _, err := w.Map(0, inFile, 1, mapFunc); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.ReduceSide(0, 1, func(key string, values []string, emit func(output string, kv KeyValue)) string {
+		var total int = 0
+
+		for _, value := range values {
+			count, tempErr := strconv.Atoi(value)
+
+			if tempErr != nil {
+				emit("rejects", KeyValue{key, value})
+				continue
+			}
+
+			total += count
+		}
+
+		return strconv.Itoa(total)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		fileName string
+		expected []KeyValue
+	}{
+		{w.mergeName(0), []KeyValue{{"a", "3"}, {"b", "0"}, {"c", "0"}}},
+		{w.sideName(0, "rejects"), []KeyValue{{"b", "x"}, {"c", "y"}}},
+		{w.sideName(0, "anomalies"), []KeyValue{}},
+	} {
+		keyValues := readKeyValueFile(t, w, test.fileName)
+
+		if !slices.Equal(keyValues, test.expected) {
+			t.Errorf("%s holds %v, expected %v", test.fileName, keyValues, test.expected)
+		}
+	}
+}
+
+//
+// TestReduceSideUndeclared
+//
+// Checks that emitting to a side output that was not declared fails the task.
+//
+func TestReduceSideUndeclared(t *testing.T) {
+	w := newTestWorker(t, "undeclared")
+	w.SideOutputs = []string{"rejects"}
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.ReduceSide(0, 1, func(key string, values []string, emit func(output string, kv KeyValue)) string {
+		emit("missing", KeyValue{key, values[0]})
+		return values[0]
+	})
+
+	if !errors.Is(err, ErrReduceFunc) {
+		t.Fatalf("error %v, expected %v", err, ErrReduceFunc)
+	}
+}
+
+//
+// createFailStorage
+//
+// Wraps a Storage so that creating one file fails, to test a task that fails part way
+// through writing its outputs.
+//
+//      Storage - the wrapped storage
+//      name    - the path whose creation fails
+//
+type createFailStorage struct {
+	Storage
+	name string
+}
+
+func (s *createFailStorage) Create(name string) (io.WriteCloser, error) {
+	if name == s.name {
+		return nil, errTestWrite
+	}
+
+	return s.Storage.Create(name)
+}
+
+//
+// TestReduceSideFailureKeepsOutput
+//
+// Fails an appending Reduce task while it writes a side file, after its merge file is
+// written, and checks that the merge and side files of the earlier run survive unchanged,
+// with no temporary files left behind.
+//
+func TestReduceSideFailureKeepsOutput(t *testing.T) {
+	w := newTestWorker(t, "sidefail")
+	w.SideOutputs  = []string{"rejects"}
+	w.AppendOutput = true
+	w.SortOutput   = true
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+		t.Fatal(err)
+	}
+
+	sideReduce := func(key string, values []string, emit func(output string, kv KeyValue)) string {
+		emit("rejects", KeyValue{key, values[0]})
+		return WordCountReduce(key, values)
+	}
+
+	if _, err := w.ReduceSide(0, 1, sideReduce); err != nil {
+		t.Fatal(err)
+	}
+
+	mergeName := w.mergeName(0)
+	sideName  := w.sideName(0, "rejects")
+
+	merge := readFile(t, w, mergeName)
+	side  := readFile(t, w, sideName)
+
+	w.Storage = &createFailStorage{Storage: w.storage(), name: sideName + ".tmp"}
+
+	_, err := w.ReduceSide(0, 1, sideReduce)
+
+	if !errors.Is(err, errTestWrite) || !errors.Is(err, ErrWriteMerge) {
+		t.Fatalf("error %v, expected %v and %v", err, errTestWrite, ErrWriteMerge)
+	}
+
+	if contents := readFile(t, w, mergeName); contents != merge {
+		t.Errorf("merge file holds %q, expected %q", contents, merge)
+	}
+
+	if contents := readFile(t, w, sideName); contents != side {
+		t.Errorf("side file holds %q, expected %q", contents, side)
+	}
+
+	for _, fileName := range []string{mergeName + ".tmp", sideName + ".tmp"} {
+		if _, err := w.storage().Stat(fileName); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s left behind (%v)", fileName, err)
+		}
+	}
+}
+
+//
+// TestReduceSidePartialCalls
+//
+// Checks that, when limiting values, the partial reduces of a key emit to side outputs too,
+// as documented, so that one key can emit once per call of reduceFunc.
+//
+func TestReduceSidePartialCalls(t *testing.T) {
+	w := newTestWorker(t, "sidepartial")
+	w.SideOutputs     = []string{"calls"}
+	w.MaxValuesPerKey = 2
+
+	inFile  := writeInput(t, w, "input", testInput)
+	mapFunc := emitMap(KeyValue{"a", "1"}, KeyValue{"a", "1"}, KeyValue{"a", "1"}, KeyValue{"a", "1"})
+
+	if _, err := w.Map(0, inFile, 1, mapFunc); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := w.ReduceSide(0, 1, func(key string, values []string, emit func(output string, kv KeyValue)) string {
+		emit("calls", KeyValue{key, strconv.Itoa(len(values))})
+		return WordCountReduce(key, values)
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if keyValues := readKeyValueFile(t, w, w.sideName(0, "calls")); len(keyValues) < 2 {
+		t.Fatalf("side file holds %v, expected a record per call", keyValues)
+	}
+
+	checkOutput(t, readOutput(t, w, 1), map[string]string{"a": "4"})
+}
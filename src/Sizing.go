@@ -0,0 +1,110 @@
+//
+// Sizing.go
+//
+// This file contains functionality for sizing a MapReduce job, e.g. choosing its number of
+// Reduce tasks from the size of its input.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+
+//
+// Reduce count limits for SuggestNReduce
+//
+const (
+	defaultPartitionBytes = 64 * 1024 * 1024 // the target partition size, unless given
+	minSuggestedNReduce   = 1                // the fewest Reduce tasks suggested
+	maxSuggestedNReduce   = 10000            // the most Reduce tasks suggested
+	nReduceRounding       = 16               // larger counts are rounded up to a multiple
+)
+
+//
+// SuggestNReduce
+//
+// Suggests the number of Reduce tasks for a job, so that each partition of its input is
+// roughly targetPartitionBytes (assuming the Map output is about the size of the input, and
+// spread evenly; see PartitionSkew). A scheduler can call it once it has sized the inputs.
+//
+// The count is the input size divided by the target, rounded up. Counts over
+// nReduceRounding are rounded up to a multiple of it, so that similar inputs get the same
+// count. The count is at least minSuggestedNReduce, and at most maxSuggestedNReduce, since
+// every Map task writes one intermediate file per Reduce task.
+//
+//      totalInputBytes      - the total size of the job's input files
+//      targetPartitionBytes - the desired size of a partition (zero or less means 64 MiB)
+//
+// Returns the suggested number of Reduce tasks.
+//
+func SuggestNReduce(totalInputBytes int64, targetPartitionBytes int64) int {
+	if targetPartitionBytes <= 0 {
+		targetPartitionBytes = defaultPartitionBytes
+	}
+
+	//
+	// Divide, rounding up, without overflowing for huge inputs:
+	//
+	var nReduce int64 = 0
+
+	if totalInputBytes > 0 {
+		nReduce = totalInputBytes / targetPartitionBytes
+
+		if totalInputBytes % targetPartitionBytes != 0 {
+			nReduce++
+		}
+	}
+
+	// Cap before rounding, so that rounding up cannot overflow
+	nReduce = min(nReduce, maxSuggestedNReduce)
+
+	if nReduce > nReduceRounding {
+		nReduce = (nReduce + nReduceRounding - 1) / nReduceRounding * nReduceRounding
+	}
+
+	return int(min(max(nReduce, minSuggestedNReduce), maxSuggestedNReduce))
+}
+
+//
+// EstimatePartitionCapacity
+//
+// Estimates the number of KeyValues each partition of a Map task receives, for preallocating
+// the partitions (see Worker.PartitionCapacity): the task's input size divided by the input
+// bytes per KeyValue emitted, spread evenly over the partitions, rounded up. With a skewed
+// Partitioner, the partitions holding more than their share grow past the estimate as usual.
+//
+//      inputBytes       - the size of the Map task's input
+//      nReduce          - the number of Reduce tasks that will be run
+//      bytesPerKeyValue - the average number of input bytes per KeyValue the map function
+//                         emits, e.g. about 6 for a word count over English text
+//
+// Returns the estimated number of KeyValues per partition (zero if any argument is zero or
+// less, which means no preallocation).
+//
+func EstimatePartitionCapacity(inputBytes int64, nReduce int, bytesPerKeyValue int) int {
+	if inputBytes <= 0 || nReduce <= 0 || bytesPerKeyValue <= 0 {
+		return 0
+	}
+
+	keyValues := inputBytes / int64(bytesPerKeyValue)
+
+	//
+	// Divide over the partitions, rounding up:
+	//
+	return int((keyValues + int64(nReduce) - 1) / int64(nReduce))
+}
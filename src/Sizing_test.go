@@ -0,0 +1,97 @@
+//
+// Sizing_test.go
+//
+// This file contains tests for the sizing helpers.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"math"
+	"testing"
+)
+
+//
+// TestSuggestNReduce
+//
+// Checks the suggested Reduce counts for tiny, medium and huge inputs: at least one task,
+// rounded up to a multiple of nReduceRounding past it, and capped.
+//
+func TestSuggestNReduce(t *testing.T) {
+	const mib = 1024 * 1024
+
+	for _, test := range []struct {
+		inputBytes  int64
+		targetBytes int64
+		expected    int
+	}{
+		// Tiny
+		{0, 0, 1},
+		{100, 0, 1},
+		{64 * mib, 0, 1},
+		{64*mib + 1, 0, 2},
+
+		// Medium
+		{10 * mib, mib, 10},
+		{1024 * mib, 64 * mib, 16},
+		{1024*mib + 1, 64 * mib, 32},
+		{10240 * mib, 64 * mib, 160},
+
+		// Huge
+		{102400 * mib, mib, maxSuggestedNReduce},
+		{math.MaxInt64, 1, maxSuggestedNReduce},
+	} {
+		nReduce := SuggestNReduce(test.inputBytes, test.targetBytes)
+
+		if nReduce != test.expected {
+			t.Errorf("SuggestNReduce(%d, %d) = %d, expected %d", test.inputBytes, test.targetBytes, nReduce, test.expected)
+		}
+	}
+}
+
+//
+// TestEstimatePartitionCapacity
+//
+// Checks the estimated KeyValues per partition, rounded up, and that invalid arguments mean
+// no preallocation.
+//
+func TestEstimatePartitionCapacity(t *testing.T) {
+	for _, test := range []struct {
+		inputBytes       int64
+		nReduce          int
+		bytesPerKeyValue int
+		expected         int
+	}{
+		{6000, 10, 6, 100},
+		{6001, 10, 6, 100},
+		{6006, 10, 6, 101},
+		{5, 3, 6, 0},
+		{0, 10, 6, 0},
+		{6000, 0, 6, 0},
+		{6000, 10, 0, 0},
+		{-1, 10, 6, 0},
+	} {
+		capacity := EstimatePartitionCapacity(test.inputBytes, test.nReduce, test.bytesPerKeyValue)
+
+		if capacity != test.expected {
+			t.Errorf("EstimatePartitionCapacity(%d, %d, %d) = %d, expected %d", test.inputBytes, test.nReduce, test.bytesPerKeyValue, capacity, test.expected)
+		}
+	}
+}
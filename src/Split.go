@@ -0,0 +1,248 @@
+//
+// Split.go
+//
+// This file contains functionality for splitting large input files into byte ranges, so that
+// one file can feed several 'map' workers (see Worker.MapSplit).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//
+// InputSplit
+//
+// A byte range of an input file, mapped by one Map task. The range is aligned to lines when
+// it is read (see Worker.MapSplit), so a split may be given any offsets.
+//
+//      File   - the name of the input file
+//      Start  - the offset of the first byte of the range
+//      Length - the number of bytes in the range
+//
+type InputSplit struct {
+	File   string
+	Start  int64
+	Length int64
+}
+
+//
+// String
+//
+// Returns a description of the split, e.g. "input.txt[0:1024]".
+//
+func (s InputSplit) String() string {
+	return fmt.Sprintf("%s[%d:%d]", s.File, s.Start, s.Start+s.Length)
+}
+
+//
+// inputSplits
+//
+// Splits input files into byte ranges with the default configuration (see
+// Worker.InputSplits).
+//
+//      inFiles   - the names of the input files
+//      splitSize - the target size of each split, in bytes
+//
+// Returns the splits, and nil on success. Otherwise, the error describing a file.
+//
+func inputSplits(inFiles []string, splitSize int64) ([]InputSplit, error) {
+	return (&Worker{}).InputSplits(inFiles, splitSize)
+}
+
+//
+// InputSplits
+//
+// Splits input files into byte ranges of about splitSize bytes each, to be assigned to one
+// Map task apiece (see MapSplit). Every file gets at least one split, so an empty file still
+// has its Map task. The last split of a file holds the remainder, which may be smaller.
+//
+//      inFiles   - the names of the input files
+//      splitSize - the target size of each split, in bytes (zero or less means one split per
+//                  file)
+//
+// Returns the splits, in file order, and nil on success. Otherwise, the error describing a
+// file.
+//
+func (w *Worker) InputSplits(inFiles []string, splitSize int64) ([]InputSplit, error) {
+	var splits []InputSplit = nil
+	var err    error        = nil
+
+	for _, inFile := range inFiles {
+		fileInfo, tempErr := w.storage().Stat(inFile)
+
+		if tempErr != nil {
+			// Error acquiring file stats
+			err = tempErr
+			break
+		}
+
+		size   := fileInfo.Size()
+		length := size
+
+		if splitSize > 0 && splitSize < size {
+			length = splitSize
+		}
+
+		splits = append(splits, InputSplit{File: inFile, Start: 0, Length: length})
+
+		for start := length; start < size; start += length {
+			splits = append(splits, InputSplit{File: inFile, Start: start, Length: min(length, size-start)})
+		}
+	}
+
+	if err != nil {
+		w.logf("Function error [Split.InputSplits]: %s\n", err.Error())
+	}
+
+	return splits, err
+}
+
+//
+// doMapSplit
+//
+// Does the job of a map worker over an input split with the default configuration (see
+// Worker.MapSplit).
+//
+// 		jobName       - the name of the MapReduce job
+//      mapTaskNumber - the unique number assigned to this Map task
+//      split         - the byte range of the input file to be mapped
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func doMapSplit(
+	jobName       string,
+	mapTaskNumber int,
+	split         InputSplit,
+	nReduce       int,
+	mapFunc       func(file string, contents string) []KeyValue,
+) (MapStats, error) {
+	return (&Worker{JobName: jobName}).MapSplit(mapTaskNumber, split, nReduce, mapFunc)
+}
+
+//
+// MapSplit
+//
+// This function does the job of a map worker like Map, but over a byte range of its input
+// file, so that several Map tasks can share one large file. The range is aligned to lines:
+// the split holds every line that starts within it, including the rest of a last line that
+// runs past its end, and none that started before it. Given splits that cover a file without
+// overlapping (see InputSplits), each line is then mapped by exactly one Map task. The
+// split's lines are passed to mapFunc as its contents, with their line endings. A split in
+// which no line starts (e.g. within one long line) has no records, so mapFunc is not called.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      split         - the byte range of the input file to be mapped
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the task's statistics, and nil on success. Otherwise, the error that failed the
+// task.
+//
+func (w *Worker) MapSplit(
+	mapTaskNumber int,
+	split         InputSplit,
+	nReduce       int,
+	mapFunc       func(file string, contents string) []KeyValue,
+) (MapStats, error) {
+	records := func(yield func(string, error) bool) {
+		contents, err := w.readSplit(split)
+
+		if err == nil && contents == "" {
+			// No line starts within the split: no records
+			return
+		}
+
+		yield(contents, err)
+	}
+
+	return mapRecords(w, mapTaskNumber, split.File, split.Start, nReduce, records, func(file string, contents string) ([]KeyValue, error) {
+		return mapFunc(file, contents), nil
+	})
+}
+
+//
+// readSplit
+//
+// Reads the lines of an input split (see MapSplit). Unless the split starts the file, the
+// byte before it is read too: the line it ends (or continues) belongs to the previous split,
+// so everything up to the first line ending is skipped. Lines are then read until one starts
+// at or past the split's end.
+//
+//      split - the byte range of the input file to be read
+//
+// Returns the split's lines, and any error reading them.
+//
+func (w *Worker) readSplit(split InputSplit) (string, error) {
+	var contents strings.Builder
+
+	file, err := w.storage().Open(split.File)
+
+	if err != nil {
+		// Error opening file
+		return "", err
+	}
+
+	defer file.Close()
+
+	position := max(split.Start-1, 0)
+	end      := split.Start + split.Length
+
+	err = skipTo(file, position)
+
+	reader := bufio.NewReader(file)
+
+	//
+	// Skip the rest of the line the previous split ends in:
+	//
+	if err == nil && split.Start > 0 {
+		line, tempErr := reader.ReadString('\n')
+
+		position += int64(len(line))
+		err       = tempErr
+	}
+
+	//
+	// Read each line that starts within the split:
+	//
+	for err == nil && position < end {
+		line, tempErr := reader.ReadString('\n')
+
+		contents.WriteString(line)
+
+		position += int64(len(line))
+		err       = tempErr
+	}
+
+	if errors.Is(err, io.EOF) {
+		// End of file ends the last line
+		err = nil
+	}
+
+	return contents.String(), err
+}
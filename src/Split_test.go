@@ -0,0 +1,138 @@
+//
+// Split_test.go
+//
+// This file contains tests for mapping input files split into byte ranges.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strings"
+	"testing"
+)
+
+//
+// splitInput
+//
+// An input of unique lines of varying lengths, with a blank line, a "\r\n" line ending, and
+// no line ending after its last line.
+//
+const splitInput = "alpha 1\nbravo two 2\ncharlie\r\n\ndelta 4 four quatre\necho\nfoxtrot six\ngolf 7"
+
+//
+// lineMap
+//
+// A Map function that emits a KeyValue of "1" for every line of its contents, keyed by the
+// line without its line ending.
+//
+//      file     - the name of the input file
+//      contents - the lines to be mapped
+//
+// Returns a KeyValue per line, in order.
+//
+func lineMap(file string, contents string) []KeyValue {
+	var keyValues []KeyValue = nil
+
+	for _, line := range strings.SplitAfter(contents, "\n") {
+		if line != "" {
+			keyValues = append(keyValues, KeyValue{strings.TrimRight(line, "\r\n"), "1"})
+		}
+	}
+
+	return keyValues
+}
+
+//
+// TestMapSplitThree
+//
+// Splits a file into 3 ranges, maps each with its own Map task, and checks that every line
+// is reduced exactly once: none lost, none duplicated.
+//
+func TestMapSplitThree(t *testing.T) {
+	w := newTestWorker(t, "split")
+
+	inFile := writeInput(t, w, "input", splitInput)
+
+	splits, err := w.InputSplits([]string{inFile}, int64(len(splitInput)/3+1))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(splits) != 3 {
+		t.Fatalf("%d splits %v, expected 3", len(splits), splits)
+	}
+
+	for m, split := range splits {
+		if _, err := w.MapSplit(m, split, 2, lineMap); err != nil {
+			t.Fatalf("split %s: %v", split, err)
+		}
+	}
+
+	for r := 0; r < 2; r++ {
+		if _, err := w.Reduce(r, len(splits), WordCountReduce); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := make(map[string]string)
+
+	for _, kv := range lineMap(inFile, splitInput) {
+		expected[kv.Key] = "1"
+	}
+
+	checkOutput(t, readOutput(t, w, 2), expected)
+}
+
+//
+// TestReadSplitAlignment
+//
+// Splits a file at every split size, and checks that the splits' lines, in order, are the
+// file's contents exactly, so that no split size cuts a line or maps it twice.
+//
+func TestReadSplitAlignment(t *testing.T) {
+	w := newTestWorker(t, "align")
+
+	inFile := writeInput(t, w, "input", splitInput)
+
+	for splitSize := 1; splitSize <= len(splitInput)+1; splitSize++ {
+		splits, err := w.InputSplits([]string{inFile}, int64(splitSize))
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var joined strings.Builder
+
+		for _, split := range splits {
+			contents, err := w.readSplit(split)
+
+			if err != nil {
+				t.Fatalf("split %s: %v", split, err)
+			}
+
+			joined.WriteString(contents)
+		}
+
+		if joined.String() != splitInput {
+			t.Fatalf("split size %d: splits hold %q, expected %q", splitSize, joined.String(), splitInput)
+		}
+	}
+}
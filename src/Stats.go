@@ -0,0 +1,240 @@
+//
+// Stats.go
+//
+// This file contains the statistics reported by 'map' workers, and computed from them (see
+// DoMap.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"math"
+)
+
+//
+// ErrSkipRecord
+//
+// Returned by a map function (see Worker.MapErr) to skip its input record, rather than fail
+// the task.
+//
+var ErrSkipRecord = errors.New("skip record")
+
+//
+// MapStats
+//
+// The statistics of a Map task.
+//
+//      InputRecords      - the number of input records given to the map function
+//      SkippedRecords    - the number of input records the map function skipped (see
+//                          ErrSkipRecord)
+//      SampledOutRecords - the number of input records left out by sampling (see
+//                          Worker.SampleRate and MapStats.SampleRate)
+//      OutputRecords     - the number of KeyValue pairs the map function emitted
+//      PartitionRecords  - the number of KeyValue pairs written to each Reduce partition
+//      Counters          - the counters the map function incremented (see MapContext), or
+//                          nil
+//
+type MapStats struct {
+	InputRecords      int
+	SkippedRecords    int
+	SampledOutRecords int
+	OutputRecords     int
+	PartitionRecords  []int
+	Counters          Counters `json:",omitempty"`
+}
+
+//
+// Counters
+//
+// Named counters that a map function increments through its MapContext, e.g. to count the
+// records it found malformed. A task's counters are reported in its MapStats.
+//
+type Counters map[string]int64
+
+//
+// Add
+//
+// Adds a delta to a counter, which starts at zero.
+//
+//      name  - the name of the counter
+//      delta - the amount to add (may be negative)
+//
+func (c Counters) Add(name string, delta int64) {
+	c[name] += delta
+}
+
+//
+// Merge
+//
+// Adds every counter of other to the same-named counter of these counters, e.g. to total
+// the counters of a job's Map tasks. Addition does not depend on order, so merging the same
+// counters in any order gives the same totals. Merge is not safe for concurrent use of c;
+// RunJobWithContext merges each task's counters once the task has completed.
+//
+//      other - the counters to be merged (unchanged)
+//
+func (c Counters) Merge(other Counters) {
+	for name, value := range other {
+		c[name] += value
+	}
+}
+
+//
+// add
+//
+// Adds the statistics of another Map task to these statistics.
+//
+//      other - the statistics to be added
+//
+func (s *MapStats) add(other MapStats) {
+	s.InputRecords      += other.InputRecords
+	s.SkippedRecords    += other.SkippedRecords
+	s.SampledOutRecords += other.SampledOutRecords
+	s.OutputRecords     += other.OutputRecords
+
+	for len(s.PartitionRecords) < len(other.PartitionRecords) {
+		s.PartitionRecords = append(s.PartitionRecords, 0)
+	}
+
+	for i, records := range other.PartitionRecords {
+		s.PartitionRecords[i] += records
+	}
+
+	if len(other.Counters) > 0 {
+		if s.Counters == nil {
+			s.Counters = Counters{}
+		}
+
+		s.Counters.Merge(other.Counters)
+	}
+}
+
+//
+// SkewStats
+//
+// Describes how evenly records are distributed across the Reduce partitions of a job, to help
+// find stragglers and choose a better Partitioner.
+//
+//      Min       - the number of records in the smallest partition
+//      Max       - the number of records in the largest partition
+//      Mean      - the mean number of records per partition
+//      StdDev    - the standard deviation of the number of records per partition
+//      Skewed    - the partitions with more than Threshold times the mean number of records
+//      Threshold - the threshold used to find skewed partitions
+//
+type SkewStats struct {
+	Min       int
+	Max       int
+	Mean      float64
+	StdDev    float64
+	Skewed    []int
+	Threshold float64
+}
+
+//
+// PartitionSkew
+//
+// Computes the skew of per-partition record counts, such as MapStats.PartitionRecords (added
+// together across Map tasks; see MapStats.add).
+//
+//      partitionRecords - the number of records in each partition
+//      threshold        - the multiple of the mean above which a partition is skewed
+//                         (e.g. 2 flags partitions with more than twice the mean)
+//
+// Returns the skew statistics. All are zero if there are no partitions.
+//
+func PartitionSkew(partitionRecords []int, threshold float64) SkewStats {
+	skew := SkewStats{Threshold: threshold}
+
+	if len(partitionRecords) == 0 {
+		return skew
+	}
+
+	//
+	// Find the extremes and the mean:
+	//
+	var total int = 0
+
+	skew.Min = partitionRecords[0]
+	skew.Max = partitionRecords[0]
+
+	for _, records := range partitionRecords {
+		skew.Min  = min(skew.Min, records)
+		skew.Max  = max(skew.Max, records)
+		total    += records
+	}
+
+	skew.Mean = float64(total) / float64(len(partitionRecords))
+
+	//
+	// Find the standard deviation, and the skewed partitions:
+	//
+	var variance float64 = 0
+
+	for i, records := range partitionRecords {
+		deviation := float64(records) - skew.Mean
+		variance  += deviation * deviation
+
+		if float64(records) > threshold*skew.Mean {
+			skew.Skewed = append(skew.Skewed, i)
+		}
+	}
+
+	skew.StdDev = math.Sqrt(variance / float64(len(partitionRecords)))
+
+	return skew
+}
+
+//
+// IntermediateSkew
+//
+// Computes the skew of a job's partitions after its map phase, by counting the records in
+// the intermediate files its Reduce tasks will read (see PartitionSkew): those of the Map
+// tasks in MapTasks, from their segments if rolled over, or the pre-shuffled files (see
+// Worker.PreShuffled).
+//
+//      nMap      - the number of Map tasks that were run
+//      nReduce   - the number of Reduce tasks that will be run
+//      threshold - the multiple of the mean above which a partition is skewed
+//
+// Returns the skew statistics, and any error reading the intermediate files.
+//
+func (w *Worker) IntermediateSkew(nMap int, nReduce int, threshold float64) (SkewStats, error) {
+	var err error = nil
+
+	partitionRecords := make([]int, nReduce)
+
+	for i := 0; i < nReduce && err == nil; i++ {
+		for _, j := range w.intermediateTasks(nMap) {
+			err = w.forEachIntermediate(j, i, func(kv KeyValue) error {
+				partitionRecords[i]++
+				return nil
+			})
+
+			if err != nil {
+				// Error reading intermediate file
+				break
+			}
+		}
+	}
+
+	return PartitionSkew(partitionRecords, threshold), err
+}
@@ -0,0 +1,151 @@
+//
+// Stats_test.go
+//
+// This file contains tests for the statistics of tasks and jobs.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// TestPartitionSkew
+//
+// Checks the skew statistics of fixed partition counts.
+//
+func TestPartitionSkew(t *testing.T) {
+	skew := PartitionSkew([]int{10, 10, 10, 70}, 2)
+
+	if skew.Min != 10 || skew.Max != 70 || skew.Mean != 25 || skew.StdDev != 25.98076211353316 {
+		t.Fatalf("statistics %+v", skew)
+	}
+
+	if !slices.Equal(skew.Skewed, []int{3}) {
+		t.Fatalf("skewed partitions %v, expected [3]", skew.Skewed)
+	}
+
+	if PartitionSkew(nil, 2).Skewed != nil {
+		t.Fatal("skewed partitions found with no partitions")
+	}
+}
+
+//
+// TestIntermediateSkew
+//
+// Maps deliberately skewed keys, one of which dominates the input, and checks that only the
+// hot key's partition is flagged.
+//
+func TestIntermediateSkew(t *testing.T) {
+	const nReduce = 4
+
+	w := newTestWorker(t, "skew")
+
+	input  := strings.Repeat("hot ", 1000) + "a b c d e f g h"
+	inFile := writeInput(t, w, "input", input)
+
+	stats, err := w.Map(0, inFile, nReduce, wordOnesMap)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	skew, err := w.IntermediateSkew(1, nReduce, 2)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(skew.Skewed, []int{partitionFor("hot", nReduce)}) {
+		t.Fatalf("skewed partitions %v, expected [%d]", skew.Skewed, partitionFor("hot", nReduce))
+	}
+
+	// The counts of the intermediate files match the task's statistics
+	if expected := PartitionSkew(stats.PartitionRecords, 2); skew.Max != expected.Max || skew.Mean != expected.Mean {
+		t.Fatalf("statistics %+v, expected %+v", skew, expected)
+	}
+}
+
+//
+// TestIntermediateSkewTasks
+//
+// Checks that IntermediateSkew counts the intermediate files the Reduce tasks read: only
+// those of the Map tasks in MapTasks, and each pre-shuffled file once when PreShuffled.
+//
+func TestIntermediateSkewTasks(t *testing.T) {
+	const nMap    = 3
+	const nReduce = 4
+
+	w := newTestWorker(t, "skewtasks")
+
+	var mapStats []MapStats = nil
+
+	for m, input := range []string{strings.Repeat("hot ", 100), "a b c d", "e f g h hot"} {
+		inFile := writeInput(t, w, "input-"+strconv.Itoa(m), input)
+
+		stats, err := w.Map(m, inFile, nReduce, wordOnesMap)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mapStats = append(mapStats, stats)
+	}
+
+	for _, test := range []struct {
+		name        string
+		mapTasks    []int
+		preShuffled bool
+		counted     []int
+	}{
+		{"every task",  nil,         false, []int{0, 1, 2}},
+		{"MapTasks",    []int{0, 2}, false, []int{0, 2}},
+		{"PreShuffled", nil,         true,  []int{0, 1, 2}},
+	} {
+		w.MapTasks    = test.mapTasks
+		w.PreShuffled = test.preShuffled
+
+		if test.preShuffled {
+			writeShuffled(t, w, nMap, nReduce, false)
+		}
+
+		expected := make([]int, nReduce)
+
+		for _, m := range test.counted {
+			for r, records := range mapStats[m].PartitionRecords {
+				expected[r] += records
+			}
+		}
+
+		skew, err := w.IntermediateSkew(nMap, nReduce, 2)
+
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+
+		if want := PartitionSkew(expected, 2); skew.Max != want.Max || skew.Mean != want.Mean {
+			t.Fatalf("%s: statistics %+v, expected %+v", test.name, skew, want)
+		}
+	}
+}
@@ -0,0 +1,387 @@
+//
+// Storage.go
+//
+// This file contains the storage backends that a job's files are read from and written to
+// (see Worker.Storage): the local filesystem by default, or any other store (e.g. an object
+// store) that implements the Storage interface.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//
+// Storage
+//
+// A backend for the files of a job: its input files, intermediate files, merge files, and
+// the files written beside them (indexes, markers, manifests). Names are paths as built by
+// the Worker (see Naming.go); a backend may map them onto keys of its own.
+//
+//      Open   - opens a file for reading; a file that does not exist returns an error
+//               satisfying errors.Is(err, fs.ErrNotExist)
+//      Create - creates a file for writing, replacing any that exists. The file must be
+//               complete once it is closed
+//      Remove - removes a file; a file that does not exist returns an error satisfying
+//               errors.Is(err, fs.ErrNotExist)
+//      Stat   - describes a file, as Open reports a file that does not exist
+//      Rename - renames a file, replacing any at the new name, as a single step where the
+//               backend supports it
+//
+// Optional capabilities are detected from further methods of a backend: Sync() error on a
+// created file (to sync it when durable; see Worker.Durable), SyncDir(name string) error
+// (to sync a renamed file's directory), and MkdirAll(path string, perm fs.FileMode) error
+// (to create partition directories; see Worker.PartitionDirs). Memory mapping (see
+// Worker.MmapIntermediate) requires opened files to be *os.File.
+//
+type Storage interface {
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	Remove(name string) error
+	Stat(name string) (fs.FileInfo, error)
+	Rename(oldName string, newName string) error
+}
+
+//
+// storage
+//
+// Returns the Worker's storage backend, or OSStorage if none is configured.
+//
+func (w *Worker) storage() Storage {
+	if w.Storage == nil {
+		return OSStorage{FileMode: w.FileMode}
+	}
+
+	return w.Storage
+}
+
+//
+// OSStorage
+//
+// Stores files on the local filesystem, through the os package (the default Storage).
+//
+//      FileMode - the permissions of created files, e.g. 0640 so that only the owner and
+//                 group of a shared cluster can read them. They are set exactly, whatever
+//                 the process's umask, and also on a file that already existed (zero means
+//                 os.Create's 0666, masked by the umask)
+//
+type OSStorage struct {
+	FileMode fs.FileMode
+}
+
+func (OSStorage) Open(name string) (io.ReadCloser, error) {
+	file, err := os.Open(name)
+
+	if err != nil {
+		// *NOTE* Returning a nil interface, rather than one holding a nil *os.File
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (s OSStorage) Create(name string) (io.WriteCloser, error) {
+	if s.FileMode == 0 {
+		file, err := os.Create(name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return file, nil
+	}
+
+	file, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, s.FileMode)
+
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	// Set the permissions exactly:
+	// *NOTE* OpenFile masks them by the umask, and leaves those of an existing file as they
+	//        were
+	//
+	err = file.Chmod(s.FileMode)
+
+	if err != nil {
+		// Error setting permissions
+		file.Close()
+		return nil, err
+	}
+
+	return file, nil
+}
+
+func (OSStorage) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSStorage) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSStorage) Rename(oldName string, newName string) error {
+	return os.Rename(oldName, newName)
+}
+
+func (OSStorage) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+//
+// SyncDir
+//
+// Syncs the directory holding a file to disk, so that the file's directory entry (e.g. after
+// a rename) survives a crash, not only its contents.
+// *NOTE* Windows cannot sync a directory, so it is not synced there, as a rename there is
+//        already durable once it returns
+//
+//      name - the name of the file whose directory is synced
+//
+// Returns nil on success. Otherwise, the error opening, syncing or closing the directory.
+//
+func (OSStorage) SyncDir(name string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	dir, err := os.Open(filepath.Dir(name))
+
+	if err != nil {
+		// Error opening directory
+		return err
+	}
+
+	err = dir.Sync()
+
+	tempErr := dir.Close()
+
+	if err == nil {
+		err = tempErr
+	}
+
+	return err
+}
+
+//
+// MemoryStorage
+//
+// Stores files in memory, e.g. to test a job without touching disk, or to run a small job
+// entirely in one process. A created file becomes visible, with its contents, once it is
+// closed, replacing any file of the same name; until then, that file is unchanged. It is
+// safe for concurrent use by the tasks of a job, and its zero value is an empty storage.
+//
+type MemoryStorage struct {
+	mutex sync.Mutex
+	files map[string]*memoryFile
+}
+
+//
+// memoryFile
+//
+// The contents of a file in a MemoryStorage. The contents are replaced, never modified in
+// place, so a reader can hold them without locking.
+//
+type memoryFile struct {
+	data    []byte
+	modTime time.Time
+}
+
+//
+// NewMemoryStorage
+//
+// Creates an empty MemoryStorage (equivalent to new(MemoryStorage)).
+//
+// Returns the new storage.
+//
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{files: make(map[string]*memoryFile)}
+}
+
+func (s *MemoryStorage) Open(name string) (io.ReadCloser, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, exists := s.files[name]
+
+	if !exists {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return memoryReader{bytes.NewReader(file.data)}, nil
+}
+
+func (s *MemoryStorage) Create(name string) (io.WriteCloser, error) {
+	return &memoryWriter{storage: s, name: name}, nil
+}
+
+func (s *MemoryStorage) Remove(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.files[name]; !exists {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(s.files, name)
+
+	return nil
+}
+
+func (s *MemoryStorage) Stat(name string) (fs.FileInfo, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, exists := s.files[name]
+
+	if !exists {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return memoryFileInfo{name: filepath.Base(name), size: int64(len(file.data)), modTime: file.modTime}, nil
+}
+
+func (s *MemoryStorage) Rename(oldName string, newName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	file, exists := s.files[oldName]
+
+	if !exists {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: fs.ErrNotExist}
+	}
+
+	delete(s.files, oldName)
+
+	s.files[newName] = file
+
+	return nil
+}
+
+//
+// memoryReader
+//
+// Reads the contents of a file in a MemoryStorage. Closing it does nothing.
+//
+type memoryReader struct {
+	*bytes.Reader
+}
+
+func (memoryReader) Close() error {
+	return nil
+}
+
+//
+// memoryWriter
+//
+// Writes a file in a MemoryStorage, buffering its contents until it is closed, which
+// publishes them as a new file.
+//
+type memoryWriter struct {
+	storage *MemoryStorage
+	name    string
+	buffer  bytes.Buffer
+}
+
+func (m *memoryWriter) Write(p []byte) (int, error) {
+	return m.buffer.Write(p)
+}
+
+func (m *memoryWriter) Close() error {
+	m.storage.mutex.Lock()
+	defer m.storage.mutex.Unlock()
+
+	if m.storage.files == nil {
+		// Zero value: first file stored
+		m.storage.files = make(map[string]*memoryFile)
+	}
+
+	m.storage.files[m.name] = &memoryFile{data: m.buffer.Bytes(), modTime: time.Now()}
+
+	return nil
+}
+
+//
+// memoryFileInfo
+//
+// Describes a file in a MemoryStorage.
+//
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (i memoryFileInfo) Name() string       { return i.name }
+func (i memoryFileInfo) Size() int64        { return i.size }
+func (i memoryFileInfo) Mode() fs.FileMode  { return 0666 }
+func (i memoryFileInfo) ModTime() time.Time { return i.modTime }
+func (i memoryFileInfo) IsDir() bool        { return false }
+func (i memoryFileInfo) Sys() any           { return nil }
+
+//
+// readStorageFile
+//
+// Reads the whole contents of a file from a storage backend, like os.ReadFile.
+//
+//      storage  - the storage backend holding the file
+//      fileName - the name of the file
+//
+// Returns the file's contents, and any error opening or reading it.
+//
+func readStorageFile(storage Storage, fileName string) ([]byte, error) {
+	file, err := storage.Open(fileName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+//
+// makeDir
+//
+// Creates a directory, and any parents, in a storage backend that has directories (see
+// Storage). Backends without them need none, so this does nothing for them.
+//
+//      storage - the storage backend
+//      path    - the path of the directory
+//
+// Returns nil on success. Otherwise, the error creating the directory.
+//
+func makeDir(storage Storage, path string) error {
+	if dirs, ok := storage.(interface{ MkdirAll(string, fs.FileMode) error }); ok {
+		return dirs.MkdirAll(path, 0777)
+	}
+
+	return nil
+}
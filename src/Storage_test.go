@@ -0,0 +1,209 @@
+//
+// Storage_test.go
+//
+// This file contains tests for the storage backends, including runs of the package's job
+// tests against a MemoryStorage.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//
+// useMemoryStorage
+//
+// Makes newTestWorker build Workers that store their files in a new MemoryStorage each,
+// until the test ends.
+// *NOTE* Not safe with parallel tests, as testStorage is shared
+//
+//      t - the test
+//
+func useMemoryStorage(t testing.TB) {
+	testStorage = func() Storage {
+		return NewMemoryStorage()
+	}
+
+	t.Cleanup(func() {
+		testStorage = nil
+	})
+}
+
+//
+// TestMemoryStorageSuite
+//
+// Runs the package's job tests that reach files only through their Worker's storage against
+// a MemoryStorage, and checks that none of them writes to disk.
+//
+func TestMemoryStorageSuite(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"BinaryRoundTrip", TestBinaryRoundTrip},
+		{"MapCSV", TestMapCSV},
+		{"MapCSVDelimiter", TestMapCSVDelimiter},
+		{"ReduceTypedSum", TestReduceTypedSum},
+		{"CombineThreshold", TestCombineThreshold},
+		{"CombineBudget", TestCombineBudget},
+		{"SkipCompleted", TestSkipCompleted},
+		{"ReduceMixedCompression", TestReduceMixedCompression},
+		{"DecodeArrayAndStream", TestDecodeArrayAndStream},
+		{"DecodeMalformedArray", TestDecodeMalformedArray},
+		{"ReduceDispatch", TestReduceDispatch},
+		{"MapSortPartitions", TestMapSortPartitions},
+		{"MapMaxOutputRecords", TestMapMaxOutputRecords},
+		{"ReduceAppendOutput", TestReduceAppendOutput},
+		{"ReduceNormalize", TestReduceNormalize},
+		{"ReducePartitionsSorted", TestReducePartitionsSorted},
+		{"ReduceDistinctValues", TestReduceDistinctValues},
+		{"ReduceMapTasks", TestReduceMapTasks},
+		{"DisableHTMLEscape", TestDisableHTMLEscape},
+		{"TaskEvents", TestTaskEvents},
+		{"FinalizeAverage", TestFinalizeAverage},
+		{"ReduceTSVEscaping", TestReduceTSVEscaping},
+		{"InsertionOrder", TestInsertionOrder},
+		{"KeyRangeUnion", TestKeyRangeUnion},
+		{"ManifestFormats", TestManifestFormats},
+		{"OnIntermediate", TestOnIntermediate},
+		{"PartitionKey", TestPartitionKey},
+		{"RangePartitionedJobSorted", TestRangePartitionedJobSorted},
+		{"MapRecordReaderFixedWidth", TestMapRecordReaderFixedWidth},
+		{"Repartition", TestRepartition},
+		{"RetryMap", TestRetryMap},
+		{"RunJobWordCount", TestRunJobWordCount},
+		{"RunJobParallelism", TestRunJobParallelism},
+		{"ReduceSide", TestReduceSide},
+		{"IntermediateSkew", TestIntermediateSkew},
+		{"TaskResultSuccess", TestTaskResultSuccess},
+		{"TaskResultFailure", TestTaskResultFailure},
+		{"TopNReduce", TestTopNReduce},
+		{"WordCountUnicode", TestWordCountUnicode},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			useMemoryStorage(t)
+
+			// The test's temporary directories, including its Workers' output directories
+			root := filepath.Dir(t.TempDir())
+
+			test.run(t)
+
+			for _, dir := range listDir(t, root) {
+				if names := listDir(t, filepath.Join(root, dir)); names != nil {
+					t.Errorf("wrote %v to disk", names)
+				}
+			}
+		})
+	}
+}
+
+//
+// TestMemoryStorageZeroValue
+//
+// Checks that the zero MemoryStorage is an empty storage that files can be written to.
+//
+func TestMemoryStorageZeroValue(t *testing.T) {
+	var storage MemoryStorage
+
+	if _, err := storage.Open("file"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("open of a missing file: %v, expected %v", err, fs.ErrNotExist)
+	}
+
+	w := &Worker{JobName: "zero", Storage: &storage}
+
+	checkOutput(t, runWordCount(t, w, []string{testInput}, 2), countWords(testInput))
+}
+
+//
+// TestMemoryStoragePublishOnClose
+//
+// Checks that a file created in a MemoryStorage is not visible until it is closed, leaving
+// the file it replaces unchanged until then, and that its operations on missing files fail
+// with fs.ErrNotExist, as the filesystem's do.
+//
+func TestMemoryStoragePublishOnClose(t *testing.T) {
+	storage := NewMemoryStorage()
+	w       := &Worker{Storage: storage}
+
+	writeInput(t, w, "file", "old")
+
+	file, err := storage.Create("file")
+
+	if err == nil {
+		_, err = io.WriteString(file, "new contents")
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readFile(t, w, "file"); contents != "old" {
+		t.Fatalf("contents %q before close, expected the old file's", contents)
+	}
+
+	if _, err := storage.Stat("other"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("stat before close: %v, expected %v", err, fs.ErrNotExist)
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if contents := readFile(t, w, "file"); contents != "new contents" {
+		t.Fatalf("contents %q after close, expected the new file's", contents)
+	}
+
+	if fileInfo, err := storage.Stat("file"); err != nil || fileInfo.Size() != 12 || fileInfo.Name() != "file" {
+		t.Fatalf("stat %v, %v, expected a 12-byte file", fileInfo, err)
+	}
+
+	//
+	// Rename and remove it, then check that the missing files are reported like the OS does:
+	//
+	if err := storage.Rename("file", "renamed"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := storage.Remove("renamed"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, storage := range []Storage{storage, OSStorage{}} {
+		missing := filepath.Join(t.TempDir(), "missing")
+
+		if _, err := storage.Open(missing); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%T open: %v", storage, err)
+		}
+
+		if err := storage.Remove(missing); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%T remove: %v", storage, err)
+		}
+
+		if err := storage.Rename(missing, missing+"2"); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%T rename: %v", storage, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+//
+// TaskResult.go
+//
+// This file contains functionality for reporting the outcome of a Map or Reduce task in one
+// uniform structure, e.g. for a scheduler's log or UI.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"time"
+)
+
+//
+// TaskResult
+//
+// The outcome of a Map or Reduce task. It is filled in whether the task succeeded or not, so
+// that the progress of a failed task is visible too.
+//
+//      Success      - whether the task succeeded (Err is nil)
+//      Duration     - how long the task ran
+//      RecordsIn    - the number of records read: input records for Map, intermediate
+//                     KeyValue pairs for Reduce
+//      RecordsOut   - the number of KeyValue pairs written: to the intermediate files for
+//                     Map, to the merge file for Reduce
+//      BytesWritten - the size of the output files that exist when the task returns
+//      OutputPaths  - the output files that exist when the task returns: the intermediate
+//                     (or combined) files for Map, the merge file for Reduce
+//      Err          - the error that failed the task, or nil
+//
+type TaskResult struct {
+	Success      bool
+	Duration     time.Duration
+	RecordsIn    int
+	RecordsOut   int
+	BytesWritten int64
+	OutputPaths  []string
+	Err          error
+}
+
+//
+// MapTask
+//
+// This function does the job of a map worker like Map, but reports its outcome as a
+// TaskResult, and sends its lifecycle events to the Worker's events channel, if any (see
+// Worker.Events): TaskStarted, a TaskProgress every progressEventRecords input records, then
+// TaskCompleted or TaskFailed.
+//
+// 		mapTaskNumber - the unique number assigned to this Map task
+//      inFile        - the name of the input file
+//      nReduce       - the number of Reduce tasks that will be run
+//      mapFunc		  - the user-defined Map function
+//
+// Returns the outcome of the task.
+//
+func (w *Worker) MapTask(
+	mapTaskNumber int,
+	inFile        string,
+	nReduce       int,
+	mapFunc       func(file string, contents string) []KeyValue,
+) TaskResult {
+	start := time.Now()
+
+	w.sendEvent(TaskStarted, false, mapTaskNumber, 0, nil)
+
+	//
+	// Count the input records through the Map function, to report progress:
+	//
+	var records int = 0
+
+	stats, err := w.Map(mapTaskNumber, inFile, nReduce, func(file string, contents string) []KeyValue {
+		records++
+
+		if records%progressEventRecords == 0 {
+			w.sendEvent(TaskProgress, false, mapTaskNumber, records, nil)
+		}
+
+		return mapFunc(file, contents)
+	})
+
+	result := TaskResult{RecordsIn: stats.InputRecords, Err: err}
+
+	for _, records := range stats.PartitionRecords {
+		result.RecordsOut += records
+	}
+
+	//
+	// Find the output files:
+	//
+	var fileNames []string = nil
+
+	if w.CombinedLayout {
+		fileNames = []string{w.combinedName(mapTaskNumber), w.combinedName(mapTaskNumber) + ".index"}
+	} else {
+		for i := 0; i < nReduce; i++ {
+			// A rolled over file is output as its segments
+			segments, tempErr := w.intermediateFiles(w.reduceName(mapTaskNumber, i))
+
+			if tempErr != nil || len(segments) == 0 {
+				segments = []string{w.reduceName(mapTaskNumber, i)}
+			}
+
+			fileNames = append(fileNames, segments...)
+		}
+	}
+
+	result.addOutputs(w.storage(), fileNames)
+
+	result.Success  = err == nil
+	result.Duration = time.Since(start)
+
+	w.sendResultEvent(false, mapTaskNumber, result)
+
+	return result
+}
+
+//
+// ReduceTask
+//
+// This function does the job of a reduce worker like Reduce, but reports its outcome as a
+// TaskResult, and sends its lifecycle events like MapTask, with a TaskProgress every
+// progressEventRecords intermediate records. A task skipped as already completed (see
+// Worker.SkipCompleted) reads and writes no records.
+//
+//      reduceTaskNumber - the unique number assigned to this Reduce task
+//      nMap			 - the number of Map tasks that were run
+//      reduceFunc       - the user-defined Reduce function
+//
+// Returns the outcome of the task.
+//
+func (w *Worker) ReduceTask(
+	reduceTaskNumber int,
+	nMap             int,
+	reduceFunc       func(key string, values []string) string,
+) TaskResult {
+	start := time.Now()
+
+	var result TaskResult
+
+	w.sendEvent(TaskStarted, true, reduceTaskNumber, 0, nil)
+
+	//
+	// Count the records through a copy of the Worker's hooks, leaving the Worker unchanged:
+	// *NOTE* The output filter is the last step before encoding, so sees the records written
+	//
+	counting := *w
+
+	counting.OnIntermediate = func(kv KeyValue) {
+		result.RecordsIn++
+
+		if result.RecordsIn%progressEventRecords == 0 {
+			w.sendEvent(TaskProgress, true, reduceTaskNumber, result.RecordsIn, nil)
+		}
+
+		if w.OnIntermediate != nil {
+			w.OnIntermediate(kv)
+		}
+	}
+
+	counting.OutputFilter = func(keyValues []KeyValue) []KeyValue {
+		if w.OutputFilter != nil {
+			keyValues = w.OutputFilter(keyValues)
+		}
+
+		result.RecordsOut = len(keyValues)
+
+		return keyValues
+	}
+
+	_, err := counting.Reduce(reduceTaskNumber, nMap, reduceFunc)
+
+	result.addOutputs(w.storage(), []string{w.mergeName(reduceTaskNumber)})
+
+	result.Err      = err
+	result.Success  = err == nil
+	result.Duration = time.Since(start)
+
+	w.sendResultEvent(true, reduceTaskNumber, result)
+
+	return result
+}
+
+//
+// addOutputs
+//
+// Adds the output files of a task that exist to its result, with their sizes.
+//
+//      storage   - the storage backend holding the files
+//      fileNames - the output files the task may have written
+//
+func (r *TaskResult) addOutputs(storage Storage, fileNames []string) {
+	for _, fileName := range fileNames {
+		fileInfo, tempErr := storage.Stat(fileName)
+
+		if tempErr == nil {
+			r.OutputPaths   = append(r.OutputPaths, fileName)
+			r.BytesWritten += fileInfo.Size()
+		}
+	}
+}
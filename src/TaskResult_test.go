@@ -0,0 +1,144 @@
+//
+// TaskResult_test.go
+//
+// This file contains tests for the results of Map and Reduce tasks.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+//
+// checkOutputPaths
+//
+// Fails the test if a task's result does not list exactly the given output files, with
+// their total size.
+//
+//      t         - the test
+//      w         - the Worker that ran the task
+//      result    - the task's result
+//      fileNames - the expected output files
+//
+func checkOutputPaths(t testing.TB, w *Worker, result TaskResult, fileNames []string) {
+	t.Helper()
+
+	var size int64 = 0
+
+	for _, fileName := range fileNames {
+		fileInfo, err := w.storage().Stat(fileName)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		size += fileInfo.Size()
+	}
+
+	if !slices.Equal(result.OutputPaths, fileNames) || result.BytesWritten != size {
+		t.Fatalf("outputs %v of %d bytes, expected %v of %d bytes", result.OutputPaths, result.BytesWritten, fileNames, size)
+	}
+}
+
+//
+// TestTaskResultSuccess
+//
+// Checks the results of a Map and a Reduce task that succeed: the records read and written,
+// and the output files with their size.
+//
+func TestTaskResultSuccess(t *testing.T) {
+	w := newTestWorker(t, "result")
+
+	inFile := writeInput(t, w, "input", testInput)
+	words  := len(countWords(testInput))
+
+	result := w.MapTask(0, inFile, 2, WordCountMap)
+
+	if !result.Success || result.Err != nil || result.Duration <= 0 {
+		t.Fatalf("map task: success %v, error %v, duration %s", result.Success, result.Err, result.Duration)
+	}
+
+	if result.RecordsIn != 1 || result.RecordsOut != words {
+		t.Fatalf("map task: %d records in, %d out, expected 1 in, %d out", result.RecordsIn, result.RecordsOut, words)
+	}
+
+	checkOutputPaths(t, w, result, []string{w.reduceName(0, 0), w.reduceName(0, 1)})
+
+	//
+	// Together, the Reduce tasks read and write every distinct word once:
+	//
+	var recordsIn  int = 0
+	var recordsOut int = 0
+
+	for r := 0; r < 2; r++ {
+		result = w.ReduceTask(r, 1, WordCountReduce)
+
+		if !result.Success || result.Err != nil {
+			t.Fatalf("reduce task %d: success %v, error %v", r, result.Success, result.Err)
+		}
+
+		checkOutputPaths(t, w, result, []string{w.mergeName(r)})
+
+		recordsIn  += result.RecordsIn
+		recordsOut += result.RecordsOut
+	}
+
+	if recordsIn != words || recordsOut != words {
+		t.Fatalf("reduce tasks: %d records in, %d out, expected %d of each", recordsIn, recordsOut, words)
+	}
+}
+
+//
+// TestTaskResultFailure
+//
+// Checks the results of a Map and a Reduce task that fail: the error, no output files, and
+// the records read before the failure.
+//
+func TestTaskResultFailure(t *testing.T) {
+	w := newTestWorker(t, "failure")
+
+	result := w.MapTask(0, filepath.Join(w.OutDir, "missing"), 1, WordCountMap)
+
+	if result.Success || !errors.Is(result.Err, ErrReadInput) || result.OutputPaths != nil || result.BytesWritten != 0 {
+		t.Fatalf("map task: success %v, error %v, outputs %v of %d bytes", result.Success, result.Err, result.OutputPaths, result.BytesWritten)
+	}
+
+	inFile := writeInput(t, w, "input", testInput)
+
+	if result = w.MapTask(0, inFile, 1, WordCountMap); !result.Success {
+		t.Fatal(result.Err)
+	}
+
+	result = w.ReduceTask(0, 1, func(key string, values []string) string {
+		panic("bad key")
+	})
+
+	if result.Success || !errors.Is(result.Err, ErrReduceFunc) || result.OutputPaths != nil {
+		t.Fatalf("reduce task: success %v, error %v, outputs %v", result.Success, result.Err, result.OutputPaths)
+	}
+
+	if words := len(countWords(testInput)); result.RecordsIn != words || result.RecordsOut != 0 {
+		t.Fatalf("reduce task: %d records in, %d out, expected %d in, none out", result.RecordsIn, result.RecordsOut, words)
+	}
+}
@@ -0,0 +1,153 @@
+//
+// Tokenizer.go
+//
+// This file contains functionality for splitting text into words, the building block of most
+// text Map functions (see WordCount.go and Grep.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"iter"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//
+// Tokenizer
+//
+// Splits text into words (tokens). The zero value splits on every rune that is not a
+// Unicode letter or combining mark, like Tokenize, so that a decomposed letter such as "e"
+// followed by U+0301 stays in its word.
+//
+// Tokens are substrings of the text, so splitting allocates nothing per token unless it is
+// lowercased (and has uppercase letters); Tokens streams them without building a slice at
+// all, for large inputs.
+//
+//      Separator - reports whether a rune separates tokens, e.g. unicode.IsSpace to split on
+//                  whitespace only (nil means every rune that is not a letter or mark)
+//      Lowercase - whether tokens are lowercased (see strings.ToLower)
+//      Stopwords - the tokens to be dropped, e.g. "the" and "a", compared after lowercasing
+//                  (nil means none)
+//
+type Tokenizer struct {
+	Separator func(r rune) bool
+	Lowercase bool
+	Stopwords map[string]bool
+}
+
+//
+// Tokens
+//
+// Splits text into tokens, yielding them one at a time.
+//
+//      contents - the text to be split
+//
+// Returns the sequence of tokens, in order.
+//
+func (t *Tokenizer) Tokens(contents string) iter.Seq[string] {
+	isSeparator := t.Separator
+
+	if isSeparator == nil {
+		isSeparator = isNotLetter
+	}
+
+	return func(yield func(token string) bool) {
+		start := -1
+
+		for i := 0; i <= len(contents); {
+			//
+			// Decode the next rune (treating the end of the text as a separator):
+			//
+			var r    rune = ' '
+			var size int  = 1
+
+			if i < len(contents) {
+				r, size = utf8.DecodeRuneInString(contents[i:])
+			}
+
+			if i < len(contents) && !isSeparator(r) {
+				if start < 0 {
+					start = i
+				}
+			} else if start >= 0 {
+				if !t.yieldToken(contents[start:i], yield) {
+					return
+				}
+
+				start = -1
+			}
+
+			i += size
+		}
+	}
+}
+
+//
+// Tokenize
+//
+// Splits text into tokens (see Tokens).
+//
+//      contents - the text to be split
+//
+// Returns the tokens, in order.
+//
+func (t *Tokenizer) Tokenize(contents string) []string {
+	var tokens []string = nil
+
+	for token := range t.Tokens(contents) {
+		tokens = append(tokens, token)
+	}
+
+	return tokens
+}
+
+//
+// yieldToken
+//
+// Lowercases a token if requested, and yields it unless it is a stopword.
+//
+//      token - the token
+//      yield - receives the token
+//
+// Returns false if yield asked to stop.
+//
+func (t *Tokenizer) yieldToken(token string, yield func(token string) bool) bool {
+	if t.Lowercase {
+		token = strings.ToLower(token)
+	}
+
+	if t.Stopwords[token] {
+		return true
+	}
+
+	return yield(token)
+}
+
+//
+// isNotLetter
+//
+// Reports whether a rune is neither a Unicode letter nor a combining mark (the default
+// separator of a Tokenizer).
+//
+func isNotLetter(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsMark(r)
+}
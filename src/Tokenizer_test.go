@@ -0,0 +1,106 @@
+//
+// Tokenizer_test.go
+//
+// This file contains tests for the Tokenizer.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+//
+// TestTokenizer
+//
+// Checks the tokens of CJK text, punctuation and mixed scripts, with the default and
+// whitespace separators, lowercasing, and stopwords.
+//
+func TestTokenizer(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		tokenizer Tokenizer
+		contents  string
+		expected  []string
+	}{
+		{"empty", Tokenizer{}, "", nil},
+		{"separators only", Tokenizer{}, " ,.!? 123 \n", nil},
+		{"punctuation", Tokenizer{}, "don't stop--believing!", []string{"don", "t", "stop", "believing"}},
+		{"CJK", Tokenizer{}, "日本語のテキスト、中文。한국어", []string{"日本語のテキスト", "中文", "한국어"}},
+		{"mixed scripts", Tokenizer{}, "Привет, world! Γειά σου 世界", []string{"Привет", "world", "Γειά", "σου", "世界"}},
+		{"combining marks", Tokenizer{}, "naïve cafe\u0301 देवनागरी", []string{"naïve", "cafe\u0301", "देवनागरी"}},
+		{"invalid UTF-8", Tokenizer{}, "ab\xffcd", []string{"ab", "cd"}},
+		{"whitespace", Tokenizer{Separator: unicode.IsSpace}, "don't  stop　日本", []string{"don't", "stop", "日本"}},
+		{"lowercase", Tokenizer{Lowercase: true}, "ÉCOLE École ΣΟΦΙΑ", []string{"école", "école", "σοφια"}},
+		{
+			"stopwords",
+			Tokenizer{Lowercase: true, Stopwords: map[string]bool{"the": true, "a": true}},
+			"The cat and a dog, THE end",
+			[]string{"cat", "and", "dog", "end"},
+		},
+	} {
+		if tokens := test.tokenizer.Tokenize(test.contents); !slices.Equal(tokens, test.expected) {
+			t.Errorf("%s: tokens %q, expected %q", test.name, tokens, test.expected)
+		}
+	}
+}
+
+//
+// TestTokenizerStop
+//
+// Checks that Tokens stops splitting when its consumer stops.
+//
+func TestTokenizerStop(t *testing.T) {
+	var tokens []string = nil
+
+	for token := range (&Tokenizer{}).Tokens("one two three four") {
+		tokens = append(tokens, token)
+
+		if len(tokens) == 2 {
+			break
+		}
+	}
+
+	if !slices.Equal(tokens, []string{"one", "two"}) {
+		t.Fatalf("tokens %q, expected the first two", tokens)
+	}
+}
+
+//
+// TestTokenizerAllocations
+//
+// Checks that streaming the tokens of a large text allocates nothing per token.
+//
+func TestTokenizerAllocations(t *testing.T) {
+	contents  := strings.Repeat("the quick brown fox, 日本語! ", 1000)
+	tokenizer := &Tokenizer{}
+
+	allocs := testing.AllocsPerRun(10, func() {
+		for range tokenizer.Tokens(contents) {
+		}
+	})
+
+	if allocs > 2 {
+		t.Fatalf("%v allocations per run over 5000 tokens, expected a constant few", allocs)
+	}
+}
@@ -0,0 +1,128 @@
+//
+// TopN.go
+//
+// This file contains functionality for keeping only the top-ranked results of a Reduce task,
+// e.g. for leaderboards and trending reports (see Worker.OutputFilter).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"container/heap"
+	"slices"
+)
+
+//
+// TopN
+//
+// Builds an output filter (see Worker.OutputFilter) that keeps the n highest-scoring results
+// of a Reduce task and discards the rest. Results are scored by their reduced values, and
+// ties are broken by key, so the selection is deterministic.
+//
+// The candidates are held in a min-heap of at most n results, whose root is the lowest
+// scoring kept so far, so selecting them takes O(n) memory beyond the task's results, and
+// O(r log n) time for r results. Note that the results themselves are held in memory by
+// Reduce until they are filtered, and that each Reduce task keeps its own top n: a job's
+// overall top n is the top n of its tasks' merge files (e.g. with nReduce = 1).
+//
+//      n    - the number of results to keep
+//      less - reports whether value a scores lower than value b (e.g. numerically)
+//
+// Returns the filter, which returns the kept results from highest to lowest score.
+//
+func TopN(n int, less func(a, b string) bool) func(keyValues []KeyValue) []KeyValue {
+	return func(keyValues []KeyValue) []KeyValue {
+		candidates := &topNHeap{less: less}
+
+		for _, kv := range keyValues {
+			if candidates.Len() < n {
+				heap.Push(candidates, kv)
+			} else if n > 0 && candidates.lessKeyValue(candidates.keyValues[0], kv) {
+				candidates.keyValues[0] = kv
+				heap.Fix(candidates, 0)
+			}
+		}
+
+		//
+		// Pop from lowest to highest score, then reverse:
+		//
+		top := make([]KeyValue, 0, candidates.Len())
+
+		for candidates.Len() > 0 {
+			top = append(top, heap.Pop(candidates).(KeyValue))
+		}
+
+		slices.Reverse(top)
+
+		return top
+	}
+}
+
+//
+// topNHeap
+//
+// A min-heap of KeyValues ordered by score, then by key (see container/heap).
+//
+type topNHeap struct {
+	keyValues []KeyValue
+	less      func(a, b string) bool
+}
+
+//
+// lessKeyValue
+//
+// Reports whether KeyValue a ranks below KeyValue b: by score, then by key in reverse, so
+// that of two equal scores the smaller key ranks higher.
+//
+func (h *topNHeap) lessKeyValue(a KeyValue, b KeyValue) bool {
+	if h.less(a.Value, b.Value) {
+		return true
+	}
+
+	if h.less(b.Value, a.Value) {
+		return false
+	}
+
+	return a.Key > b.Key
+}
+
+func (h *topNHeap) Len() int {
+	return len(h.keyValues)
+}
+
+func (h *topNHeap) Less(i, j int) bool {
+	return h.lessKeyValue(h.keyValues[i], h.keyValues[j])
+}
+
+func (h *topNHeap) Swap(i, j int) {
+	h.keyValues[i], h.keyValues[j] = h.keyValues[j], h.keyValues[i]
+}
+
+func (h *topNHeap) Push(x any) {
+	h.keyValues = append(h.keyValues, x.(KeyValue))
+}
+
+func (h *topNHeap) Pop() any {
+	last := h.keyValues[len(h.keyValues)-1]
+
+	h.keyValues = h.keyValues[:len(h.keyValues)-1]
+
+	return last
+}
@@ -0,0 +1,99 @@
+//
+// TopN_test.go
+//
+// This file contains tests for the top-N output filter.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// numericLess
+//
+// Compares two values as integers, for scoring counts.
+//
+//      a - the first value
+//      b - the second value
+//
+// Returns whether a is less than b.
+//
+func numericLess(a, b string) bool {
+	x, _ := strconv.Atoi(a)
+	y, _ := strconv.Atoi(b)
+
+	return x < y
+}
+
+//
+// TestTopN
+//
+// Checks that TopN keeps the n highest-scoring results, from highest to lowest score, with
+// ties broken by key, and keeps every result when there are fewer than n.
+//
+func TestTopN(t *testing.T) {
+	keyValues := []KeyValue{
+		{"a", "3"}, {"b", "10"}, {"c", "7"}, {"d", "1"}, {"e", "7"}, {"f", "12"}, {"g", "2"},
+	}
+
+	for _, test := range []struct {
+		n        int
+		expected []KeyValue
+	}{
+		{0, []KeyValue{}},
+		{1, []KeyValue{{"f", "12"}}},
+		{4, []KeyValue{{"f", "12"}, {"b", "10"}, {"c", "7"}, {"e", "7"}}},
+		{3, []KeyValue{{"f", "12"}, {"b", "10"}, {"c", "7"}}},
+		{10, []KeyValue{{"f", "12"}, {"b", "10"}, {"c", "7"}, {"e", "7"}, {"a", "3"}, {"g", "2"}, {"d", "1"}}},
+	} {
+		top := TopN(test.n, numericLess)(slices.Clone(keyValues))
+
+		if !slices.Equal(top, test.expected) {
+			t.Errorf("top %d: %v, expected %v", test.n, top, test.expected)
+		}
+	}
+}
+
+//
+// TestTopNReduce
+//
+// Runs a word count job with TopN as the output filter of a single Reduce task, and checks
+// that its merge file holds only the most frequent words, in order.
+//
+func TestTopNReduce(t *testing.T) {
+	w := newTestWorker(t, "topn")
+	w.OutputFilter = TopN(2, numericLess)
+
+	input := strings.Repeat("fox ", 5) + strings.Repeat("dog ", 3) + "cat cat hen"
+
+	runWordCount(t, w, []string{input}, 1)
+
+	keyValues := readKeyValueFile(t, w, w.mergeName(0))
+	expected  := []KeyValue{{"fox", "5"}, {"dog", "3"}}
+
+	if !slices.Equal(keyValues, expected) {
+		t.Fatalf("merge file %v, expected %v", keyValues, expected)
+	}
+}
@@ -0,0 +1,124 @@
+//
+// Truncation_test.go
+//
+// This file contains tests for tolerating truncated intermediate files.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+)
+
+//
+// TestTolerateTruncation
+//
+// Reduces, over sorted and unsorted partitions, a complete intermediate file with one cut
+// short part way through a KeyValue, as by a Map task that crashed, in both the stream and
+// array formats. Checks that with TolerateTruncation the KeyValues before the cut are kept
+// and a warning is logged, and that without it the task fails.
+//
+func TestTolerateTruncation(t *testing.T) {
+	tests := []struct {
+		name      string
+		truncated string
+	}{
+		{"stream", formatMagic + "1\n" + `{"Key":"a","Value":"1"}` + "\n" + `{"Key":"b","Value":"1"}` + "\n" + `{"Key":"c","Va`},
+		{"array",  `[{"Key":"a","Value":"1"},{"Key":"b","Value":"1"},{"Key":"c","Value":"1"}`},
+		{"packed", `{"Key":"a","Value":"1"}{"Key":"b","Value":"1"}{"Ke`},
+	}
+
+	for _, test := range tests {
+		for _, sorted := range []bool{false, true} {
+			for _, tolerate := range []bool{false, true} {
+				var logs bytes.Buffer
+
+				w := newTestWorker(t, "truncation")
+				w.SortPartitions     = sorted
+				w.PartitionsSorted   = sorted
+				w.TolerateTruncation = tolerate
+				w.Logger             = log.New(&logs, "", 0)
+
+				inFile := writeInput(t, w, "input", "a b c")
+
+				if _, err := w.Map(0, inFile, 1, WordCountMap); err != nil {
+					t.Fatal(err)
+				}
+
+				writeIntermediate(t, w, 1, 0, test.truncated)
+
+				_, err := w.Reduce(0, 2, WordCountReduce)
+
+				if !tolerate {
+					if err == nil {
+						t.Fatalf("%s (sorted %v): truncated file decoded without TolerateTruncation", test.name, sorted)
+					}
+
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("%s (sorted %v): %v", test.name, sorted, err)
+				}
+
+				expected := map[string]string{"a": "2", "b": "2", "c": "1"}
+
+				if test.name == "array" {
+					expected["c"] = "2"
+				}
+
+				checkOutput(t, readOutput(t, w, 1), expected)
+
+				if !strings.Contains(logs.String(), "truncated") {
+					t.Fatalf("%s (sorted %v): logged %q, expected a truncation warning", test.name, sorted, logs.String())
+				}
+			}
+		}
+	}
+}
+
+//
+// TestTolerateTruncationCorrupt
+//
+// Checks that corruption within an intermediate file, rather than at its end, still fails
+// the Reduce task when tolerating truncation.
+//
+func TestTolerateTruncationCorrupt(t *testing.T) {
+	for _, contents := range []string{
+		`{"Key":"a","Value":"1"}` + "\n" + `{"Key":"b",,"Value":"1"}` + "\n" + `{"Key":"c","Value":"1"}` + "\n",
+		`[{"Key":"a","Value":"1"},}{"Key":"b","Value":"1"}]`,
+	} {
+		for _, sorted := range []bool{false, true} {
+			w := newTestWorker(t, "truncation")
+			w.PartitionsSorted   = sorted
+			w.TolerateTruncation = true
+			w.Logger             = log.New(&bytes.Buffer{}, "", 0)
+
+			writeIntermediate(t, w, 0, 0, contents)
+
+			if _, err := w.Reduce(0, 1, WordCountReduce); err == nil {
+				t.Fatalf("sorted %v: corrupt file %q decoded", sorted, contents)
+			}
+		}
+	}
+}
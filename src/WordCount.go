@@ -0,0 +1,119 @@
+//
+// WordCount.go
+//
+// This file contains a reusable word count job: the reference Map and Reduce functions for
+// counting the words of the input files (see RunJob).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"strconv"
+)
+
+//
+// WordCountMap
+//
+// Counts the words of an input file, splitting its contents on every character that is not
+// a letter (see Tokenize). Letters of every script count, so "naïve" and "日本語" are each one
+// word. Words are case-sensitive.
+//
+//      file     - the name of the input file
+//      contents - the contents of the input file
+//
+// Returns one KeyValue per distinct word, in the order first seen, with the word's count as
+// its value.
+//
+func WordCountMap(file string, contents string) []KeyValue {
+	return NewWordCountMap(Tokenize)(file, contents)
+}
+
+//
+// NewWordCountMap
+//
+// Builds a word count Map function like WordCountMap, with a custom tokenizer (e.g. the
+// Tokenize method of a Tokenizer that folds case, or keeps digits in words).
+//
+//      tokenize - splits contents into words
+//
+// Returns the Map function.
+//
+func NewWordCountMap(tokenize func(contents string) []string) func(file string, contents string) []KeyValue {
+	return func(file string, contents string) []KeyValue {
+		counts    := make(map[string]int)
+		keyValues := make([]KeyValue, 0)
+
+		for _, word := range tokenize(contents) {
+			if _, exists := counts[word]; !exists {
+				keyValues = append(keyValues, KeyValue{Key: word})
+			}
+
+			counts[word]++
+		}
+
+		for i := range keyValues {
+			keyValues[i].Value = strconv.Itoa(counts[keyValues[i].Key])
+		}
+
+		return keyValues
+	}
+}
+
+//
+// WordCountReduce
+//
+// Sums the counts of a word. Since a sum accepts its own output as input, it can also be
+// used as a Combiner, and with AppendOutput.
+//
+//      key    - the word
+//      values - the counts of the word
+//
+// Returns the total count, or "error" if a count is not an integer.
+//
+func WordCountReduce(key string, values []string) string {
+	var total int = 0
+
+	for _, value := range values {
+		count, tempErr := strconv.Atoi(value)
+
+		if tempErr != nil {
+			// Do not log. Propogate error to caller via output value.
+			return "error"
+		}
+
+		total += count
+	}
+
+	return strconv.Itoa(total)
+}
+
+//
+// Tokenize
+//
+// Splits text into words on every character that is not a Unicode letter or combining mark
+// (see Tokenizer).
+//
+//      contents - the text to be split
+//
+// Returns the words, in order.
+//
+func Tokenize(contents string) []string {
+	return (&Tokenizer{}).Tokenize(contents)
+}
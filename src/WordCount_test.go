@@ -0,0 +1,104 @@
+//
+// WordCount_test.go
+//
+// This file contains tests for the word count reference job.
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+//
+// TestWordCountMap
+//
+// Checks that WordCountMap emits each distinct word once, in the order first seen, with its
+// count, splitting on every character that is not a letter.
+//
+func TestWordCountMap(t *testing.T) {
+	keyValues := WordCountMap("input", "The cat, the hat; naïve CAT-cat 42 naïve\n")
+
+	expected := []KeyValue{
+		{"The", "1"},
+		{"cat", "2"},
+		{"the", "1"},
+		{"hat", "1"},
+		{"naïve", "2"},
+		{"CAT", "1"},
+	}
+
+	if !slices.Equal(keyValues, expected) {
+		t.Fatalf("emitted %v, expected %v", keyValues, expected)
+	}
+
+	if keyValues := WordCountMap("empty", " 1 2, 3 "); len(keyValues) != 0 {
+		t.Fatalf("emitted %v for input without words", keyValues)
+	}
+}
+
+//
+// TestNewWordCountMap
+//
+// Checks that NewWordCountMap splits contents with the tokenizer it is given.
+//
+func TestNewWordCountMap(t *testing.T) {
+	keyValues := NewWordCountMap(strings.Fields)("input", "cat-cat 42 cat-cat 日本語")
+
+	expected := []KeyValue{{"cat-cat", "2"}, {"42", "1"}, {"日本語", "1"}}
+
+	if !slices.Equal(keyValues, expected) {
+		t.Fatalf("emitted %v, expected %v", keyValues, expected)
+	}
+}
+
+//
+// TestWordCountUnicode
+//
+// Runs the word count job over inputs in several scripts, and checks that words of every
+// script are counted, across Map tasks and partitions.
+//
+func TestWordCountUnicode(t *testing.T) {
+	w := newTestWorker(t, "unicode")
+
+	inputs := []string{"naïve café 日本語 Ωmega", "café, naïve! Ωmega Ωmega"}
+
+	expected := map[string]string{"naïve": "2", "café": "2", "日本語": "1", "Ωmega": "3"}
+
+	checkOutput(t, runWordCount(t, w, inputs, 3), expected)
+}
+
+//
+// TestWordCountReduce
+//
+// Checks that WordCountReduce sums counts, and returns "error" for a count that is not an
+// integer.
+//
+func TestWordCountReduce(t *testing.T) {
+	if total := WordCountReduce("word", []string{"1", "20", "300"}); total != "321" {
+		t.Fatalf("total %s, expected 321", total)
+	}
+
+	if total := WordCountReduce("word", []string{"1", "x"}); total != "error" {
+		t.Fatalf("total %s of an invalid count, expected error", total)
+	}
+}
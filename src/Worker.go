@@ -0,0 +1,505 @@
+//
+// Worker.go
+//
+// This file contains the configuration shared by 'map' and 'reduce' workers (see DoMap.go
+// and DoReduce.go).
+//
+// The MIT License (MIT)
+//
+// Copyright (c) 2023 Luke Andrews.  All Rights Reserved.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of this
+// software and associated documentation files (the "Software"), to deal in the Software
+// without restriction, including without limitation the rights to use, copy, modify, merge,
+// publish, distribute, sub-license, and/or sell copies of the Software, and to permit persons
+// to whom the Software is furnished to do so, subject to the following conditions:
+//
+// * The above copyright notice and this permission notice shall be included in all copies or
+// substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR IMPLIED, 
+// INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS FOR A PARTICULAR
+// PURPOSE AND NONINFRINGEMENT.  IN NO EVENT SHALL THE AUTHORS OR COPYRIGHT HOLDERS BE LIABLE
+// FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR 
+// OTHERWISE, ARISING FROM, OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER
+// DEALINGS IN THE SOFTWARE.
+//
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//
+// Worker
+//
+// Holds the configuration shared by the Map and Reduce tasks of a job, so that it can be
+// reused across tasks. Apart from JobName, the zero value of every field is the default,
+// which means (&Worker{JobName: jobName}).MapTask(...) behaves like doMap(jobName, ...).
+//
+//      JobName             - the name of the MapReduce job
+//      RunID               - identifies this run of the job in intermediate file and
+//                            manifest names, so that overlapping runs of the same job do
+//                            not overwrite each other's files (empty means none, which keeps
+//                            the names deterministic; see NewRunID)
+//      OutDir              - the directory that intermediate and merge files are written
+//                            to and read from (empty means the current directory)
+//      Config              - the job's user configuration, passed read-only to map and
+//                            reduce functions in their context (see MapContext and
+//                            ReduceContext), so that they need no globals (nil means none)
+//      Storage             - the backend that input, intermediate and merge files are read
+//                            from and written to, e.g. a MemoryStorage (nil means
+//                            OSStorage, the local filesystem; see Storage.go)
+//      FileMode            - the permissions of the files the default storage creates,
+//                            e.g. 0640, applied exactly rather than masked by the umask
+//                            (zero means os.Create's 0666, masked by the umask)
+//      Naming              - the scheme used to name intermediate and merge files (see
+//                            Naming.go)
+//      PartitionDirs       - whether intermediate files are kept in a subdirectory per
+//                            Reduce task, <OutDir>/r<reduceTaskNumber>, created by Map as
+//                            needed (false keeps them in OutDir). Combined files hold
+//                            every partition, so stay in OutDir
+//      Partitioner         - assigns each key to a Reduce partition (nil means
+//                            partitionFor)
+//      PartitionKey        - derives the key Map partitions a KeyValue by, so related keys
+//                            (e.g. user+date keys of one user) go to the same Reduce task,
+//                            while the whole KeyValue is still written (nil means kv.Key)
+//      Logger              - receives task errors (nil means standard output)
+//      Events              - receives the lifecycle events of MapTask and ReduceTask,
+//                            without blocking them (nil means none; see Events.go)
+//      Timeout             - the maximum time each mapFunc/reduceFunc call may run (zero
+//                            means no timeout)
+//      MaxOutputRecords    - the maximum number of KeyValues one mapFunc call may emit
+//                            before Map fails, to catch runaway map functions (zero means
+//                            unlimited)
+//      SampleRate          - the fraction of input records Map maps, selected
+//                            deterministically, for approximate jobs (zero means every
+//                            record; see Sampling.go)
+//      SampleSeed          - the seed that selects the sampled records; the same seed
+//                            selects the same records
+//      SortPartitions      - whether Map sorts each partition's KeyValues by key before
+//                            encoding
+//      CanonicalPartitions - whether Map sorts each partition's KeyValues by key, then
+//                            value, before encoding, so that the same input gives
+//                            byte-identical intermediate files
+//      PartitionCapacity   - the number of KeyValues Map preallocates each partition for,
+//                            from an estimate of its output, to avoid growing it
+//                            repeatedly (zero means partitions grow as needed; see
+//                            EstimatePartitionCapacity)
+//      WriteBufferSize     - the size of Map's write buffer for each intermediate file
+//                            (zero means defaultWriteBufferSize)
+//      CreateLimiter       - consulted by Map before creating each file, e.g. a
+//                            TokenBucket shared by every task to cap the create rate of a
+//                            cluster (nil means unlimited)
+//      Compression         - how Map compresses each intermediate file (Reduce detects it)
+//      CombinedLayout      - whether Map writes one combined file holding every partition,
+//                            with an index of their offsets, rather than one file per
+//                            partition (see Combined.go)
+//      PreShuffled         - whether Reduce reads its partition from a single file shuffled
+//                            outside the job (see NamingScheme.ShuffleName), rather than
+//                            from each Map task's output; when PartitionsSorted, the file
+//                            must be sorted as a whole (see Shuffled.go)
+//      Combiner            - combines the values of a key in Map's buffered output, like a
+//                            Reduce function (nil means no combining)
+//      CombineThreshold    - the size, in bytes, a partition's buffered output must grow
+//                            past before Map combines it (zero means never)
+//      CombineBudget       - the size, in bytes, Map's buffered output across all
+//                            partitions must grow past before Map combines every partition
+//                            and flushes it to its intermediate file (zero means never
+//                            flush early)
+//      SegmentSize         - the size, in bytes, an intermediate file must grow past
+//                            before Map rolls the partition over to a new segment file
+//                            (zero means never roll over)
+//      ReadBufferSize      - the size of Reduce's read buffer for each decoded file (zero
+//                            means defaultReadBufferSize)
+//      MmapIntermediate    - whether Reduce memory maps each intermediate file to decode
+//                            it, which can speed up reads from local disk. Small files,
+//                            combined files, and platforms without mmap are read normally
+//      MapTasks            - the Map tasks whose intermediate files Reduce reads and
+//                            cleans up, e.g. to exclude superseded outputs (nil means
+//                            every task, 0 to nMap-1)
+//      AppendOutput        - whether Reduce folds its results into an existing merge file,
+//                            rather than overwrite it. A key in both is re-reduced by
+//                            calling reduceFunc with [existing, new], so reduceFunc must
+//                            accept its own output as input (e.g. a sum, but not a count of
+//                            values). The merge file is read back as JSON, so a task with
+//                            any other output fails before reducing (see OutputFormat)
+//      SkipCompleted       - whether Reduce writes a completion marker, holding its merge
+//                            file's size and checksum, beside the merge file, and returns
+//                            at once from a task whose merge file matches its marker, so
+//                            that a retried task is a no-op. The marker does not cover the
+//                            intermediate files, so a task with AppendOutput, which must
+//                            fold in every run's new ones, fails instead
+//      OnIntermediate      - called by Reduce with each KeyValue it decodes from the
+//                            intermediate files, before normalizing, deduplicating or
+//                            bounding to a key range, e.g. to sample or count them; never
+//                            called concurrently (nil means none). With PartitionsSorted,
+//                            Reduce stops reading past the end of its key range, so the
+//                            KeyValues after it are not observed
+//      Normalize           - transforms each key before Reduce groups it, e.g. to fold
+//                            case. Keys that normalize alike must also be assigned the same
+//                            partition, e.g. by a Partitioner that normalizes too (nil
+//                            means keys are grouped as emitted)
+//      KeyEquality         - groups the keys Reduce reduces (after normalizing) by a custom
+//                            equality, e.g. numerically, passing reduceFunc each group's
+//                            canonical key. Equal keys must be assigned the same partition,
+//                            and each key is compared with every group, so it is slow for
+//                            many distinct keys (see KeyEquality.go; nil means string
+//                            equality)
+//      KeyRange            - bounds Reduce to the keys in a range (after normalizing),
+//                            written to a merge file qualified with the range, so that
+//                            disjoint ranges can split a skewed partition between several
+//                            Reduce calls (nil means every key)
+//      TolerateTruncation  - whether Reduce keeps the KeyValues of an intermediate file
+//                            that ends part way through a KeyValue, e.g. written by a Map
+//                            task that crashed, and logs a warning (false fails the task;
+//                            corruption within a file always fails it)
+//      StrictDecoding      - whether Reduce rejects intermediate KeyValues with fields other
+//                            than Key and Value, naming the file, record and byte offset
+//                            (false ignores extra fields, as encoding/json does)
+//      KeyFilter           - selects the keys Reduce groups and reduces (after
+//                            normalizing), e.g. a whitelist (see KeySet and BloomFilter,
+//                            which lets a few other keys through); other keys are dropped
+//                            as decoded, and left out of the merge file (nil means every
+//                            key)
+//      ReduceParallelism   - the maximum number of keys Reduce calls reduceFunc for at
+//                            once, on a pool of goroutines, so reduceFunc must be safe for
+//                            concurrent use. Results keep their serial order, and a sorted
+//                            merge is always reduced serially (zero or one means one key at
+//                            a time)
+//      Finalize            - transforms each key's reduced value once, after Reduce (and
+//                            appending), e.g. to divide a sum by a count. Its results may
+//                            not be valid reduceFunc input, so it does not suit
+//                            AppendOutput (nil means the reduced value is written as is)
+//      OutputFilter        - selects which of Reduce's results (after appending) are
+//                            written to its merge file, e.g. TopN (nil means all of them)
+//      StrictUniqueKeys    - whether ReduceMulti fails the task when an output key is
+//                            emitted more than once, e.g. by the calls for two keys, to
+//                            catch bugs in expanding reducers (false writes them all)
+//      SideOutputs         - the names of the side outputs ReduceSide may emit to. Every
+//                            call of reduceFunc may emit, including the partial reduces of
+//                            MaxValuesPerKey and the re-reduce of AppendOutput, not only
+//                            the final call for a key (see ReduceSide)
+//      InsertionOrder      - whether Reduce writes keys in the order first decoded (by Map
+//                            task, then by file order), for output that is stable across
+//                            runs without sorting (false means random order; a sorted merge
+//                            always writes keys in sorted order)
+//      SortOutput          - whether Reduce sorts its merge file by key, as required by
+//                            FinalMerge and ConcatMerge
+//      DedupFinalMerge     - whether FinalMerge drops KeyValues identical to an earlier one,
+//                            e.g. a key written by two partitions under a faulty
+//                            Partitioner, for set-like outputs (see Merge.go)
+//      OutputFormat        - how Reduce encodes its merge file (see Format.go)
+//      RecordSeparator     - ends each record Reduce and ConvertMerge write, e.g. "\r\n"
+//                            for Windows consumers or "\x00" for xargs -0 ("" means "\n")
+//      OutputCompression   - how Reduce compresses its merge file, which then has the
+//                            compression's extension, e.g. ".gz"; readers of merge files
+//                            detect it (see Compression.go)
+//      MaxValuesPerKey     - the number of values of a key at which Reduce reduces them to
+//                            one partial value (zero means unlimited)
+//      DistinctValues      - whether Reduce passes only the distinct values of each key to
+//                            reduceFunc, in the order first seen, rather than every value
+//                            emitted. With MaxValuesPerKey, values are only deduplicated
+//                            within each partially reduced chunk
+//      Reducers            - Reduce functions for the keys starting with each prefix, used
+//                            by Reduce in place of its reduceFunc, including when partially
+//                            reducing and appending (see Dispatch.go)
+//      ReducerFor          - selects the Reduce function for a key, taking precedence over
+//                            Reducers (nil, or a nil result, means none)
+//      InputFilter         - selects the input files RunJob and MapGroup map, e.g. by
+//                            extension (see Extensions); other files are skipped before
+//                            the Map tasks are numbered (nil means every file)
+//      SplitSize           - the target size, in bytes, of the input splits RunJob maps
+//                            with one Map task each, so a large file is mapped in parallel
+//                            (zero means one Map task per input file; see Split.go)
+//      Parallelism         - the maximum number of tasks RunJob runs at once (zero means
+//                            one)
+//      EmitManifest        - whether RunJob writes the job's manifest once it completes;
+//                            an OutputFormat and RecordSeparator whose records it cannot
+//                            count fail the job before it runs (see Manifest.go)
+//      PartitionsSorted    - whether the intermediate files were written with
+//                            SortPartitions, so Reduce can merge them as streams, holding
+//                            one key's values at a time and writing keys in sorted order,
+//                            rather than group them in a map. Normalize and MaxValuesPerKey
+//                            then do not apply, and KeyEquality is an error
+//      PipelineReads       - whether Reduce decodes its sorted intermediate files on a
+//                            goroutine of its own, overlapping reading with reducing, for
+//                            the same merge file (requires PartitionsSorted; see
+//                            Pipeline.go)
+//      Durable             - whether Map, Reduce and WriteManifest sync each file they
+//                            write to disk (and, after renaming a file, its directory)
+//                            before reporting completion, so completed outputs survive a
+//                            crash. This makes every task wait for the disk, which costs
+//                            from milliseconds (SSD) to tens of milliseconds (HDD) per
+//                            file, so it is off by default
+//      DisableHTMLEscape   - whether Map and Reduce leave '<', '>' and '&' unescaped when
+//                            encoding JSON, for smaller files that match non-escaping
+//                            encoders byte for byte (false escapes them, as encoding/json
+//                            does by default)
+//      CleanupIntermediate - whether Reduce removes the intermediate files it read once
+//                            its merge file is written (false keeps them, e.g. for
+//                            debugging)
+//
+type Worker struct {
+	JobName             string
+	RunID               string
+	OutDir              string
+	Config              map[string]string
+	Storage             Storage
+	FileMode            fs.FileMode
+	Naming              NamingScheme
+	PartitionDirs       bool
+	Partitioner         func(key string, nReduce int) int
+	PartitionKey        func(kv KeyValue) string
+	Logger              *log.Logger
+	Events              chan<- TaskEvent
+	Timeout             time.Duration
+	MaxOutputRecords    int
+	SampleRate          float64
+	SampleSeed          int64
+	SortPartitions      bool
+	CanonicalPartitions bool
+	PartitionCapacity   int
+	WriteBufferSize     int
+	CreateLimiter       Limiter
+	Compression         Compression
+	CombinedLayout      bool
+	PreShuffled         bool
+	Combiner            func(key string, values []string) string
+	CombineThreshold    int
+	CombineBudget       int
+	SegmentSize         int64
+	ReadBufferSize      int
+	MmapIntermediate    bool
+	MapTasks            []int
+	AppendOutput        bool
+	SkipCompleted       bool
+	OnIntermediate      func(kv KeyValue)
+	Normalize           func(key string) string
+	KeyEquality         *KeyEquality
+	KeyRange            *KeyRange
+	TolerateTruncation  bool
+	StrictDecoding      bool
+	KeyFilter           func(key string) bool
+	ReduceParallelism   int
+	Finalize            func(key string, combined string) string
+	OutputFilter        func(keyValues []KeyValue) []KeyValue
+	StrictUniqueKeys    bool
+	SideOutputs         []string
+	InsertionOrder      bool
+	SortOutput          bool
+	DedupFinalMerge     bool
+	OutputFormat        OutputFormat
+	RecordSeparator     string
+	OutputCompression   Compression
+	MaxValuesPerKey     int
+	DistinctValues      bool
+	Reducers            map[string]func(key string, values []string) string
+	ReducerFor          func(key string) func(key string, values []string) string
+	Durable             bool
+	DisableHTMLEscape   bool
+	CleanupIntermediate bool
+	PartitionsSorted    bool
+	PipelineReads       bool
+	InputFilter         func(name string) bool
+	SplitSize           int64
+	Parallelism         int
+	EmitManifest        bool
+}
+
+//
+// reduceName
+//
+// Builds the path of an intermediate file from the Worker's naming scheme, run identifier,
+// and output directory (or the Reduce task's subdirectory of it; see partitionDir).
+//
+//      mapTaskNumber    - the number of the Map task that writes the file
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//
+// Returns the intermediate file path.
+//
+func (w *Worker) reduceName(mapTaskNumber int, reduceTaskNumber int) string {
+	jobName := w.JobName
+
+	if w.RunID != "" {
+		jobName += "." + w.RunID
+	}
+
+	return filepath.Join(w.partitionDir(reduceTaskNumber), w.Naming.ReduceName(jobName, mapTaskNumber, reduceTaskNumber))
+}
+
+//
+// shuffleName
+//
+// Builds the path of a Reduce task's pre-shuffled file from the Worker's naming scheme, run
+// identifier, and output directory (see Worker.PreShuffled).
+//
+//      reduceTaskNumber - the number of the Reduce task that reads the file
+//
+// Returns the pre-shuffled file path.
+//
+func (w *Worker) shuffleName(reduceTaskNumber int) string {
+	jobName := w.JobName
+
+	if w.RunID != "" {
+		jobName += "." + w.RunID
+	}
+
+	return filepath.Join(w.OutDir, w.Naming.ShuffleName(jobName, reduceTaskNumber))
+}
+
+//
+// partitionDir
+//
+// Builds the path of the directory holding the intermediate files of a Reduce task: the
+// output directory, or its subdirectory r<reduceTaskNumber> when using partition
+// directories (see Worker.PartitionDirs).
+//
+//      reduceTaskNumber - the number of the Reduce task that reads the files
+//
+// Returns the directory path.
+//
+func (w *Worker) partitionDir(reduceTaskNumber int) string {
+	if w.PartitionDirs {
+		return filepath.Join(w.OutDir, "r"+strconv.Itoa(reduceTaskNumber))
+	}
+
+	return w.OutDir
+}
+
+//
+// combinedName
+//
+// Builds the path of a combined intermediate file from the Worker's naming scheme, run
+// identifier, and output directory (see reduceName).
+//
+//      mapTaskNumber - the number of the Map task that writes the file
+//
+// Returns the combined file path.
+//
+func (w *Worker) combinedName(mapTaskNumber int) string {
+	jobName := w.JobName
+
+	if w.RunID != "" {
+		jobName += "." + w.RunID
+	}
+
+	return filepath.Join(w.OutDir, w.Naming.CombinedName(jobName, mapTaskNumber))
+}
+
+//
+// mergeName
+//
+// Builds the path of a merge file (see mergeStem), with the extension of the Worker's output
+// compression, if any (see Compression.extension).
+//
+//      reduceTaskNumber - the number of the Reduce task that writes the file
+//
+// Returns the merge file path.
+//
+func (w *Worker) mergeName(reduceTaskNumber int) string {
+	return w.mergeStem(reduceTaskNumber) + w.OutputCompression.extension()
+}
+
+//
+// mergeStem
+//
+// Builds the path of a merge file from the Worker's naming scheme and output directory,
+// qualified with the Worker's key range, if any (see KeyRange.suffix), but without an
+// extension for compression.
+//
+//      reduceTaskNumber - the number of the Reduce task that writes the file
+//
+// Returns the merge file path, without its compression extension.
+//
+func (w *Worker) mergeStem(reduceTaskNumber int) string {
+	return filepath.Join(w.OutDir, w.Naming.MergeName(w.JobName, reduceTaskNumber)) + w.KeyRange.suffix()
+}
+
+//
+// partitionFor
+//
+// Determines the Reduce partition a key is assigned to by the Worker's partitioner.
+//
+//      key     - the key to be partitioned
+//      nReduce - the number of Reduce tasks that will be run
+//
+// Returns the partition number, in the range [0, nReduce).
+//
+func (w *Worker) partitionFor(key string, nReduce int) int {
+	if w.Partitioner == nil {
+		return partitionFor(key, nReduce)
+	}
+
+	return w.Partitioner(key, nReduce)
+}
+
+//
+// partitionKey
+//
+// Derives the key a KeyValue is partitioned by, using the Worker's PartitionKey.
+//
+//      kv - the KeyValue to be partitioned
+//
+// Returns the partitioning key (kv.Key, if PartitionKey is nil).
+//
+func (w *Worker) partitionKey(kv KeyValue) string {
+	if w.PartitionKey == nil {
+		return kv.Key
+	}
+
+	return w.PartitionKey(kv)
+}
+
+//
+// waitCreate
+//
+// Waits until the Worker's CreateLimiter, if any, allows a file to be created.
+//
+func (w *Worker) waitCreate() {
+	if w.CreateLimiter != nil {
+		w.CreateLimiter.Wait()
+	}
+}
+
+//
+// mapTasks
+//
+// Lists the Map tasks whose intermediate files are read by the Worker's Reduce tasks.
+//
+//      nMap - the number of Map tasks that were run
+//
+// Returns MapTasks if set. Otherwise, every Map task number from 0 to nMap-1.
+//
+func (w *Worker) mapTasks(nMap int) []int {
+	if w.MapTasks != nil {
+		return w.MapTasks
+	}
+
+	mapTasks := make([]int, nMap)
+
+	for i := 0; i < nMap; i++ {
+		mapTasks[i] = i
+	}
+
+	return mapTasks
+}
+
+//
+// logf
+//
+// Reports a message through the Worker's logger, or to standard output if there is none.
+//
+//      format - the format of the message (see fmt.Printf)
+//      args   - the arguments of the format
+//
+func (w *Worker) logf(format string, args ...any) {
+	if w.Logger == nil {
+		fmt.Printf(format, args...)
+	} else {
+		w.Logger.Printf(format, args...)
+	}
+}